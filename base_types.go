@@ -8,6 +8,14 @@ const (
 	Red
 )
 
+// Opponent returns the other player.
+func (c Color) Opponent() Color {
+	if c == Yellow {
+		return Red
+	}
+	return Yellow
+}
+
 type MoveType byte
 
 const (
@@ -50,9 +58,21 @@ type Draw struct{}
 type YellowWins struct{}
 type RedWins struct{}
 
+// DrawByNoProgress ends a game after too many consecutive moves without a token drop.
+type DrawByNoProgress struct{}
+
+// DrawByMoveLimit ends a game which reached its maximum number of moves.
+type DrawByMoveLimit struct{}
+
 func (err Draw) Error() string {
 	return "draw"
 }
+func (err DrawByNoProgress) Error() string {
+	return "draw by lack of progress"
+}
+func (err DrawByMoveLimit) Error() string {
+	return "draw by move limit"
+}
 func (err YellowWins) Error() string {
 	return "yellow wins"
 }
@@ -60,8 +80,32 @@ func (err RedWins) Error() string {
 	return "red wins"
 }
 
+// IsWin returns whether the end of the game denoted by err is a win for color.
+func IsWin(err error, color Color) bool {
+	switch err.(type) {
+	case YellowWins:
+		return color == Yellow
+	case RedWins:
+		return color == Red
+	}
+	return false
+}
+
 type ErrorInvalidMove struct{}
 
 func (err ErrorInvalidMove) Error() string {
 	return "invalid move"
 }
+
+// ErrorNoOpTilt is returned for a tilt which leaves the board unchanged, when such tilts are forbidden.
+//
+// It is a specific invalid move: errors.Is(err, ErrorInvalidMove{}) holds.
+type ErrorNoOpTilt struct{}
+
+func (err ErrorNoOpTilt) Error() string {
+	return "invalid move: tilt leaves the board unchanged"
+}
+
+func (err ErrorNoOpTilt) Is(target error) bool {
+	return target == ErrorInvalidMove{}
+}
@@ -1,6 +1,7 @@
 package g4_test
 
 import (
+	"errors"
 	"g4"
 	"testing"
 )
@@ -34,3 +35,60 @@ func TestShorthands(t *testing.T) {
 		}
 	}
 }
+
+func TestOpponent(t *testing.T) {
+	if got := g4.Yellow.Opponent(); got != g4.Red {
+		t.Errorf("got %v but want red", got)
+	}
+	if got := g4.Red.Opponent(); got != g4.Yellow {
+		t.Errorf("got %v but want yellow", got)
+	}
+}
+
+func TestDrawErrors(t *testing.T) {
+	examples := []struct {
+		err  error
+		want string
+	}{
+		{err: g4.Draw{}, want: "draw"},
+		{err: g4.DrawByNoProgress{}, want: "draw by lack of progress"},
+		{err: g4.DrawByMoveLimit{}, want: "draw by move limit"},
+	}
+	for k, ex := range examples {
+		if got := ex.err.Error(); got != ex.want {
+			t.Errorf("example %d: got '%s' but want '%s'", k, got, ex.want)
+		}
+	}
+}
+
+func TestErrorNoOpTilt(t *testing.T) {
+	var err error = g4.ErrorNoOpTilt{}
+	if !errors.Is(err, g4.ErrorInvalidMove{}) {
+		t.Errorf("expected ErrorNoOpTilt to be an invalid move")
+	}
+	if errors.Is(g4.ErrorInvalidMove{}, g4.ErrorNoOpTilt{}) {
+		t.Errorf("expected ErrorInvalidMove not to be a no-op tilt")
+	}
+}
+
+func TestIsWin(t *testing.T) {
+	examples := []struct {
+		err   error
+		color g4.Color
+		want  bool
+	}{
+		{g4.YellowWins{}, g4.Yellow, true},
+		{g4.YellowWins{}, g4.Red, false},
+		{g4.RedWins{}, g4.Red, true},
+		{g4.RedWins{}, g4.Yellow, false},
+		{g4.Draw{}, g4.Yellow, false},
+		{g4.DrawByMoveLimit{}, g4.Red, false},
+		{nil, g4.Yellow, false},
+		{g4.ErrorInvalidMove{}, g4.Red, false},
+	}
+	for k, ex := range examples {
+		if got := g4.IsWin(ex.err, ex.color); got != ex.want {
+			t.Errorf("example %d: got %v but want %v", k, got, ex.want)
+		}
+	}
+}
@@ -117,6 +117,48 @@ func (b bitboard) northEast3() bitboard {
 	return (b << 27) & north3Mask
 }
 
+// rowsBelow returns the bitboard of the `n` lowest rows of every column.
+func rowsBelow(n int) bitboard {
+	return (one<<n - 1) * 0x0101010101010101
+}
+
+// shift moves every bit by `dc` columns and `dr` rows.
+//
+// Bits moved out of the board are lost.
+func (b bitboard) shift(dc, dr int) bitboard {
+	switch {
+	case dr > 0:
+		b = (b << dr) &^ rowsBelow(dr)
+	case dr < 0:
+		b = (b >> -dr) & rowsBelow(8+dr)
+	}
+	switch {
+	case dc > 0:
+		b <<= 8 * dc
+	case dc < 0:
+		b >>= 8 * -dc
+	}
+	return b
+}
+
+// threats returns the squares which would complete a connect 4 pattern.
+//
+// Squares already set are included as well.
+func (b bitboard) threats() bitboard {
+	var t bitboard
+	for _, d := range [...][2]int{{0, 1}, {1, 0}, {1, 1}, {1, -1}} {
+		// at(k) has a bit on square e when b has one on e + k*d.
+		at := func(k int) bitboard {
+			return b.shift(-k*d[0], -k*d[1])
+		}
+		t |= at(1)&at(2)&at(3) |
+			at(-1)&at(1)&at(2) |
+			at(-2)&at(-1)&at(1) |
+			at(-3)&at(-2)&at(-1)
+	}
+	return t
+}
+
 // hasConnect4 returns whether the bitboard has a connect 4 pattern.
 // The pattern can occur horizontally, vertically or diagonally.
 func (b bitboard) hasConnect4() bool {
@@ -586,3 +586,88 @@ func TestBitboardRotateLeft(t *testing.T) {
 		}
 	}
 }
+
+func TestBitboardShift(t *testing.T) {
+	examples := []struct {
+		in     string
+		dc, dr int
+		out    string
+	}{
+		{
+			in:  "x6x|8|8|8|8|8|8|8",
+			dc:  0,
+			dr:  1,
+			out: "1x6|8|8|8|8|8|8|8",
+		},
+		{
+			in:  "x6x|8|8|8|8|8|8|8",
+			dc:  0,
+			dr:  -2,
+			out: "5x2|8|8|8|8|8|8|8",
+		},
+		{
+			in:  "x6x|8|8|8|8|8|8|xx6",
+			dc:  1,
+			dr:  0,
+			out: "8|x6x|8|8|8|8|8|8",
+		},
+		{
+			in:  "x6x|8|8|8|8|8|8|xx6",
+			dc:  -1,
+			dr:  1,
+			out: "8|8|8|8|8|8|1xx5|8",
+		},
+		{
+			in:  "xxxxxxxx|8|8|8|8|8|8|8",
+			dc:  3,
+			dr:  -3,
+			out: "8|8|8|xxxxx3|8|8|8|8",
+		},
+	}
+	for k, ex := range examples {
+		in, _ := bitboardFromString(ex.in)
+		want, _ := bitboardFromString(ex.out)
+		if got := in.shift(ex.dc, ex.dr); got != want {
+			t.Errorf("example %d: got %v but want %v", k, got, want)
+		}
+	}
+}
+
+func TestBitboardThreats(t *testing.T) {
+	examples := []struct {
+		in  string
+		out string
+	}{
+		{
+			in:  "8|8|8|8|8|8|8|8",
+			out: "8|8|8|8|8|8|8|8",
+		},
+		{
+			in:  "xxx5|8|8|8|8|8|8|8",
+			out: "3x4|8|8|8|8|8|8|8",
+		},
+		{
+			in:  "8|x7|x7|x7|8|8|8|8",
+			out: "x7|8|8|8|x7|8|8|8",
+		},
+		{
+			in:  "x7|x7|8|x7|8|8|8|8",
+			out: "8|8|x7|8|8|8|8|8",
+		},
+		{
+			in:  "x7|1x6|2x5|8|8|8|8|8",
+			out: "8|8|8|3x4|8|8|8|8",
+		},
+		{
+			in:  "8|2x5|1x6|x7|8|8|8|8",
+			out: "3x4|8|8|8|8|8|8|8",
+		},
+	}
+	for k, ex := range examples {
+		in, _ := bitboardFromString(ex.in)
+		want, _ := bitboardFromString(ex.out)
+		if got := in.threats() &^ in; got != want {
+			t.Errorf("example %d: got %v but want %v", k, got, want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package bitsim
 import (
 	"fmt"
 	"g4"
+	"math/bits"
 	"strconv"
 	"strings"
 )
@@ -54,11 +55,66 @@ func FromString(s string) (b Board, err error) {
 	return
 }
 
+// Board holds the tokens of a g4 board.
+//
+// Squares are addressed by column and row. Columns are numbered from 0 to 7,
+// left to right, in the order of the string representation. Rows are numbered
+// from 0 to 7, bottom to top, the bottom being where gravity pulls the tokens.
 type Board struct {
 	yellowBits bitboard
 	redBits    bitboard
 }
 
+// Cell returns the color of the token at given column and row, or g4.Empty.
+//
+// Coordinates out of the board are empty.
+func (b Board) Cell(column, row int) g4.Color {
+	if column < 0 || column >= 8 || row < 0 || row >= 8 {
+		return g4.Empty
+	}
+	mask := one << (row + 8*column)
+	if b.yellowBits&mask != 0 {
+		return g4.Yellow
+	}
+	if b.redBits&mask != 0 {
+		return g4.Red
+	}
+	return g4.Empty
+}
+
+// Set returns the board with the square at given column and row set to `color`.
+//
+// Setting g4.Empty removes the token. No gravity is applied, and coordinates
+// out of the board leave it unchanged.
+func (b Board) Set(column, row int, color g4.Color) Board {
+	if column < 0 || column >= 8 || row < 0 || row >= 8 {
+		return b
+	}
+	mask := one << (row + 8*column)
+	b.yellowBits &^= mask
+	b.redBits &^= mask
+	switch color {
+	case g4.Yellow:
+		b.yellowBits |= mask
+	case g4.Red:
+		b.redBits |= mask
+	}
+	return b
+}
+
+// Grid returns the board as an array of lines, ready to be drawn.
+//
+// grid[i][j] is the square on the i-th line from the top and the j-th column
+// from the left, that is to say Cell(j, 7-i).
+func (b Board) Grid() (grid [8][8]g4.Color) {
+	for i := range grid {
+		for j := range grid[i] {
+			grid[i][j] = b.Cell(j, 7-i)
+		}
+	}
+	return
+}
+
 // String returns the string representation of the board.
 func (b Board) String() string {
 	var s strings.Builder
@@ -92,13 +148,47 @@ func (b Board) String() string {
 	return s.String()
 }
 
-// Returns the total number of tokens on the board.
-func (b Board) count() int {
+// Tokens returns the set of squares holding a token of given color.
+//
+// Square (column, row) is the bit number row + 8*column. For g4.Empty, it
+// returns the set of empty squares.
+func (b Board) Tokens(color g4.Color) uint64 {
+	switch color {
+	case g4.Yellow:
+		return uint64(b.yellowBits)
+	case g4.Red:
+		return uint64(b.redBits)
+	}
+	return uint64(^(b.yellowBits | b.redBits))
+}
+
+// Threats returns the set of empty squares where a token of given color would make a connect 4.
+//
+// Squares are numbered as in Tokens. Gravity is not taken into account: the
+// squares need not be playable right now.
+func (b Board) Threats(color g4.Color) uint64 {
+	var own bitboard
+	switch color {
+	case g4.Yellow:
+		own = b.yellowBits
+	case g4.Red:
+		own = b.redBits
+	default:
+		return 0
+	}
+	return uint64(own.threats() &^ (b.yellowBits | b.redBits))
+}
+
+// Count returns the total number of tokens on the board.
+func (b Board) Count() int {
 	return b.yellowBits.count() + b.redBits.count()
 }
 
-// heights returns a list of heights for all the columns.
-func (b Board) heights() [8]int {
+// Heights returns a list of heights for all the columns.
+//
+// The height of a column is its number of tokens, which for a settled board
+// is also the row where the next token would land.
+func (b Board) Heights() [8]int {
 	return [8]int{
 		((b.yellowBits | b.redBits) & col0Mask).count(),
 		((b.yellowBits | b.redBits) & col1Mask).count(),
@@ -149,9 +239,30 @@ func (b Board) ApplyGravity() Board {
 	return b
 }
 
+// Mirror flips the board left to right.
+//
+// Mirroring preserves the game: tokens dropped in column c become tokens
+// dropped in column 7-c, and tilts left become tilts right.
+func (b Board) Mirror() Board {
+	return Board{
+		yellowBits: bitboard(bits.ReverseBytes64(uint64(b.yellowBits))),
+		redBits:    bitboard(bits.ReverseBytes64(uint64(b.redBits))),
+	}
+}
+
+// SwapColors turns yellow tokens into red ones and vice versa.
+func (b Board) SwapColors() Board {
+	return Board{yellowBits: b.redBits, redBits: b.yellowBits}
+}
+
+// tilt applies `times` left rotations, then gravity.
+func (b Board) tilt(times int) Board {
+	return b.RotateLeft(times).ApplyGravity()
+}
+
 // AddToken adds a token on top of requested column.
 func (b Board) AddToken(column int, color g4.Color) Board {
-	height := b.heights()[column]
+	height := b.Heights()[column]
 	if height < 8 {
 		switch color {
 		case g4.Yellow:
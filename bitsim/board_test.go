@@ -122,8 +122,8 @@ func TestBoardHeights(t *testing.T) {
 		if err != nil {
 			t.Errorf("example %d: FromString returned an error %v", k, err)
 		}
-		if in.heights() != ex.out {
-			t.Errorf("example %d: got %v but want %v", k, in.heights(), ex.out)
+		if in.Heights() != ex.out {
+			t.Errorf("example %d: got %v but want %v", k, in.Heights(), ex.out)
 		}
 	}
 }
@@ -327,6 +327,193 @@ func TestBoardAddToken(t *testing.T) {
 	}
 }
 
+func TestBoardCount(t *testing.T) {
+	examples := []struct {
+		in  string
+		out int
+	}{
+		{in: "8|8|8|8|8|8|8|8", out: 0},
+		{in: "8|8|8|8|rrryr3|ryyyyyr1|r7|yr6", out: 15},
+		{in: "ryryryry|ryryryry|ryryryry|yryryryr|yryryryr|yryryryr|ryryryry|ryryryry", out: 64},
+	}
+	for k, ex := range examples {
+		in, _ := FromString(ex.in)
+		if in.Count() != ex.out {
+			t.Errorf("example %d: got %v but want %v", k, in.Count(), ex.out)
+		}
+	}
+}
+
+func TestBoardCell(t *testing.T) {
+	board, _ := FromString("yr6|8|8|8|8|8|8|1r5y")
+	examples := []struct {
+		column, row int
+		out         g4.Color
+	}{
+		{column: 0, row: 0, out: g4.Yellow},
+		{column: 0, row: 1, out: g4.Red},
+		{column: 0, row: 2, out: g4.Empty},
+		{column: 7, row: 1, out: g4.Red},
+		{column: 7, row: 7, out: g4.Yellow},
+		{column: 3, row: 3, out: g4.Empty},
+		{column: -1, row: 0, out: g4.Empty},
+		{column: 0, row: 8, out: g4.Empty},
+	}
+	for k, ex := range examples {
+		if got := board.Cell(ex.column, ex.row); got != ex.out {
+			t.Errorf("example %d: got %v but want %v", k, got, ex.out)
+		}
+	}
+}
+
+func TestBoardSet(t *testing.T) {
+	examples := []struct {
+		in          string
+		column, row int
+		color       g4.Color
+		out         string
+	}{
+		{
+			in:     "8|8|8|8|8|8|8|8",
+			column: 2,
+			row:    0,
+			color:  g4.Yellow,
+			out:    "8|8|y7|8|8|8|8|8",
+		},
+		{
+			in:     "yr6|8|8|8|8|8|8|8",
+			column: 0,
+			row:    1,
+			color:  g4.Yellow,
+			out:    "yy6|8|8|8|8|8|8|8",
+		},
+		{
+			in:     "yr6|8|8|8|8|8|8|8",
+			column: 0,
+			row:    0,
+			color:  g4.Empty,
+			out:    "1r6|8|8|8|8|8|8|8",
+		},
+		{
+			in:     "8|8|8|8|8|8|8|8",
+			column: 7,
+			row:    7,
+			color:  g4.Red,
+			out:    "8|8|8|8|8|8|8|7r",
+		},
+		{
+			in:     "8|8|8|8|8|8|8|8",
+			column: 8,
+			row:    0,
+			color:  g4.Red,
+			out:    "8|8|8|8|8|8|8|8",
+		},
+	}
+	for k, ex := range examples {
+		got, _ := FromString(ex.in)
+		got = got.Set(ex.column, ex.row, ex.color)
+		if got.String() != ex.out {
+			t.Errorf("example %d: got '%v' but want '%v'", k, got, ex.out)
+		}
+	}
+}
+
+func TestBoardGrid(t *testing.T) {
+	board, _ := FromString("yr6|8|8|8|8|8|8|1r5y")
+	grid := board.Grid()
+	for i := range grid {
+		for j := range grid[i] {
+			var want g4.Color
+			switch {
+			case i == 7 && j == 0:
+				want = g4.Yellow
+			case i == 6 && j == 0:
+				want = g4.Red
+			case i == 6 && j == 7:
+				want = g4.Red
+			case i == 0 && j == 7:
+				want = g4.Yellow
+			}
+			if grid[i][j] != want {
+				t.Errorf("line %d, column %d: got %v but want %v", i, j, grid[i][j], want)
+			}
+		}
+	}
+}
+
+func TestBoardTokens(t *testing.T) {
+	board, _ := FromString("yr6|8|8|8|8|8|8|1r5y")
+	if got := board.Tokens(g4.Yellow); got != 1|1<<63 {
+		t.Errorf("yellow: got %x", got)
+	}
+	if got := board.Tokens(g4.Red); got != 1<<1|1<<57 {
+		t.Errorf("red: got %x", got)
+	}
+	if got := board.Tokens(g4.Empty); got != ^uint64(1|1<<63|1<<1|1<<57) {
+		t.Errorf("empty: got %x", got)
+	}
+}
+
+func TestBoardThreats(t *testing.T) {
+	examples := []struct {
+		in     string
+		yellow string
+		red    string
+	}{
+		{
+			in:     "yyy5|rr6|r7|r7|8|8|8|8",
+			yellow: "3x4|8|8|8|8|8|8|8",
+			red:    "8|8|8|8|x7|8|8|8",
+		},
+		{
+			// NB: an occupied square is not a threat.
+			in:     "yyyr4|rr6|r7|r7|8|8|8|8",
+			yellow: "8|8|8|8|8|8|8|8",
+			red:    "8|8|8|8|x7|8|8|8",
+		},
+	}
+	for k, ex := range examples {
+		board, _ := FromString(ex.in)
+		yellow, _ := bitboardFromString(ex.yellow)
+		red, _ := bitboardFromString(ex.red)
+		if got := board.Threats(g4.Yellow); got != uint64(yellow) {
+			t.Errorf("example %d: yellow: got %x but want %x", k, got, yellow)
+		}
+		if got := board.Threats(g4.Red); got != uint64(red) {
+			t.Errorf("example %d: red: got %x but want %x", k, got, red)
+		}
+	}
+}
+
+func TestBoardMirror(t *testing.T) {
+	examples := []struct {
+		in  string
+		out string
+	}{
+		{in: "8|8|8|8|8|8|8|8", out: "8|8|8|8|8|8|8|8"},
+		{in: "yr6|r7|8|8|8|8|8|yyy5", out: "yyy5|8|8|8|8|8|r7|yr6"},
+	}
+	for k, ex := range examples {
+		board, _ := FromString(ex.in)
+		if got := board.Mirror().String(); got != ex.out {
+			t.Errorf("example %d: got %s but want %s", k, got, ex.out)
+		}
+	}
+
+	// Mirroring swaps left and right tilts.
+	board, _ := FromString("yr6|r7|8|yyr5|8|8|8|8")
+	if board.tilt(1).Mirror() != board.Mirror().tilt(3) {
+		t.Errorf("mirror does not swap tilts")
+	}
+}
+
+func TestBoardSwapColors(t *testing.T) {
+	board, _ := FromString("yr6|r7|8|8|8|8|8|yyy5")
+	if got, want := board.SwapColors().String(), "ry6|y7|8|8|8|8|8|rrr5"; got != want {
+		t.Errorf("got %s but want %s", got, want)
+	}
+}
+
 // Benchmarks the performance of the String method.
 //
 // Before switching to strings.Builder, it would do 10x more allocations and be twice as slow.
@@ -344,7 +531,8 @@ func BenchmarkBoardString(b *testing.B) {
 		board, _ := FromString(ex)
 		b.Run(ex, func(b *testing.B) {
 			for i := 0; i < b.N; i++ {
-				board.String()
+				// The result is discarded explicitly, since go vet reports unused String calls.
+				_ = board.String()
 			}
 		})
 	}
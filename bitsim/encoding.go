@@ -0,0 +1,189 @@
+package bitsim
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"g4"
+)
+
+const (
+	// boardSize is the size of the binary representation of a board.
+	boardSize = 16
+	// gameSize is the size of the binary representation of a game.
+	gameSize = boardSize + 1
+)
+
+// ErrorRulesNotEncodable is returned when encoding a game with rules or move
+// counters, which the binary form does not hold.
+var ErrorRulesNotEncodable = errors.New("cannot encode the rules or the counters of a game")
+
+// MarshalBinary encodes the board on 16 bytes.
+//
+// The yellow bitboard comes first, then the red one, both in little-endian order.
+func (b Board) MarshalBinary() ([]byte, error) {
+	data := make([]byte, boardSize)
+	binary.LittleEndian.PutUint64(data, uint64(b.yellowBits))
+	binary.LittleEndian.PutUint64(data[8:], uint64(b.redBits))
+	return data, nil
+}
+
+// UnmarshalBinary decodes a board encoded by MarshalBinary.
+//
+// It fails if the decoded board does not pass Validate.
+func (b *Board) UnmarshalBinary(data []byte) error {
+	if len(data) != boardSize {
+		return fmt.Errorf("invalid board size: %d bytes", len(data))
+	}
+	board := Board{
+		yellowBits: bitboard(binary.LittleEndian.Uint64(data)),
+		redBits:    bitboard(binary.LittleEndian.Uint64(data[8:])),
+	}
+	if err := board.Validate(); err != nil {
+		return err
+	}
+	*b = board
+	return nil
+}
+
+// MarshalBinary encodes the game on 17 bytes: the board followed by the mover.
+//
+// The rules and the counters are not encoded, so that it fails with
+// ErrorRulesNotEncodable for games having any.
+func (g Game) MarshalBinary() ([]byte, error) {
+	if g.Rules != (Rules{}) || g.MoveCount != 0 || g.NoProgressCount != 0 {
+		return nil, ErrorRulesNotEncodable
+	}
+	data, _ := g.Board.MarshalBinary()
+	return append(data, byte(g.Mover)), nil
+}
+
+// UnmarshalBinary decodes a game encoded by MarshalBinary, with the standard rules.
+func (g *Game) UnmarshalBinary(data []byte) error {
+	if len(data) != gameSize {
+		return fmt.Errorf("invalid game size: %d bytes", len(data))
+	}
+	var board Board
+	if err := board.UnmarshalBinary(data[:boardSize]); err != nil {
+		return err
+	}
+	mover := g4.Color(data[boardSize])
+	if mover != g4.Yellow && mover != g4.Red {
+		return fmt.Errorf("invalid mover: %d", mover)
+	}
+	*g = Game{Board: board, Mover: mover}
+	return nil
+}
+
+// Line is a sequence of moves played from a given state.
+type Line struct {
+	Start Game
+	Moves []g4.Move
+}
+
+// MarshalBinary encodes the line as a move stream.
+//
+// The starting game comes first, followed by the number of moves and by one
+// code per move, all written as unsigned varints. Token moves are coded by their
+// column (0 to 7), and tilt moves by 8 (left), 9 (down) and 10 (right).
+// Colors are not encoded since they can be deduced from the starting mover.
+// Like games, lines cannot start with rules or counters.
+func (l Line) MarshalBinary() ([]byte, error) {
+	data, err := l.Start.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	data = appendUvarint(data, uint64(len(l.Moves)))
+	for k, move := range l.Moves {
+		code, err := moveCode(move)
+		if err != nil {
+			return nil, fmt.Errorf("move %d: %w", k, err)
+		}
+		data = appendUvarint(data, code)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes a line encoded by MarshalBinary.
+//
+// Moves are replayed from the starting game, so that decoding fails on illegal
+// moves or on moves played after the end of the game.
+func (l *Line) UnmarshalBinary(data []byte) error {
+	if len(data) < gameSize {
+		return fmt.Errorf("invalid line size: %d bytes", len(data))
+	}
+	var start Game
+	if err := start.UnmarshalBinary(data[:gameSize]); err != nil {
+		return err
+	}
+	data = data[gameSize:]
+
+	count, n := binary.Uvarint(data)
+	if n <= 0 {
+		return errors.New("invalid number of moves")
+	}
+	data = data[n:]
+	if count > uint64(len(data)) {
+		return fmt.Errorf("invalid number of moves: %d", count)
+	}
+
+	game := start
+	moves := make([]g4.Move, 0, count)
+	for k := uint64(0); k < count; k++ {
+		code, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("move %d: invalid code", k)
+		}
+		data = data[n:]
+		move, err := codeMove(code, game.Mover)
+		if err != nil {
+			return fmt.Errorf("move %d: %w", k, err)
+		}
+		if err := game.Validate(); err != nil {
+			return fmt.Errorf("move %d: game is already over: %w", k, err)
+		}
+		game, err = game.Apply(move)
+		if errors.Is(err, g4.ErrorInvalidMove{}) {
+			return fmt.Errorf("move %d: %w", k, err)
+		}
+		moves = append(moves, move)
+	}
+	if len(data) != 0 {
+		return fmt.Errorf("%d trailing bytes", len(data))
+	}
+
+	l.Start, l.Moves = start, moves
+	return nil
+}
+
+// moveCode returns the binary code of a move.
+func moveCode(move g4.Move) (uint64, error) {
+	switch move.Type {
+	case g4.Token:
+		if move.Column >= 0 && move.Column < 8 {
+			return uint64(move.Column), nil
+		}
+	case g4.Tilt:
+		if move.Direction >= g4.LEFT && move.Direction <= g4.RIGHT {
+			return 8 + uint64(move.Direction-g4.LEFT), nil
+		}
+	}
+	return 0, g4.ErrorInvalidMove{}
+}
+
+// codeMove returns the move corresponding to a binary code.
+func codeMove(code uint64, color g4.Color) (g4.Move, error) {
+	switch {
+	case code < 8:
+		return g4.TokenMove(color, int(code)), nil
+	case code <= 10:
+		return g4.TiltMove(color, g4.LEFT+g4.Direction(code-8)), nil
+	}
+	return g4.Move{}, fmt.Errorf("invalid move code: %d", code)
+}
+
+func appendUvarint(data []byte, x uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], x)
+	return append(data, buf[:n]...)
+}
@@ -0,0 +1,134 @@
+package bitsim_test
+
+import (
+	"errors"
+	"g4"
+	"g4/bitsim"
+	"reflect"
+	"testing"
+)
+
+func TestBoardBinaryRoundTrip(t *testing.T) {
+	examples := []string{
+		"8|8|8|8|8|8|8|8",
+		"y7|8|8|8|8|8|8|8",
+		"rr6|8|8|8|8|yyyyyyyy|8|8",
+		"8|8|8|8|rrryr3|ryyyyyr1|r7|yr6",
+	}
+	for k, ex := range examples {
+		board, _ := bitsim.FromString(ex)
+		data, err := board.MarshalBinary()
+		if err != nil || len(data) != 16 {
+			t.Errorf("example %d: got (%v, %v) but want 16 bytes", k, data, err)
+		}
+		var got bitsim.Board
+		if err := got.UnmarshalBinary(data); err != nil || got != board {
+			t.Errorf("example %d: got (%v, %v) but want (%v, nil)", k, got, err, board)
+		}
+	}
+}
+
+func TestBoardUnmarshalBinaryError(t *testing.T) {
+	examples := [][]byte{
+		nil,
+		make([]byte, 15),
+		make([]byte, 17),
+		{1, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0}, // NB: overlapping tokens.
+		{2, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, // NB: floating token.
+	}
+	for k, ex := range examples {
+		var board bitsim.Board
+		if err := board.UnmarshalBinary(ex); err == nil {
+			t.Errorf("example %d: expected error", k)
+		}
+	}
+}
+
+func TestGameBinaryRoundTrip(t *testing.T) {
+	board, _ := bitsim.FromString("8|8|8|8|rrryr3|ryyyyyr1|r7|yr6")
+	for _, mover := range []g4.Color{g4.Yellow, g4.Red} {
+		game := bitsim.Game{Board: board, Mover: mover}
+		data, _ := game.MarshalBinary()
+		var got bitsim.Game
+		if err := got.UnmarshalBinary(data); err != nil || got != game {
+			t.Errorf("got (%v, %v) but want (%v, nil)", got, err, game)
+		}
+	}
+
+	var game bitsim.Game
+	if err := game.UnmarshalBinary(make([]byte, 17)); err == nil {
+		t.Errorf("empty mover: expected error")
+	}
+
+	// Decoding resets the rules and the counters.
+	game = bitsim.Game{Board: board, Mover: g4.Red, Rules: bitsim.Rules{MoveLimit: 10}, MoveCount: 3, NoProgressCount: 2}
+	data, _ := bitsim.Game{Board: board, Mover: g4.Yellow}.MarshalBinary()
+	if err := game.UnmarshalBinary(data); err != nil || game != (bitsim.Game{Board: board, Mover: g4.Yellow}) {
+		t.Errorf("got (%+v, %v) but want the standard rules and no counters", game, err)
+	}
+}
+
+func TestGameMarshalBinaryError(t *testing.T) {
+	examples := []bitsim.Game{
+		{Mover: g4.Yellow, Rules: bitsim.Rules{NoProgressLimit: 4}},
+		{Mover: g4.Yellow, Rules: bitsim.Rules{ForbidNoOpTilts: true}},
+		{Mover: g4.Yellow, MoveCount: 1},
+		{Mover: g4.Yellow, NoProgressCount: 1},
+	}
+	for k, ex := range examples {
+		if _, err := ex.MarshalBinary(); !errors.Is(err, bitsim.ErrorRulesNotEncodable) {
+			t.Errorf("example %d: got %v but want ErrorRulesNotEncodable", k, err)
+		}
+		if _, err := (bitsim.Line{Start: ex}).MarshalBinary(); !errors.Is(err, bitsim.ErrorRulesNotEncodable) {
+			t.Errorf("example %d: got %v for the line but want ErrorRulesNotEncodable", k, err)
+		}
+	}
+}
+
+func TestLineBinaryRoundTrip(t *testing.T) {
+	board, _ := bitsim.FromString(bitsim.StartingPosition)
+	line := bitsim.Line{
+		Start: bitsim.Game{Board: board, Mover: g4.Yellow},
+		Moves: []g4.Move{
+			g4.TokenMove(g4.Yellow, 0),
+			g4.TokenMove(g4.Red, 7),
+			g4.TiltMove(g4.Yellow, g4.LEFT),
+			g4.TiltMove(g4.Red, g4.DOWN),
+			g4.TiltMove(g4.Yellow, g4.RIGHT),
+			g4.TokenMove(g4.Red, 3),
+		},
+	}
+	data, err := line.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(data) != 17+1+6 {
+		t.Errorf("got %d bytes but want %d", len(data), 17+1+6)
+	}
+	var got bitsim.Line
+	if err := got.UnmarshalBinary(data); err != nil || !reflect.DeepEqual(got, line) {
+		t.Errorf("got (%v, %v) but want (%v, nil)", got, err, line)
+	}
+}
+
+func TestLineUnmarshalBinaryError(t *testing.T) {
+	empty, _ := bitsim.Game{Mover: g4.Yellow}.MarshalBinary()
+	won, _ := bitsim.FromString("yyyy4|8|8|8|8|8|8|8")
+	over, _ := bitsim.Game{Board: won, Mover: g4.Red}.MarshalBinary()
+	full, _ := bitsim.FromString("ryryryry|8|8|8|8|8|8|8")
+	fullColumn, _ := bitsim.Game{Board: full, Mover: g4.Yellow}.MarshalBinary()
+	examples := [][]byte{
+		empty,                                    // NB: missing number of moves.
+		append(append([]byte{}, empty...), 2, 0), // NB: missing move.
+		append(append([]byte{}, empty...), 1, 11),     // NB: invalid code.
+		append(append([]byte{}, empty...), 1, 0, 0),   // NB: trailing byte.
+		append(append([]byte{}, over...), 1, 8),       // NB: game is over.
+		append(append([]byte{}, fullColumn...), 1, 0), // NB: column is full.
+	}
+	for k, ex := range examples {
+		var line bitsim.Line
+		if err := line.UnmarshalBinary(ex); err == nil {
+			t.Errorf("example %d: expected error", k)
+		}
+	}
+}
@@ -12,6 +12,20 @@ type Game struct {
 
 	// Mover denotes the player with the move.
 	Mover g4.Color
+
+	// Rules holds the optional rules of the game.
+	Rules Rules
+
+	// MoveCount is the number of moves played so far.
+	// It is only counted when Rules.MoveLimit is set.
+	MoveCount int
+
+	// NoProgressCount is the number of consecutive moves without a token drop.
+	// It is only counted when Rules.NoProgressLimit is set.
+	//
+	// NB: counters stay at zero under standard rules, so that a game is then
+	// entirely described by its board and mover.
+	NoProgressCount int
 }
 
 // Returns an error if game is over.
@@ -29,10 +43,17 @@ func (g Game) Validate() error {
 		return g4.RedWins{}
 	}
 
-	if g.Board.count() == 64 {
+	if g.Board.Count() == 64 {
 		return g4.Draw{}
 	}
 
+	if g.Rules.NoProgressLimit > 0 && g.NoProgressCount >= g.Rules.NoProgressLimit {
+		return g4.DrawByNoProgress{}
+	}
+	if g.Rules.MoveLimit > 0 && g.MoveCount >= g.Rules.MoveLimit {
+		return g4.DrawByMoveLimit{}
+	}
+
 	return nil
 }
 
@@ -46,15 +67,15 @@ func (g Game) Generate() ([]g4.Move, error) {
 	}
 
 	// Tilt moves.
-	moves = append(
-		moves,
-		g4.TiltMove(g.Mover, g4.LEFT),
-		g4.TiltMove(g.Mover, g4.DOWN),
-		g4.TiltMove(g.Mover, g4.RIGHT),
-	)
+	for times, direction := range []g4.Direction{g4.LEFT, g4.DOWN, g4.RIGHT} {
+		if g.Rules.ForbidNoOpTilts && g.Board.tilt(times+1) == g.Board {
+			continue
+		}
+		moves = append(moves, g4.TiltMove(g.Mover, direction))
+	}
 
 	// Token moves.
-	for column, height := range g.Board.heights() {
+	for column, height := range g.Board.Heights() {
 		if height < 8 {
 			moves = append(moves, g4.TokenMove(g.Mover, column))
 		}
@@ -64,6 +85,8 @@ func (g Game) Generate() ([]g4.Move, error) {
 }
 
 // Apply performs a move from a game state.
+//
+// Under Rules.ForbidNoOpTilts, a tilt which leaves the board unchanged fails with g4.ErrorNoOpTilt.
 func (g Game) Apply(move g4.Move) (Game, error) {
 
 	// Check that game is still live.
@@ -85,13 +108,17 @@ func (g Game) Apply(move g4.Move) (Game, error) {
 		default:
 			return g, g4.ErrorInvalidMove{}
 		}
-		g.Board = g.Board.RotateLeft(times).ApplyGravity()
+		board := g.Board.tilt(times)
+		if g.Rules.ForbidNoOpTilts && board == g.Board {
+			return g, g4.ErrorNoOpTilt{}
+		}
+		g.Board = board
 
 	case g4.Token:
 		if move.Column < 0 || move.Column >= 8 {
 			return g, g4.ErrorInvalidMove{}
 		}
-		if g.Board.heights()[move.Column] == 8 {
+		if g.Board.Heights()[move.Column] == 8 {
 			return g, g4.ErrorInvalidMove{}
 		}
 		g.Board = g.Board.AddToken(move.Column, g.Mover)
@@ -101,6 +128,18 @@ func (g Game) Apply(move g4.Move) (Game, error) {
 
 	}
 
+	// Update counters.
+	if g.Rules.MoveLimit > 0 {
+		g.MoveCount++
+	}
+	if g.Rules.NoProgressLimit > 0 {
+		if move.Type == g4.Token {
+			g.NoProgressCount = 0
+		} else {
+			g.NoProgressCount++
+		}
+	}
+
 	// Switch Mover.
 	if g.Mover == g4.Red {
 		g.Mover = g4.Yellow
@@ -199,7 +199,7 @@ func TestGenerate(t *testing.T) {
 		if err != nil {
 			t.Errorf("example %d: error in FromString: %v", k, err)
 		}
-		game := bitsim.Game{board, ex.color}
+		game := bitsim.Game{Board: board, Mover: ex.color}
 		out, err := game.Generate()
 		if err != ex.err {
 			t.Errorf("example %d: Generate; invalid error: got %v but want %v", k, err, ex.err)
@@ -266,7 +266,7 @@ func TestApplyCorrectMoves(t *testing.T) {
 		if err != nil {
 			t.Errorf("example %d: error in FromString (in): %v", k, err)
 		}
-		game := bitsim.Game{board, ex.color}
+		game := bitsim.Game{Board: board, Mover: ex.color}
 		for i, move := range ex.moves {
 			game, err = game.Apply(move)
 			if err != nil {
@@ -277,7 +277,7 @@ func TestApplyCorrectMoves(t *testing.T) {
 		if err != nil {
 			t.Errorf("example %d: error in FromString (out): %v", k, err)
 		}
-		want := bitsim.Game{board, ex.outColor}
+		want := bitsim.Game{Board: board, Mover: ex.outColor}
 		if game != want {
 			t.Errorf("example %d: wrong game state after game moves: got %v but wanted %v", k, game, want)
 		}
@@ -316,7 +316,7 @@ func TestApplyInvalidMoves(t *testing.T) {
 		if err != nil {
 			t.Errorf("example %d: error in FromString (in): %v", k, err)
 		}
-		game := bitsim.Game{board, ex.color}
+		game := bitsim.Game{Board: board, Mover: ex.color}
 		_, err = game.Apply(ex.move)
 		if err != ex.err {
 			t.Errorf("example %d: incorrect error: got %v but want %v", k, err, ex.err)
@@ -324,4 +324,153 @@ func TestApplyInvalidMoves(t *testing.T) {
 	}
 }
 
+func TestApplyLimits(t *testing.T) {
+	tilts := []g4.Move{
+		g4.TiltMove(g4.Yellow, g4.LEFT),
+		g4.TiltMove(g4.Red, g4.RIGHT),
+		g4.TiltMove(g4.Yellow, g4.DOWN),
+		g4.TiltMove(g4.Red, g4.DOWN),
+	}
+	examples := []struct {
+		rules bitsim.Rules
+		moves []g4.Move
+		err   error
+	}{
+		{
+			rules: bitsim.Rules{},
+			moves: tilts,
+			err:   nil,
+		},
+		{
+			rules: bitsim.Rules{NoProgressLimit: 4},
+			moves: tilts,
+			err:   g4.DrawByNoProgress{},
+		},
+		{
+			rules: bitsim.Rules{NoProgressLimit: 4},
+			moves: append(append([]g4.Move{}, tilts[:3]...), g4.TokenMove(g4.Red, 0)),
+			err:   nil,
+		},
+		{
+			rules: bitsim.Rules{NoProgressLimit: 3},
+			moves: []g4.Move{
+				g4.TiltMove(g4.Yellow, g4.LEFT),
+				g4.TiltMove(g4.Red, g4.RIGHT),
+				g4.TokenMove(g4.Yellow, 0),
+				g4.TiltMove(g4.Red, g4.DOWN),
+			},
+			err: nil,
+		},
+		{
+			rules: bitsim.Rules{MoveLimit: 4},
+			moves: []g4.Move{
+				g4.TokenMove(g4.Yellow, 0),
+				g4.TokenMove(g4.Red, 1),
+				g4.TokenMove(g4.Yellow, 2),
+				g4.TokenMove(g4.Red, 3),
+			},
+			err: g4.DrawByMoveLimit{},
+		},
+		{
+			// NB: a connect 4 on the last move takes precedence over the limit.
+			rules: bitsim.Rules{MoveLimit: 7},
+			moves: []g4.Move{
+				g4.TokenMove(g4.Yellow, 0),
+				g4.TokenMove(g4.Red, 1),
+				g4.TokenMove(g4.Yellow, 0),
+				g4.TokenMove(g4.Red, 1),
+				g4.TokenMove(g4.Yellow, 0),
+				g4.TokenMove(g4.Red, 1),
+				g4.TokenMove(g4.Yellow, 0),
+			},
+			err: g4.YellowWins{},
+		},
+	}
+	for k, ex := range examples {
+		board, _ := bitsim.FromString(bitsim.StartingPosition)
+		game := bitsim.Game{Board: board, Mover: g4.Yellow, Rules: ex.rules}
+		var err error
+		for i, move := range ex.moves {
+			if i > 0 && err != nil {
+				t.Errorf("example %d: move %d: unexpected error %v", k, i, err)
+			}
+			game, err = game.Apply(move)
+		}
+		if err != ex.err {
+			t.Errorf("example %d: got %v but want %v", k, err, ex.err)
+		}
+		if _, err := game.Generate(); err != ex.err {
+			t.Errorf("example %d: Generate: got %v but want %v", k, err, ex.err)
+		}
+	}
+}
+
+// Tests that counters do not change games under standard rules.
+func TestApplyStandardCounters(t *testing.T) {
+	board, _ := bitsim.FromString(bitsim.StartingPosition)
+	game := bitsim.Game{Board: board, Mover: g4.Yellow}
+	game, _ = game.Apply(g4.TiltMove(g4.Yellow, g4.LEFT))
+	game, _ = game.Apply(g4.TiltMove(g4.Red, g4.LEFT))
+	if want := (bitsim.Game{Board: board, Mover: g4.Yellow}); game != want {
+		t.Errorf("got %+v but want %+v", game, want)
+	}
+}
+
+func TestGenerateForbidNoOpTilts(t *testing.T) {
+	examples := []struct {
+		in  string
+		out []g4.Move
+	}{
+		{
+			in:  "8|8|8|8|8|8|8|8",
+			out: tokenMoves(g4.Yellow, []int{0, 1, 2, 3, 4, 5, 6, 7}),
+		},
+		{
+			in: "y7|8|8|8|8|8|8|8",
+			out: concatMoves(
+				tiltMoves(g4.Yellow, []g4.Direction{g4.LEFT, g4.DOWN}),
+				tokenMoves(g4.Yellow, []int{0, 1, 2, 3, 4, 5, 6, 7}),
+			),
+		},
+		{
+			// NB: a symmetric board stays in place when tilted down.
+			in: "yry5|8|8|8|8|8|8|yry5",
+			out: concatMoves(
+				tiltMoves(g4.Yellow, []g4.Direction{g4.LEFT, g4.RIGHT}),
+				tokenMoves(g4.Yellow, []int{0, 1, 2, 3, 4, 5, 6, 7}),
+			),
+		},
+		{
+			in: "yr6|8|8|8|8|8|8|8",
+			out: concatMoves(
+				tiltMoves(g4.Yellow, []g4.Direction{g4.LEFT, g4.DOWN, g4.RIGHT}),
+				tokenMoves(g4.Yellow, []int{0, 1, 2, 3, 4, 5, 6, 7}),
+			),
+		},
+	}
+	for k, ex := range examples {
+		board, _ := bitsim.FromString(ex.in)
+		game := bitsim.Game{Board: board, Mover: g4.Yellow, Rules: bitsim.Rules{ForbidNoOpTilts: true}}
+		out, err := game.Generate()
+		if err != nil {
+			t.Errorf("example %d: unexpected error %v", k, err)
+		}
+		if !compareMoves(out, ex.out) {
+			t.Errorf("example %d: got %v but want %v", k, out, ex.out)
+		}
+	}
+}
+
+func TestApplyForbidNoOpTilts(t *testing.T) {
+	board, _ := bitsim.FromString(bitsim.StartingPosition)
+	game := bitsim.Game{Board: board, Mover: g4.Yellow, Rules: bitsim.Rules{ForbidNoOpTilts: true}}
+	if _, err := game.Apply(g4.TiltMove(g4.Yellow, g4.DOWN)); err != (g4.ErrorNoOpTilt{}) {
+		t.Errorf("got %v but want %v", err, g4.ErrorNoOpTilt{})
+	}
+	game.Rules = bitsim.Rules{}
+	if _, err := game.Apply(g4.TiltMove(g4.Yellow, g4.DOWN)); err != nil {
+		t.Errorf("got %v but want nil under standard rules", err)
+	}
+}
+
 // TODO: test perft (benchmark).
@@ -0,0 +1,29 @@
+package bitsim
+
+// Hash returns a 64-bit hash of the board.
+//
+// Equal boards have equal hashes. Different boards may collide, although it is
+// unlikely, so hashes are suited for transposition tables but not as identifiers.
+func (b Board) Hash() uint64 {
+	return mix64(mix64(uint64(b.yellowBits)) ^ uint64(b.redBits))
+}
+
+// Hash returns a 64-bit hash of the game.
+//
+// It accounts for the board, the mover and the move counters. The rules are
+// not hashed: games played under different rules should not be mixed.
+func (g Game) Hash() uint64 {
+	h := g.Board.Hash() ^ uint64(g.Mover)
+	h ^= uint64(g.MoveCount)<<8 ^ uint64(g.NoProgressCount)<<32
+	return mix64(h)
+}
+
+// mix64 is the finalizer of splitmix64, a fast bijective mixing function.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,42 @@
+package bitsim_test
+
+import (
+	"g4"
+	"g4/bitsim"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	positions := []string{
+		"8|8|8|8|8|8|8|8",
+		"y7|8|8|8|8|8|8|8",
+		"r7|8|8|8|8|8|8|8",
+		"8|8|8|8|8|8|8|y7",
+		"yr6|8|8|8|8|8|8|8",
+		"ry6|8|8|8|8|8|8|8",
+	}
+	seen := make(map[uint64]bitsim.Game)
+	for _, position := range positions {
+		board, err := bitsim.FromString(position)
+		if err != nil {
+			t.Fatalf("error in FromString: %v", err)
+		}
+		for _, mover := range []g4.Color{g4.Yellow, g4.Red} {
+			game := bitsim.Game{Board: board, Mover: mover}
+			if other, ok := seen[game.Hash()]; ok {
+				t.Errorf("hash collision between %v and %v", game, other)
+			}
+			seen[game.Hash()] = game
+		}
+	}
+
+	board, _ := bitsim.FromString("yr6|8|8|8|8|8|8|8")
+	same, _ := bitsim.FromString("yr111111|8|8|8|8|8|8|8")
+	if board.Hash() != same.Hash() {
+		t.Errorf("equal boards have different hashes")
+	}
+	counted := bitsim.Game{Board: board, Mover: g4.Yellow, NoProgressCount: 1}
+	if counted.Hash() == (bitsim.Game{Board: board, Mover: g4.Yellow}).Hash() {
+		t.Errorf("move counters are not hashed")
+	}
+}
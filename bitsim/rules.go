@@ -0,0 +1,78 @@
+package bitsim
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Rules holds the optional rules of a game.
+//
+// The zero value denotes the standard rules.
+type Rules struct {
+	// NoProgressLimit is the number of consecutive moves without a token drop
+	// after which the game is a draw. Zero disables the rule.
+	NoProgressLimit int
+
+	// MoveLimit is the total number of moves after which the game is a draw.
+	// Zero disables the rule.
+	MoveLimit int
+
+	// ForbidNoOpTilts forbids tilts which leave the board unchanged, so that
+	// players cannot pass.
+	ForbidNoOpTilts bool
+}
+
+// String returns the variant name of the rules.
+//
+// Standard rules are named "standard", other rules are a comma-separated list
+// of options, such as "no-progress=40,move-limit=200,forbid-noop-tilts".
+func (r Rules) String() string {
+	var options []string
+	if r.NoProgressLimit > 0 {
+		options = append(options, "no-progress="+strconv.Itoa(r.NoProgressLimit))
+	}
+	if r.MoveLimit > 0 {
+		options = append(options, "move-limit="+strconv.Itoa(r.MoveLimit))
+	}
+	if r.ForbidNoOpTilts {
+		options = append(options, "forbid-noop-tilts")
+	}
+	if len(options) == 0 {
+		return "standard"
+	}
+	return strings.Join(options, ",")
+}
+
+// ParseRules reads rules from their variant name.
+//
+// The empty string is accepted for standard rules.
+func ParseRules(s string) (Rules, error) {
+	var r Rules
+	if s == "" || s == "standard" {
+		return r, nil
+	}
+	for _, option := range strings.Split(s, ",") {
+		if option == "forbid-noop-tilts" {
+			r.ForbidNoOpTilts = true
+			continue
+		}
+		parts := strings.SplitN(option, "=", 2)
+		if len(parts) != 2 {
+			return r, fmt.Errorf("invalid rule option '%s'", option)
+		}
+		value, err := strconv.Atoi(parts[1])
+		if err != nil || value <= 0 {
+			return r, fmt.Errorf("invalid value for rule option '%s'", option)
+		}
+		switch parts[0] {
+		case "no-progress":
+			r.NoProgressLimit = value
+		case "move-limit":
+			r.MoveLimit = value
+		default:
+			return r, fmt.Errorf("unknown rule option '%s'", parts[0])
+		}
+	}
+	return r, nil
+}
@@ -0,0 +1,49 @@
+package bitsim_test
+
+import (
+	"g4/bitsim"
+	"testing"
+)
+
+func TestRulesString(t *testing.T) {
+	examples := []struct {
+		in  bitsim.Rules
+		out string
+	}{
+		{in: bitsim.Rules{}, out: "standard"},
+		{in: bitsim.Rules{NoProgressLimit: 40}, out: "no-progress=40"},
+		{in: bitsim.Rules{MoveLimit: 200}, out: "move-limit=200"},
+		{in: bitsim.Rules{NoProgressLimit: 40, MoveLimit: 200}, out: "no-progress=40,move-limit=200"},
+		{in: bitsim.Rules{ForbidNoOpTilts: true}, out: "forbid-noop-tilts"},
+		{in: bitsim.Rules{MoveLimit: 80, ForbidNoOpTilts: true}, out: "move-limit=80,forbid-noop-tilts"},
+	}
+	for k, ex := range examples {
+		if got := ex.in.String(); got != ex.out {
+			t.Errorf("example %d: got '%s' but want '%s'", k, got, ex.out)
+		}
+		if got, err := bitsim.ParseRules(ex.out); err != nil || got != ex.in {
+			t.Errorf("example %d: got (%+v, %v) but want (%+v, nil)", k, got, err, ex.in)
+		}
+	}
+	if got, err := bitsim.ParseRules(""); err != nil || got != (bitsim.Rules{}) {
+		t.Errorf("empty string: got (%+v, %v)", got, err)
+	}
+}
+
+func TestParseRulesError(t *testing.T) {
+	examples := []string{
+		"blitz",
+		"no-progress",
+		"no-progress=",
+		"no-progress=0",
+		"move-limit=-3",
+		"move-limit=10,",
+		"undo=3",
+		"forbid-noop-tilts=1",
+	}
+	for k, ex := range examples {
+		if got, err := bitsim.ParseRules(ex); err == nil {
+			t.Errorf("example %d: got (%+v, %v) but expected error", k, got, err)
+		}
+	}
+}
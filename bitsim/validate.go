@@ -0,0 +1,191 @@
+package bitsim
+
+import (
+	"fmt"
+	"g4"
+	"math/bits"
+)
+
+// ErrorOverlappingTokens is returned when a square holds both a yellow and a red token.
+type ErrorOverlappingTokens struct {
+	Column, Row int
+}
+
+func (err ErrorOverlappingTokens) Error() string {
+	return fmt.Sprintf("overlapping tokens in column %d, row %d", err.Column, err.Row)
+}
+
+// ErrorFloatingToken is returned when a token has an empty square below it.
+type ErrorFloatingToken struct {
+	Column, Row int
+}
+
+func (err ErrorFloatingToken) Error() string {
+	return fmt.Sprintf("floating token in column %d, row %d", err.Column, err.Row)
+}
+
+// ErrorUnreachable is returned for a position that no legal game can reach.
+type ErrorUnreachable struct {
+	Reason string
+}
+
+func (err ErrorUnreachable) Error() string {
+	return "unreachable position: " + err.Reason
+}
+
+// Validate checks that the board is physically consistent.
+//
+// It fails if a square holds two tokens, or if a token floats above an empty
+// square. Such boards can be built with FromString but never occur in a game.
+func (b Board) Validate() error {
+	if overlap := b.yellowBits & b.redBits; overlap != 0 {
+		k := bits.TrailingZeros64(uint64(overlap))
+		return ErrorOverlappingTokens{Column: k / 8, Row: k % 8}
+	}
+	tokens := b.yellowBits | b.redBits
+	if floating := tokens & (^tokens).north(); floating != 0 {
+		k := bits.TrailingZeros64(uint64(floating))
+		return ErrorFloatingToken{Column: k / 8, Row: k % 8}
+	}
+	return nil
+}
+
+// IsReachable checks that the position, with `mover` to play, can occur in a game.
+//
+// On top of Validate, it looks for a legal previous position: a live position
+// from which the other player either dropped one of the top tokens or tilted
+// the board. This rules out, for instance, connect-4s that no single move could
+// have created. It only looks one move back, so it is a necessary condition.
+//
+// NB: token counts are not checked on their own, since either player may tilt
+// instead of dropping a token.
+func (b Board) IsReachable(mover g4.Color) error {
+	if mover != g4.Yellow && mover != g4.Red {
+		return ErrorUnreachable{Reason: "invalid mover"}
+	}
+	if err := b.Validate(); err != nil {
+		return err
+	}
+
+	// The previous player dropped a token.
+	previousBits := b.yellowBits
+	if mover == g4.Yellow {
+		previousBits = b.redBits
+	}
+	for column, height := range b.Heights() {
+		if height == 0 {
+			continue
+		}
+		top := one << (height - 1 + 8*column)
+		if previousBits&top == 0 {
+			continue
+		}
+		previous := Board{yellowBits: b.yellowBits &^ top, redBits: b.redBits &^ top}
+		if !previous.isOver() {
+			return nil
+		}
+	}
+
+	// The previous player tilted the board.
+	for times := 1; times < 4; times++ {
+		if b.hasLiveTiltPredecessor(times) {
+			return nil
+		}
+	}
+
+	return ErrorUnreachable{Reason: "all previous positions are finished games"}
+}
+
+// isOver returns whether a game is over on this board.
+func (b Board) isOver() bool {
+	return b.hasYellowConnect4() || b.hasRedConnect4() || b.Count() == 64
+}
+
+// hasLiveTiltPredecessor returns whether a live settled board gives b after `times` left rotations.
+//
+// The rotation moves each line of the predecessor into a column of b, where
+// gravity packs its tokens in order. The heights of b thus tell how many tokens
+// each line of the predecessor holds, which leaves only the order of its column
+// heights to enumerate (at most 8! possibilities).
+func (b Board) hasLiveTiltPredecessor(times int) bool {
+	heights := b.Heights()
+
+	// Build the number of columns of the predecessor having each height.
+	var multiplicity [9]int
+	if times == 2 {
+		for _, height := range heights {
+			multiplicity[height]++
+		}
+	} else {
+		// lineCounts[r] is the number of tokens in line r of the predecessor.
+		var lineCounts [9]int
+		for r := 0; r < 8; r++ {
+			c, _ := rotateCell(0, r, times)
+			lineCounts[r] = heights[c]
+		}
+		multiplicity[0] = 8 - lineCounts[0]
+		for h := 1; h <= 8; h++ {
+			multiplicity[h] = lineCounts[h-1] - lineCounts[h]
+			if multiplicity[h] < 0 {
+				return false
+			}
+		}
+	}
+
+	var columnHeights [8]int
+	var enumerate func(column int) bool
+	enumerate = func(column int) bool {
+		if column == 8 {
+			previous, ok := b.untilt(times, columnHeights)
+			return ok && !previous.isOver()
+		}
+		for h := 0; h <= 8; h++ {
+			if multiplicity[h] == 0 {
+				continue
+			}
+			multiplicity[h]--
+			columnHeights[column] = h
+			done := enumerate(column + 1)
+			multiplicity[h]++
+			if done {
+				return true
+			}
+		}
+		return false
+	}
+	return enumerate(0)
+}
+
+// untilt builds the settled board with given column heights which gives b after `times` left rotations.
+func (b Board) untilt(times int, columnHeights [8]int) (Board, bool) {
+	var previous Board
+	for column := 0; column < 8; column++ {
+		// Tokens packed in this column come from the rotated column, in order.
+		k := 0
+		for row := 0; row < 8; row++ {
+			c, r := rotateCell(column, row, 4-times)
+			if r >= columnHeights[c] {
+				continue
+			}
+			src := one << (k + 8*column)
+			dst := one << (r + 8*c)
+			if b.yellowBits&src != 0 {
+				previous.yellowBits |= dst
+			} else if b.redBits&src != 0 {
+				previous.redBits |= dst
+			} else {
+				return previous, false
+			}
+			k++
+		}
+	}
+	return previous, previous.RotateLeft(times).ApplyGravity() == b
+}
+
+// rotateCell returns the coordinates of a square after `times` left rotations.
+func rotateCell(column, row, times int) (int, int) {
+	for k := 0; k < times%4; k++ {
+		column, row = 7-row, column
+	}
+	return column, row
+}
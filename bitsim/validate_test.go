@@ -0,0 +1,149 @@
+package bitsim
+
+import (
+	"g4"
+	"testing"
+)
+
+func TestBoardValidate(t *testing.T) {
+	examples := []struct {
+		in  string
+		err error
+	}{
+		{
+			in:  "8|8|8|8|8|8|8|8",
+			err: nil,
+		},
+		{
+			in:  "8|8|8|8|rrryr3|ryyyyyr1|r7|yr6",
+			err: nil,
+		},
+		{
+			in:  "1y6|8|8|8|8|8|8|8",
+			err: ErrorFloatingToken{Column: 0, Row: 1},
+		},
+		{
+			in:  "yr6|8|8|yy1r4|8|8|8|8",
+			err: ErrorFloatingToken{Column: 3, Row: 3},
+		},
+	}
+	for k, ex := range examples {
+		board, _ := FromString(ex.in)
+		if err := board.Validate(); err != ex.err {
+			t.Errorf("example %d: got %v but want %v", k, err, ex.err)
+		}
+	}
+
+	// NB: overlapping tokens cannot be described by a string.
+	board := Board{yellowBits: one | one<<10, redBits: one<<10 | one<<11}
+	want := ErrorOverlappingTokens{Column: 1, Row: 2}
+	if err := board.Validate(); err != want {
+		t.Errorf("got %v but want %v", err, want)
+	}
+}
+
+func TestBoardIsReachable(t *testing.T) {
+	examples := []struct {
+		in    string
+		mover g4.Color
+		ok    bool
+	}{
+		{
+			in:    StartingPosition,
+			mover: g4.Yellow,
+			ok:    true,
+		},
+		{
+			in:    StartingPosition,
+			mover: g4.Red,
+			ok:    true,
+		},
+		{
+			in:    "y7|8|8|8|8|8|8|8",
+			mover: g4.Yellow,
+			ok:    true,
+		},
+		{
+			in:    "yyyy4|8|8|8|8|8|8|8",
+			mover: g4.Red,
+			ok:    true,
+		},
+		{
+			// NB: a tilt can make both players connect 4.
+			in:    "8|8|8|8|8|8|rrrr4|yyyy4",
+			mover: g4.Red,
+			ok:    true,
+		},
+		{
+			in:    "yyyy4|8|8|8|8|8|8|yyyy4",
+			mover: g4.Red,
+			ok:    false,
+		},
+		{
+			// NB: red may have tilted a line of five yellow tokens with gaps.
+			in:    "yyyyy3|8|8|8|8|8|8|8",
+			mover: g4.Red,
+			ok:    true,
+		},
+		{
+			in:    "yyyyyyyy|8|8|8|8|8|8|8",
+			mover: g4.Red,
+			ok:    false,
+		},
+		{
+			in:    "1y6|8|8|8|8|8|8|8",
+			mover: g4.Red,
+			ok:    false,
+		},
+		{
+			in:    StartingPosition,
+			mover: g4.Empty,
+			ok:    false,
+		},
+	}
+	for k, ex := range examples {
+		board, _ := FromString(ex.in)
+		err := board.IsReachable(ex.mover)
+		if (err == nil) != ex.ok {
+			t.Errorf("example %d: got %v", k, err)
+		}
+	}
+}
+
+// Tests that positions coming from actual games are reachable.
+func TestBoardIsReachableGames(t *testing.T) {
+	examples := []struct {
+		in    string
+		moves []g4.Move
+	}{
+		{
+			in: "8|8|8|8|8|8|8|8",
+			moves: []g4.Move{
+				g4.TokenMove(g4.Yellow, 0),
+				g4.TokenMove(g4.Red, 1),
+				g4.TokenMove(g4.Yellow, 2),
+				g4.TokenMove(g4.Red, 3),
+				g4.TiltMove(g4.Yellow, g4.LEFT),
+				g4.TokenMove(g4.Red, 3),
+				g4.TiltMove(g4.Yellow, g4.RIGHT),
+				g4.TiltMove(g4.Red, g4.DOWN),
+			},
+		},
+		{
+			in: "yr6|8|yr6|8|yr6|8|yr6|8",
+			moves: []g4.Move{
+				g4.TiltMove(g4.Yellow, g4.LEFT),
+			},
+		},
+	}
+	for k, ex := range examples {
+		board, _ := FromString(ex.in)
+		game := Game{Board: board, Mover: g4.Yellow}
+		for i, move := range ex.moves {
+			game, _ = game.Apply(move)
+			if err := game.Board.IsReachable(game.Mover); err != nil {
+				t.Errorf("example %d: move %d: got %v on %v", k, i, err, game.Board)
+			}
+		}
+	}
+}
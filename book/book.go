@@ -0,0 +1,232 @@
+// Package book implements opening books for g4.
+//
+// A book maps positions to the moves played from them, with a weight and the
+// results obtained. It is built from game records or self-play games, and
+// lets the engine vary its openings by picking moves at random, in proportion
+// to their weights.
+package book
+
+import (
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"g4/record"
+	"math/rand"
+	"sort"
+)
+
+// Entry is a move of the book, with its statistics.
+type Entry struct {
+	Move g4.Move
+
+	// Weight is the likelihood of the move being chosen, relative to the other moves of the position.
+	Weight int
+
+	// Wins, Draws and Losses count the results of the games where the move was
+	// played, from the point of view of the player of the move.
+	Wins, Draws, Losses int
+}
+
+// Games returns the number of games where the move was played.
+func (e Entry) Games() int {
+	return e.Wins + e.Draws + e.Losses
+}
+
+// Score returns the average result of the move, between 0 (lost) and 1 (won).
+func (e Entry) Score() float64 {
+	if e.Games() == 0 {
+		return 0.5
+	}
+	return (float64(e.Wins) + float64(e.Draws)/2) / float64(e.Games())
+}
+
+// keySize is the size of the keys of positions.
+const keySize = 17
+
+// Book maps positions to moves.
+type Book struct {
+	positions map[[keySize]byte][]Entry
+}
+
+// New creates an empty book.
+func New() *Book {
+	return &Book{positions: make(map[[keySize]byte][]Entry)}
+}
+
+// Key returns the key of a position in books.
+//
+// It is the binary encoding of the board and mover, as written by
+// bitsim.Game.MarshalBinary: move counters are ignored. Unlike hashes, keys
+// are stable across versions, so that saved books stay valid.
+func Key(g bitsim.Game) (key [keySize]byte) {
+	data, _ := bitsim.Game{Board: g.Board, Mover: g.Mover}.MarshalBinary()
+	copy(key[:], data)
+	return key
+}
+
+// Len returns the number of positions in the book.
+func (b *Book) Len() int {
+	return len(b.positions)
+}
+
+// Lookup returns the moves of the book in given position, by decreasing weight.
+func (b *Book) Lookup(g bitsim.Game) []Entry {
+	entries := b.positions[Key(g)]
+	result := make([]Entry, len(entries))
+	for k, entry := range entries {
+		entry.Move.Color = g.Mover
+		result[k] = entry
+	}
+	return result
+}
+
+// Choose picks a move of the book at random, in proportion to the weights.
+//
+// It returns false if the position is not in the book.
+func (b *Book) Choose(g bitsim.Game, r *rand.Rand) (g4.Move, bool) {
+	entries := b.Lookup(g)
+	total := 0
+	for _, entry := range entries {
+		total += entry.Weight
+	}
+	if total <= 0 {
+		return g4.Move{}, false
+	}
+	x := r.Intn(total)
+	for _, entry := range entries {
+		if x < entry.Weight {
+			return entry.Move, true
+		}
+		x -= entry.Weight
+	}
+	panic("unreachable")
+}
+
+// Add records that move was played in g, with given result for the player of the move.
+//
+// The weight of the move is incremented. The result is one of g4.YellowWins,
+// g4.RedWins, g4.Draw or nil for an unfinished game, which does not count in the statistics.
+func (b *Book) Add(g bitsim.Game, move g4.Move, result error) {
+	delta := Entry{Move: move, Weight: 1}
+	switch result.(type) {
+	case g4.YellowWins:
+		if g.Mover == g4.Yellow {
+			delta.Wins = 1
+		} else {
+			delta.Losses = 1
+		}
+	case g4.RedWins:
+		if g.Mover == g4.Red {
+			delta.Wins = 1
+		} else {
+			delta.Losses = 1
+		}
+	case nil:
+	default:
+		delta.Draws = 1
+	}
+	b.add(Key(g), delta)
+}
+
+// add merges an entry into the entries of a position.
+func (b *Book) add(key [keySize]byte, delta Entry) {
+	delta.Move.Color = g4.Empty
+	entries := b.positions[key]
+	for k := range entries {
+		if entries[k].Move == delta.Move {
+			entries[k].Weight += delta.Weight
+			entries[k].Wins += delta.Wins
+			entries[k].Draws += delta.Draws
+			entries[k].Losses += delta.Losses
+			sortEntries(entries)
+			return
+		}
+	}
+	entries = append(entries, delta)
+	sortEntries(entries)
+	b.positions[key] = entries
+}
+
+// AddRecord adds the first plies moves of a game record to the book.
+func (b *Book) AddRecord(rec *record.Record, plies int) error {
+	games, err := rec.Replay()
+	if err != nil {
+		return err
+	}
+	result := recordResult(rec.Result)
+	for k, node := range rec.Moves {
+		if k >= plies {
+			break
+		}
+		b.Add(games[k], node.Move, result)
+	}
+	return nil
+}
+
+// recordResult converts the result of a record to the form expected by Add.
+func recordResult(result record.Result) error {
+	switch result {
+	case record.YellowWins:
+		return g4.YellowWins{}
+	case record.RedWins:
+		return g4.RedWins{}
+	case record.Draw:
+		return g4.Draw{}
+	}
+	return nil
+}
+
+// Merge adds the entries of other to the book, summing weights and statistics.
+func (b *Book) Merge(other *Book) {
+	for key, entries := range other.positions {
+		for _, entry := range entries {
+			b.add(key, entry)
+		}
+	}
+}
+
+// Prune removes the moves played less than minGames times, and the positions left without moves.
+func (b *Book) Prune(minGames int) {
+	for key, entries := range b.positions {
+		var kept []Entry
+		for _, entry := range entries {
+			if entry.Games() >= minGames {
+				kept = append(kept, entry)
+			}
+		}
+		if len(kept) == 0 {
+			delete(b.positions, key)
+			continue
+		}
+		sortEntries(kept)
+		b.positions[key] = kept
+	}
+}
+
+// sortEntries sorts entries by decreasing weight, then by move.
+func sortEntries(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Weight != entries[j].Weight {
+			return entries[i].Weight > entries[j].Weight
+		}
+		return moveCode(entries[i].Move) < moveCode(entries[j].Move)
+	})
+}
+
+// moveCode returns the code of a move in book files: 0 to 7 for tokens, 8 to 10 for tilts.
+func moveCode(move g4.Move) byte {
+	if move.Type == g4.Tilt {
+		return 8 + byte(move.Direction-g4.LEFT)
+	}
+	return byte(move.Column)
+}
+
+func codeMove(code byte) (g4.Move, error) {
+	switch {
+	case code < 8:
+		return g4.TokenMove(g4.Empty, int(code)), nil
+	case code <= 10:
+		return g4.TiltMove(g4.Empty, g4.LEFT+g4.Direction(code-8)), nil
+	}
+	return g4.Move{}, fmt.Errorf("invalid move code: %d", code)
+}
@@ -0,0 +1,194 @@
+package book_test
+
+import (
+	"g4"
+	"g4/bitsim"
+	"g4/book"
+	"g4/internal/g4test"
+	"g4/record"
+	"math/rand"
+	"testing"
+)
+
+func TestAdd(t *testing.T) {
+	start := g4test.Game(t, bitsim.StartingPosition, g4.Yellow)
+	b := book.New()
+	b.Add(start, g4.TokenMove(g4.Yellow, 3), g4.YellowWins{})
+	b.Add(start, g4.TokenMove(g4.Yellow, 3), g4.RedWins{})
+	b.Add(start, g4.TokenMove(g4.Yellow, 4), g4.Draw{})
+	b.Add(start, g4.TokenMove(g4.Yellow, 3), nil)
+	b.Add(start, g4.TiltMove(g4.Yellow, g4.LEFT), g4.DrawByMoveLimit{})
+
+	want := []book.Entry{
+		{Move: g4.TokenMove(g4.Yellow, 3), Weight: 3, Wins: 1, Losses: 1},
+		{Move: g4.TokenMove(g4.Yellow, 4), Weight: 1, Draws: 1},
+		{Move: g4.TiltMove(g4.Yellow, g4.LEFT), Weight: 1, Draws: 1},
+	}
+	got := b.Lookup(start)
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries but want %d", len(got), len(want))
+	}
+	for k := range want {
+		if got[k] != want[k] {
+			t.Errorf("entry %d: got %+v but want %+v", k, got[k], want[k])
+		}
+	}
+	if b.Len() != 1 {
+		t.Errorf("got %d positions but want 1", b.Len())
+	}
+}
+
+func TestLookupIgnoresCounters(t *testing.T) {
+	g := g4test.Game(t, "y7|8|8|8|8|8|8|8", g4.Red)
+	b := book.New()
+	b.Add(g, g4.TokenMove(g4.Red, 0), nil)
+
+	g.MoveCount, g.NoProgressCount = 7, 3
+	if got := b.Lookup(g); len(got) != 1 || got[0].Move != g4.TokenMove(g4.Red, 0) {
+		t.Errorf("got %+v", got)
+	}
+	g.Mover = g4.Yellow
+	if got := b.Lookup(g); len(got) != 0 {
+		t.Errorf("got %+v for the other mover", got)
+	}
+}
+
+func TestEntryScore(t *testing.T) {
+	examples := []struct {
+		in   book.Entry
+		want float64
+	}{
+		{in: book.Entry{}, want: 0.5},
+		{in: book.Entry{Wins: 3, Losses: 1}, want: 0.75},
+		{in: book.Entry{Wins: 1, Draws: 2, Losses: 1}, want: 0.5},
+		{in: book.Entry{Losses: 2}, want: 0},
+	}
+	for k, ex := range examples {
+		if got := ex.in.Score(); got != ex.want {
+			t.Errorf("example %d: got %v but want %v", k, got, ex.want)
+		}
+	}
+}
+
+func TestChoose(t *testing.T) {
+	start := g4test.Game(t, bitsim.StartingPosition, g4.Yellow)
+	b := book.New()
+	for k := 0; k < 3; k++ {
+		b.Add(start, g4.TokenMove(g4.Yellow, 3), nil)
+	}
+	b.Add(start, g4.TokenMove(g4.Yellow, 4), nil)
+
+	r := rand.New(rand.NewSource(1))
+	counts := make(map[g4.Move]int)
+	for k := 0; k < 4000; k++ {
+		move, ok := b.Choose(start, r)
+		if !ok {
+			t.Fatalf("position not found")
+		}
+		counts[move]++
+	}
+	if len(counts) != 2 {
+		t.Fatalf("got moves %v", counts)
+	}
+	if n := counts[g4.TokenMove(g4.Yellow, 3)]; n < 2800 || n > 3200 {
+		t.Errorf("got column 4 %d times out of 4000 but want about 3000", n)
+	}
+
+	if _, ok := b.Choose(g4test.Game(t, "y7|8|8|8|8|8|8|8", g4.Red), r); ok {
+		t.Errorf("chose a move in a position out of the book")
+	}
+}
+
+func TestAddRecord(t *testing.T) {
+	rec := &record.Record{
+		Result: record.RedWins,
+		Moves: []record.Node{
+			{Move: g4.TokenMove(g4.Yellow, 0)},
+			{Move: g4.TokenMove(g4.Red, 1)},
+			{Move: g4.TokenMove(g4.Yellow, 2)},
+		},
+	}
+	b := book.New()
+	if err := b.AddRecord(rec, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if b.Len() != 2 {
+		t.Fatalf("got %d positions but want 2", b.Len())
+	}
+	got := b.Lookup(g4test.Game(t, bitsim.StartingPosition, g4.Yellow))
+	if len(got) != 1 || got[0].Losses != 1 {
+		t.Errorf("got %+v for the first move", got)
+	}
+	got = b.Lookup(g4test.Game(t, "y7|8|8|8|8|8|8|8", g4.Red))
+	if len(got) != 1 || got[0].Wins != 1 || got[0].Move != g4.TokenMove(g4.Red, 1) {
+		t.Errorf("got %+v for the second move", got)
+	}
+
+	rec.Moves[1].Move = g4.TokenMove(g4.Yellow, 1)
+	if err := book.New().AddRecord(rec, 2); err == nil {
+		t.Errorf("expected error for an invalid record")
+	}
+}
+
+func TestMergeAndPrune(t *testing.T) {
+	start := g4test.Game(t, bitsim.StartingPosition, g4.Yellow)
+	other := g4test.Game(t, "y7|8|8|8|8|8|8|8", g4.Red)
+	b1, b2 := book.New(), book.New()
+	b1.Add(start, g4.TokenMove(g4.Yellow, 3), g4.YellowWins{})
+	b1.Add(other, g4.TokenMove(g4.Red, 0), g4.YellowWins{})
+	b2.Add(start, g4.TokenMove(g4.Yellow, 3), g4.Draw{})
+	b2.Add(start, g4.TokenMove(g4.Yellow, 4), g4.Draw{})
+
+	b1.Merge(b2)
+	want := book.Entry{Move: g4.TokenMove(g4.Yellow, 3), Weight: 2, Wins: 1, Draws: 1}
+	if got := b1.Lookup(start); len(got) != 2 || got[0] != want {
+		t.Errorf("got %+v after merge", got)
+	}
+
+	b1.Prune(2)
+	if b1.Len() != 1 {
+		t.Errorf("got %d positions after pruning but want 1", b1.Len())
+	}
+	if got := b1.Lookup(start); len(got) != 1 || got[0] != want {
+		t.Errorf("got %+v after pruning", got)
+	}
+}
+
+func TestPruneThenChoose(t *testing.T) {
+	start := g4test.Game(t, bitsim.StartingPosition, g4.Yellow)
+	b := book.New()
+	for k := 0; k < 5; k++ {
+		b.Add(start, g4.TokenMove(g4.Yellow, 3), nil)
+	}
+	for k := 0; k < 3; k++ {
+		b.Add(start, g4.TokenMove(g4.Yellow, 4), g4.Draw{})
+	}
+	b.Add(start, g4.TokenMove(g4.Yellow, 5), g4.YellowWins{})
+	b.Add(start, g4.TokenMove(g4.Yellow, 5), g4.RedWins{})
+
+	b.Prune(2)
+	want := []book.Entry{
+		{Move: g4.TokenMove(g4.Yellow, 4), Weight: 3, Draws: 3},
+		{Move: g4.TokenMove(g4.Yellow, 5), Weight: 2, Wins: 1, Losses: 1},
+	}
+	got := b.Lookup(start)
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("got %+v after pruning but want %+v", got, want)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	counts := make(map[g4.Move]int)
+	for k := 0; k < 5000; k++ {
+		move, ok := b.Choose(start, r)
+		if !ok {
+			t.Fatalf("position not found after pruning")
+		}
+		counts[move]++
+	}
+	if len(counts) != 2 || counts[g4.TokenMove(g4.Yellow, 3)] != 0 {
+		t.Fatalf("got moves %v", counts)
+	}
+	if n := counts[g4.TokenMove(g4.Yellow, 4)]; n < 2800 || n > 3200 {
+		t.Errorf("got column 5 %d times out of 5000 but want about 3000", n)
+	}
+}
@@ -0,0 +1,141 @@
+package book
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"g4/bitsim"
+	"io"
+	"os"
+	"sort"
+)
+
+// magic starts every book file.
+const magic = "G4BK\x02"
+
+// errorFormat is returned for files which are not books.
+var errorFormat = errors.New("not a book file")
+
+// Write writes the book in binary form.
+//
+// The file starts with a magic string and the number of positions. Positions
+// follow by increasing key, each made of the key (the 17 bytes of Key) and
+// the number of moves, then for each move its code (one byte), weight, wins,
+// draws and losses. All numbers but keys are unsigned varints.
+func (b *Book) Write(w io.Writer) error {
+	keys := make([][keySize]byte, 0, len(b.positions))
+	for key := range b.positions {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i][:], keys[j][:]) < 0 })
+
+	bw := bufio.NewWriter(w)
+	var buf [binary.MaxVarintLen64]byte
+	writeUvarint := func(x int) {
+		bw.Write(buf[:binary.PutUvarint(buf[:], uint64(x))])
+	}
+
+	bw.WriteString(magic)
+	writeUvarint(len(keys))
+	for _, key := range keys {
+		bw.Write(key[:])
+		entries := b.positions[key]
+		writeUvarint(len(entries))
+		for _, entry := range entries {
+			bw.WriteByte(moveCode(entry.Move))
+			writeUvarint(entry.Weight)
+			writeUvarint(entry.Wins)
+			writeUvarint(entry.Draws)
+			writeUvarint(entry.Losses)
+		}
+	}
+	return bw.Flush()
+}
+
+// Read reads a book written by Write.
+func Read(r io.Reader) (*Book, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(magic))
+	if _, err := io.ReadFull(br, header); err != nil || string(header) != magic {
+		return nil, errorFormat
+	}
+	readUvarint := func() (int, error) {
+		x, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return int(x), err
+	}
+
+	count, err := readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	b := New()
+	var previous [keySize]byte
+	for i := 0; i < count; i++ {
+		var key [keySize]byte
+		if _, err := io.ReadFull(br, key[:]); err != nil {
+			return nil, fmt.Errorf("position %d: %w", i, err)
+		}
+		var g bitsim.Game
+		if err := g.UnmarshalBinary(key[:]); err != nil {
+			return nil, fmt.Errorf("position %d: %w", i, err)
+		}
+		if i > 0 && bytes.Compare(key[:], previous[:]) <= 0 {
+			return nil, fmt.Errorf("position %d: keys are not sorted", i)
+		}
+		previous = key
+
+		n, err := readUvarint()
+		if err != nil {
+			return nil, fmt.Errorf("position %d: %w", i, err)
+		}
+		for k := 0; k < n; k++ {
+			code, err := br.ReadByte()
+			if err != nil {
+				return nil, fmt.Errorf("position %d: %w", i, io.ErrUnexpectedEOF)
+			}
+			move, err := codeMove(code)
+			if err != nil {
+				return nil, fmt.Errorf("position %d: %w", i, err)
+			}
+			entry := Entry{Move: move}
+			for _, field := range []*int{&entry.Weight, &entry.Wins, &entry.Draws, &entry.Losses} {
+				if *field, err = readUvarint(); err != nil {
+					return nil, fmt.Errorf("position %d: %w", i, err)
+				}
+			}
+			b.add(key, entry)
+		}
+	}
+	if _, err := br.ReadByte(); err != io.EOF {
+		return nil, fmt.Errorf("trailing data after the positions")
+	}
+	return b, nil
+}
+
+// Load reads a book from a file.
+func Load(path string) (*Book, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Save writes the book to a file.
+func (b *Book) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := b.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,102 @@
+package book_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"g4"
+	"g4/bitsim"
+	"g4/book"
+	"g4/internal/g4test"
+	"testing"
+)
+
+func TestWriteRead(t *testing.T) {
+	start := g4test.Game(t, bitsim.StartingPosition, g4.Yellow)
+	other := g4test.Game(t, "y7|8|8|8|8|8|8|8", g4.Red)
+	b := book.New()
+	b.Add(start, g4.TokenMove(g4.Yellow, 3), g4.YellowWins{})
+	b.Add(start, g4.TiltMove(g4.Yellow, g4.RIGHT), g4.Draw{})
+	for k := 0; k < 300; k++ {
+		b.Add(other, g4.TokenMove(g4.Red, 7), g4.RedWins{})
+	}
+
+	var buf bytes.Buffer
+	if err := b.Write(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data := buf.Bytes()
+	read, err := book.Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if read.Len() != b.Len() {
+		t.Errorf("got %d positions but want %d", read.Len(), b.Len())
+	}
+	for _, g := range []bitsim.Game{start, other} {
+		got, want := read.Lookup(g), b.Lookup(g)
+		if len(got) != len(want) {
+			t.Errorf("%v: got %+v but want %+v", g.Board, got, want)
+			continue
+		}
+		for k := range want {
+			if got[k] != want[k] {
+				t.Errorf("%v: entry %d: got %+v but want %+v", g.Board, k, got[k], want[k])
+			}
+		}
+	}
+
+	// Writing is deterministic.
+	var again bytes.Buffer
+	read.Write(&again)
+	if !bytes.Equal(again.Bytes(), data) {
+		t.Errorf("got different bytes when writing the book again")
+	}
+}
+
+func TestReadError(t *testing.T) {
+	b := book.New()
+	b.Add(g4test.Game(t, bitsim.StartingPosition, g4.Yellow), g4.TokenMove(g4.Yellow, 3), nil)
+	var buf bytes.Buffer
+	b.Write(&buf)
+	valid := buf.Bytes()
+
+	badMove := append([]byte{}, valid...)
+	// Magic, count, key, number of moves, then the first move code.
+	badMove[5+1+17+1] = 11
+	badKey := append([]byte{}, valid...)
+	// The mover of the first key.
+	badKey[5+1+16] = 3
+	examples := [][]byte{
+		nil,
+		[]byte("G4TB\x01"),
+		valid[:len(valid)-1],
+		append(append([]byte{}, valid...), 0),
+		badMove,
+		badKey,
+	}
+	for k, ex := range examples {
+		if _, err := book.Read(bytes.NewReader(ex)); err == nil {
+			t.Errorf("example %d: expected error", k)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	// Keys are written in book files: they must not change.
+	examples := []struct {
+		board string
+		mover g4.Color
+		want  string
+	}{
+		{bitsim.StartingPosition, g4.Yellow, "0000000000000000000000000000000001"},
+		{"yr6|8|8|8|8|8|8|r7", g4.Red, "0100000000000000020000000000000102"},
+	}
+	for k, ex := range examples {
+		g := g4test.Game(t, ex.board, ex.mover)
+		g.MoveCount, g.NoProgressCount = 12, 3
+		key := book.Key(g)
+		if got := hex.EncodeToString(key[:]); got != ex.want {
+			t.Errorf("example %d: got key %s but want %s", k, got, ex.want)
+		}
+	}
+}
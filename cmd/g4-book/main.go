@@ -0,0 +1,175 @@
+// Command g4-book builds, merges and shows opening books.
+//
+// Usage:
+//
+//	g4-book [-records games.txt] [-selfplay 100] [-plies 12] [-merge old.g4bk] -o book.g4bk
+//	g4-book -show book.g4bk [-position 8|8|8|8|8|8|8|8] [-mover yellow]
+//
+// The book holds the first plies moves of the games of the record files and
+// of the self-play games, where the engine plays both sides at a noisy level
+// so that the games vary.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"g4/book"
+	"g4/record"
+	"g4/search"
+	"os"
+	"strings"
+	"time"
+)
+
+func main() {
+	recordPaths := flag.String("records", "", "comma-separated game record files to take openings from")
+	selfPlay := flag.Int("selfplay", 0, "number of self-play games to take openings from")
+	levelName := flag.String("level", "intermediate", "strength level of the engine in self-play games")
+	think := flag.Duration("think", 100*time.Millisecond, "thinking time of the engine on each move of self-play games")
+	seed := flag.Int64("seed", 1, "seed of the first self-play game, incremented for each game")
+	moveLimit := flag.Int("move-limit", 200, "draw self-play games after this many moves")
+	plies := flag.Int("plies", 12, "number of moves of each game added to the book")
+	mergePaths := flag.String("merge", "", "comma-separated books to merge into the output")
+	minGames := flag.Int("min-games", 1, "remove the moves played in fewer games")
+	output := flag.String("o", "", "output file")
+	show := flag.String("show", "", "show the moves of a book in a position instead of building a book")
+	position := flag.String("position", bitsim.StartingPosition, "position to show")
+	moverName := flag.String("mover", "yellow", "player to move in the position to show (yellow or red)")
+	flag.Parse()
+
+	if *show != "" {
+		if err := showPosition(*show, *position, *moverName); err != nil {
+			fail(err)
+		}
+		return
+	}
+	if *output == "" {
+		fail(fmt.Errorf("missing output file"))
+	}
+
+	b := book.New()
+	for _, path := range splitList(*recordPaths) {
+		if err := addRecords(b, path, *plies); err != nil {
+			fail(err)
+		}
+	}
+	if *selfPlay > 0 {
+		level, err := search.ParseLevel(*levelName)
+		if err != nil {
+			fail(err)
+		}
+		for k := 0; k < *selfPlay; k++ {
+			level.Seed = *seed + int64(k)
+			if err := addSelfPlay(b, level, *think, *moveLimit, *plies); err != nil {
+				fail(err)
+			}
+		}
+	}
+	for _, path := range splitList(*mergePaths) {
+		other, err := book.Load(path)
+		if err != nil {
+			fail(fmt.Errorf("%s: %w", path, err))
+		}
+		b.Merge(other)
+	}
+	b.Prune(*minGames)
+
+	if err := b.Save(*output); err != nil {
+		fail(err)
+	}
+	fmt.Printf("%d positions\n", b.Len())
+}
+
+// addRecords adds the openings of the games of a record file to the book.
+func addRecords(b *book.Book, path string, plies int) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	records, err := record.NewReader(f).ReadAll()
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for k, rec := range records {
+		if err := b.AddRecord(rec, plies); err != nil {
+			return fmt.Errorf("%s: game %d: %w", path, k+1, err)
+		}
+	}
+	return nil
+}
+
+// addSelfPlay plays a game of the engine against itself, and adds its opening to the book.
+func addSelfPlay(b *book.Book, level search.Level, think time.Duration, moveLimit, plies int) error {
+	controller := search.NewController(search.New(search.Options{Threads: 1, TableSize: 1 << 16}))
+	defer controller.Close()
+	controller.SetLevel(level)
+
+	game := bitsim.Game{Mover: g4.Yellow, Rules: bitsim.Rules{MoveLimit: moveLimit}}
+	var positions []bitsim.Game
+	var moves []g4.Move
+	for {
+		result, err := controller.Think(context.Background(), game, search.Clock{MoveTime: think})
+		if err != nil {
+			return err
+		}
+		positions = append(positions, game)
+		moves = append(moves, result.Move)
+		game, err = game.Apply(result.Move)
+		if err != nil {
+			for k := range moves {
+				if k < plies {
+					b.Add(positions[k], moves[k], err)
+				}
+			}
+			return nil
+		}
+	}
+}
+
+// showPosition prints the moves of a book in a position.
+func showPosition(path, position, moverName string) error {
+	b, err := book.Load(path)
+	if err != nil {
+		return err
+	}
+	board, err := bitsim.FromString(position)
+	if err != nil {
+		return err
+	}
+	g := bitsim.Game{Board: board, Mover: g4.Yellow}
+	switch moverName {
+	case "yellow":
+	case "red":
+		g.Mover = g4.Red
+	default:
+		return fmt.Errorf("invalid mover: %s", moverName)
+	}
+
+	entries := b.Lookup(g)
+	if len(entries) == 0 {
+		fmt.Println("position not in book")
+		return nil
+	}
+	fmt.Printf("%-5s %8s %8s %6s %6s %6s %7s\n", "move", "weight", "games", "wins", "draws", "losses", "score")
+	for _, entry := range entries {
+		fmt.Printf("%-5v %8d %8d %6d %6d %6d %6.1f%%\n",
+			entry.Move, entry.Weight, entry.Games(), entry.Wins, entry.Draws, entry.Losses, 100*entry.Score())
+	}
+	return nil
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,112 @@
+// Command g4-coach reviews recorded games and annotates the mistakes of the players.
+//
+// Usage:
+//
+//	g4-coach [-depth 6] [-threshold 40] [-o annotated.txt] games.txt...
+//
+// Blunders, missed wins and missed blocks are written as comments of the
+// moves, with the best alternatives as variations. The records are written
+// to the output file, or to the standard output.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"g4"
+	"g4/coach"
+	"g4/eval"
+	"g4/nn"
+	"g4/record"
+	"g4/search"
+	"g4/tablebase"
+	"io"
+	"os"
+)
+
+func main() {
+	depth := flag.Int("depth", coach.DefaultDepth, "search depth of the review")
+	threshold := flag.Int("threshold", coach.DefaultThreshold, "loss of score making a blunder")
+	threads := flag.Int("threads", search.DefaultThreads(), "number of threads of the engine")
+	tablebasePath := flag.String("tablebase", "", "endgame tablebase used by the engine")
+	networkPath := flag.String("network", "", "neural network evaluator, instead of the heuristic one")
+	output := flag.String("o", "", "output file (defaults to the standard output)")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fail(fmt.Errorf("missing record file"))
+	}
+	options := coach.Options{
+		Depth:     *depth,
+		Threshold: *threshold,
+		Search:    search.Options{Threads: *threads},
+	}
+	var err error
+	if *tablebasePath != "" {
+		if options.Search.Tablebase, err = tablebase.Load(*tablebasePath); err != nil {
+			fail(err)
+		}
+	}
+	options.Search.Evaluator = eval.New(nil)
+	if *networkPath != "" {
+		if options.Search.Evaluator, err = nn.Load(*networkPath); err != nil {
+			fail(err)
+		}
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fail(err)
+		}
+		defer f.Close()
+		out = f
+	}
+	w := record.NewWriter(out)
+	for _, path := range flag.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			fail(err)
+		}
+		records, err := record.NewReader(f).ReadAll()
+		f.Close()
+		if err != nil {
+			fail(err)
+		}
+		for k, rec := range records {
+			start, err := rec.StartingGame()
+			if err != nil {
+				fail(fmt.Errorf("%s, game %d: %w", path, k+1, err))
+			}
+			moves := make([]g4.Move, len(rec.Moves))
+			for ply, node := range rec.Moves {
+				moves[ply] = node.Move
+			}
+			annotations, err := coach.Review(context.Background(), start, moves, options)
+			if err != nil {
+				fail(fmt.Errorf("%s, game %d: %w", path, k+1, err))
+			}
+			coach.Annotate(rec, annotations)
+			if err := w.Write(rec); err != nil {
+				fail(err)
+			}
+			fmt.Fprintf(os.Stderr, "%s, game %d: %s\n", path, k+1, summary(annotations))
+		}
+	}
+}
+
+// summary counts the mistakes of each kind.
+func summary(annotations []coach.Annotation) string {
+	counts := make(map[coach.Kind]int)
+	for _, a := range annotations {
+		counts[a.Kind]++
+	}
+	return fmt.Sprintf("blunders %d, missed wins %d, missed blocks %d",
+		counts[coach.Blunder], counts[coach.MissedWin], counts[coach.MissedBlock])
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,62 @@
+// Command g4-engine runs the g4 engine behind the text protocol of package protocol.
+//
+// Usage:
+//
+//	g4-engine [-threads 4] [-level max] [-book book.g4bk] [-tablebase table.g4tb] [-network net.g4nn]
+//
+// Commands are read from the standard input and answers written to the
+// standard output, so that any client of the protocol can drive the engine.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"g4/book"
+	"g4/nn"
+	"g4/protocol"
+	"g4/search"
+	"g4/tablebase"
+	"os"
+)
+
+func main() {
+	threads := flag.Int("threads", search.DefaultThreads(), "number of search threads")
+	levelName := flag.String("level", search.MaxLevel.Name, "strength level of the engine")
+	bookPath := flag.String("book", "", "opening book")
+	tablebasePath := flag.String("tablebase", "", "endgame tablebase")
+	networkPath := flag.String("network", "", "neural network evaluator, instead of the heuristic one")
+	flag.Parse()
+
+	options := search.Options{Threads: *threads}
+	var err error
+	if *bookPath != "" {
+		if options.Book, err = book.Load(*bookPath); err != nil {
+			fail(err)
+		}
+	}
+	if *tablebasePath != "" {
+		if options.Tablebase, err = tablebase.Load(*tablebasePath); err != nil {
+			fail(err)
+		}
+	}
+	if *networkPath != "" {
+		if options.Evaluator, err = nn.Load(*networkPath); err != nil {
+			fail(err)
+		}
+	}
+	level, err := search.ParseLevel(*levelName)
+	if err != nil {
+		fail(err)
+	}
+
+	server := protocol.NewServer(options)
+	server.SetLevel(level)
+	if err := server.Serve(os.Stdin, os.Stdout); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
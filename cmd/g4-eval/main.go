@@ -0,0 +1,64 @@
+// Command g4-eval prints the static evaluation of a position, term by term.
+//
+// Usage:
+//
+//	g4-eval [-weights file.json] [-network net.g4nn] [-mover red] <position>
+//
+// The position uses the board notation of bitsim.FromString, and defaults to
+// the starting position. Scores are given from the point of view of the mover.
+// With a neural network, its score is printed after the breakdown.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"g4/eval"
+	"g4/nn"
+	"os"
+)
+
+func main() {
+	weightsPath := flag.String("weights", "", "read term weights from this JSON file")
+	networkPath := flag.String("network", "", "also print the score of this neural network")
+	mover := flag.String("mover", "yellow", "player to move (yellow or red)")
+	flag.Parse()
+
+	position := bitsim.StartingPosition
+	if flag.NArg() > 0 {
+		position = flag.Arg(0)
+	}
+	board, err := bitsim.FromString(position)
+	if err != nil {
+		fail(err)
+	}
+	game := bitsim.Game{Board: board, Mover: g4.Yellow}
+	switch *mover {
+	case "yellow":
+	case "red":
+		game.Mover = g4.Red
+	default:
+		fail(fmt.Errorf("invalid mover: %s", *mover))
+	}
+
+	weights := eval.DefaultWeights
+	if *weightsPath != "" {
+		if weights, err = eval.LoadWeights(*weightsPath); err != nil {
+			fail(err)
+		}
+	}
+	fmt.Print(eval.New(weights).Breakdown(game))
+	if *networkPath != "" {
+		network, err := nn.Load(*networkPath)
+		if err != nil {
+			fail(err)
+		}
+		fmt.Printf("network: %+d (raw output %.4f)\n", network.Evaluate(game), network.Forward(game))
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,98 @@
+// Command g4-fairplay compares the moves of players in recorded games with the top choices of the engine.
+//
+// Usage:
+//
+//	g4-fairplay [-depths 2,4,6] [-skip 4] [-match 0.9] [-loss 5] [-min-moves 40] games.txt...
+//
+// It prints, for each player, the rate of moves matching the top choice of
+// the engine at each depth and the average score lost by a move, and flags the
+// players crossing the thresholds. Everything runs offline.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"g4/eval"
+	"g4/fairplay"
+	"g4/nn"
+	"g4/record"
+	"g4/search"
+	"g4/tablebase"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	depths := flag.String("depths", "2,4,6", "search depths of the comparison, separated by commas")
+	skip := flag.Int("skip", fairplay.DefaultSkipPlies, "number of opening moves of each game left out (-1 keeps all)")
+	matchRate := flag.Float64("match", fairplay.DefaultThresholds.MatchRate, "lowest rate of matches at the greatest depth which is flagged")
+	averageLoss := flag.Float64("loss", fairplay.DefaultThresholds.AverageLoss, "greatest average score loss which is flagged")
+	minMoves := flag.Int("min-moves", fairplay.DefaultThresholds.MinMoves, "number of moves needed to flag a player")
+	threads := flag.Int("threads", search.DefaultThreads(), "number of threads of the engine")
+	tablebasePath := flag.String("tablebase", "", "endgame tablebase used by the engine")
+	networkPath := flag.String("network", "", "neural network evaluator, instead of the heuristic one")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fail(fmt.Errorf("missing record file"))
+	}
+	options := fairplay.Options{SkipPlies: *skip, Search: search.Options{Threads: *threads}}
+	var err error
+	if options.Depths, err = parseDepths(*depths); err != nil {
+		fail(err)
+	}
+	if *tablebasePath != "" {
+		if options.Search.Tablebase, err = tablebase.Load(*tablebasePath); err != nil {
+			fail(err)
+		}
+	}
+	options.Search.Evaluator = eval.New(nil)
+	if *networkPath != "" {
+		if options.Search.Evaluator, err = nn.Load(*networkPath); err != nil {
+			fail(err)
+		}
+	}
+
+	var records []*record.Record
+	for _, path := range flag.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			fail(err)
+		}
+		read, err := record.NewReader(f).ReadAll()
+		f.Close()
+		if err != nil {
+			fail(fmt.Errorf("%s: %w", path, err))
+		}
+		records = append(records, read...)
+	}
+
+	report, err := fairplay.Analyze(context.Background(), records, options)
+	if err != nil {
+		fail(err)
+	}
+	thresholds := fairplay.Thresholds{MatchRate: *matchRate, AverageLoss: *averageLoss, MinMoves: *minMoves}
+	if err := report.WriteTable(os.Stdout, thresholds); err != nil {
+		fail(err)
+	}
+}
+
+// parseDepths reads increasing search depths separated by commas.
+func parseDepths(s string) ([]int, error) {
+	var depths []int
+	for _, field := range strings.Split(s, ",") {
+		depth, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || depth <= 0 || (len(depths) > 0 && depth <= depths[len(depths)-1]) {
+			return nil, fmt.Errorf("invalid depths: %s", s)
+		}
+		depths = append(depths, depth)
+	}
+	return depths, nil
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,143 @@
+// Command g4-puzzles finds tactical puzzles in self-play and recorded games.
+//
+// Usage:
+//
+//	g4-puzzles [-records games.txt] [-selfplay 100] [-min-moves 2] [-max-moves 3] [-tilts] -o puzzles.txt
+//
+// A puzzle is a position with a single winning move, leading to a forced win
+// in a few moves. Puzzles are written as game records, whose main line is the
+// solution. They can be played with g4 -puzzles puzzles.txt.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"g4/bitsim"
+	"g4/puzzle"
+	"g4/record"
+	"g4/selfplay"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// candidate is a position to examine.
+type candidate struct {
+	game   bitsim.Game
+	source string
+}
+
+func main() {
+	recordsPath := flag.String("records", "", "game records to scan")
+	selfplayGames := flag.Int("selfplay", 0, "number of self-play games to scan")
+	depth := flag.Int("depth", 2, "search depth of the self-play games")
+	seed := flag.Int64("seed", 1, "seed of the self-play games")
+	minMoves := flag.Int("min-moves", 2, "minimum number of moves of the wins")
+	maxMoves := flag.Int("max-moves", 3, "maximum number of moves of the wins")
+	tilts := flag.Bool("tilts", false, "keep only the puzzles whose solution needs a tilt")
+	budget := flag.Int("budget", puzzle.DefaultBudget, "node budget of the solver for each alternative first move")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of positions examined at the same time")
+	output := flag.String("o", "", "output file")
+	flag.Parse()
+
+	if *output == "" {
+		fail(fmt.Errorf("missing output file"))
+	}
+	var candidates []candidate
+	seen := make(map[bitsim.Game]bool)
+	add := func(g bitsim.Game, source string) {
+		// NB: positions are compared without the move counters.
+		position := bitsim.Game{Board: g.Board, Mover: g.Mover, Rules: g.Rules}
+		if g.Validate() == nil && !seen[position] {
+			seen[position] = true
+			candidates = append(candidates, candidate{game: position, source: source})
+		}
+	}
+
+	if *recordsPath != "" {
+		f, err := os.Open(*recordsPath)
+		if err != nil {
+			fail(err)
+		}
+		records, err := record.NewReader(f).ReadAll()
+		f.Close()
+		if err != nil {
+			fail(err)
+		}
+		for k, rec := range records {
+			games, _ := rec.Replay()
+			for ply, game := range games {
+				add(game, fmt.Sprintf("%s, game %d, ply %d", *recordsPath, k+1, ply))
+			}
+		}
+	}
+	if *selfplayGames > 0 {
+		options := selfplay.Options{Games: *selfplayGames, Seed: *seed, Depth: *depth, RandomPlies: 4}
+		err := selfplay.Generate(context.Background(), options, func(samples []selfplay.Sample) error {
+			for _, sample := range samples {
+				// The move limit of self-play is no rule of the puzzles.
+				sample.Game.Rules = bitsim.Rules{}
+				add(sample.Game, fmt.Sprintf("self-play, seed %d, game %d, ply %d", *seed, sample.GameIndex+1, sample.Ply))
+			}
+			return nil
+		})
+		if err != nil {
+			fail(err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d positions to examine\n", len(candidates))
+
+	options := puzzle.Options{MinMoves: *minMoves, MaxMoves: *maxMoves, TiltsOnly: *tilts, Budget: *budget}
+	found := make([]*puzzle.Puzzle, len(candidates))
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < *workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := range indices {
+				if p, ok := puzzle.Find(context.Background(), candidates[k].game, options); ok {
+					p.Source = candidates[k].source
+					found[k] = &p
+				}
+			}
+		}()
+	}
+	for k := range candidates {
+		indices <- k
+	}
+	close(indices)
+	wg.Wait()
+
+	f, err := os.Create(*output)
+	if err != nil {
+		fail(err)
+	}
+	w := record.NewWriter(f)
+	count, withTilt := 0, 0
+	for _, p := range found {
+		if p == nil {
+			continue
+		}
+		if err = w.Write(p.Record()); err != nil {
+			break
+		}
+		count++
+		if p.Tilt {
+			withTilt++
+		}
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fail(err)
+	}
+	fmt.Printf("%d puzzles, %d with a tilt\n", count, withTilt)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,102 @@
+// Command g4-selfplay generates training data from games of the engine against itself.
+//
+// Usage:
+//
+//	g4-selfplay [-games 1000] [-depth 4] [-seed 1] [-format jsonl] -o data.jsonl
+//
+// Each sample is a position, the distribution of the moves of the engine and
+// the outcome of the game. Games open with random moves so that they vary,
+// and the same seed always gives the same data.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"g4/eval"
+	"g4/nn"
+	"g4/selfplay"
+	"os"
+	"runtime"
+)
+
+func main() {
+	games := flag.Int("games", 1000, "number of games")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of games played at the same time")
+	seed := flag.Int64("seed", 1, "seed of the games")
+	depth := flag.Int("depth", selfplay.DefaultDepth, "search depth used to score moves")
+	temperature := flag.Float64("temperature", selfplay.DefaultTemperature, "temperature of the move distributions, in evaluation units")
+	randomPlies := flag.Int("random-plies", 4, "number of random moves opening each game")
+	moveLimit := flag.Int("move-limit", selfplay.DefaultMoveLimit, "draw games after this many moves")
+	weightsPath := flag.String("weights", "", "JSON file of evaluation weights")
+	networkPath := flag.String("network", "", "neural network evaluator, instead of the heuristic one")
+	format := flag.String("format", "jsonl", "format of the dataset (jsonl or binary)")
+	output := flag.String("o", "", "output file")
+	flag.Parse()
+
+	if *output == "" {
+		fail(fmt.Errorf("missing output file"))
+	}
+	options := selfplay.Options{
+		Games:       *games,
+		Workers:     *workers,
+		Seed:        *seed,
+		Depth:       *depth,
+		Temperature: *temperature,
+		RandomPlies: *randomPlies,
+		MoveLimit:   *moveLimit,
+	}
+	if *weightsPath != "" {
+		weights, err := eval.LoadWeights(*weightsPath)
+		if err != nil {
+			fail(err)
+		}
+		options.Evaluator = eval.New(weights)
+	}
+	if *networkPath != "" {
+		network, err := nn.Load(*networkPath)
+		if err != nil {
+			fail(err)
+		}
+		options.Evaluator = network
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		fail(err)
+	}
+	var w selfplay.Writer
+	switch *format {
+	case "jsonl":
+		w = selfplay.NewJSONWriter(f)
+	case "binary":
+		w = selfplay.NewBinaryWriter(f)
+	default:
+		fail(fmt.Errorf("invalid format: %s", *format))
+	}
+
+	count, played := 0, 0
+	err = selfplay.Generate(context.Background(), options, func(samples []selfplay.Sample) error {
+		count += len(samples)
+		played++
+		if played%100 == 0 {
+			fmt.Fprintf(os.Stderr, "%d games, %d samples\n", played, count)
+		}
+		return w.Write(samples)
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fail(err)
+	}
+	fmt.Printf("%d games, %d samples\n", played, count)
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
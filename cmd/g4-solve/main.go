@@ -0,0 +1,62 @@
+// Command g4-solve proves the outcome of a position and prints its proof tree.
+//
+// Usage:
+//
+//	g4-solve [-mover red] [-budget N] [-tree] <position>
+//
+// The position uses the board notation of bitsim.FromString. The outcome is
+// given for the player to move.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"g4/solver"
+	"os"
+)
+
+func main() {
+	mover := flag.String("mover", "yellow", "player to move (yellow or red)")
+	budget := flag.Int("budget", 1000000, "maximum number of positions to create")
+	printTree := flag.Bool("tree", false, "print the whole proof tree instead of its main line")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fail(fmt.Errorf("expected a position"))
+	}
+	board, err := bitsim.FromString(flag.Arg(0))
+	if err != nil {
+		fail(err)
+	}
+	game := bitsim.Game{Board: board, Mover: g4.Yellow}
+	switch *mover {
+	case "yellow":
+	case "red":
+		game.Mover = g4.Red
+	default:
+		fail(fmt.Errorf("invalid mover: %s", *mover))
+	}
+
+	result, err := solver.Solve(context.Background(), game, *budget)
+	if err != nil && result.Outcome == solver.Unknown {
+		fmt.Printf("unknown after %d positions: %v\n", result.Nodes, err)
+		os.Exit(1)
+	}
+	if err != nil {
+		fail(err)
+	}
+	fmt.Printf("%v (proof depth %d, %d positions)\n", result.Outcome, result.Proof.Depth(), result.Nodes)
+	if *printTree {
+		fmt.Print(result.Proof)
+		return
+	}
+	fmt.Println(result.Proof.Line())
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,67 @@
+// Command g4-stats measures the game tree of g4 along random games.
+//
+// Usage:
+//
+//	g4-stats [-games 10000] [-seed 1] [-bucket 10] [-forbid-noop-tilts] [-position "8|8|8|8|8|8|8|8"]
+//
+// It prints the average branching factor, how often tilts are no-ops, create
+// a double connect-4 or decide the game, the results of random play and the
+// distribution of the game lengths, as a base for discussions on the rules.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"g4/gametree"
+	"math/rand"
+	"os"
+)
+
+func main() {
+	games := flag.Int("games", 10000, "number of random games")
+	seed := flag.Int64("seed", 1, "seed of the random games")
+	limit := flag.Int("limit", gametree.DefaultGameLimit, "abandon games after this many plies")
+	bucket := flag.Int("bucket", 10, "width of the buckets of the game length distribution, in plies")
+	noProgressLimit := flag.Int("no-progress", 0, "draw after this many consecutive moves without a token drop (0 disables)")
+	moveLimit := flag.Int("move-limit", 0, "draw after this many moves in total (0 disables)")
+	forbidNoOpTilts := flag.Bool("forbid-noop-tilts", false, "forbid tilts which leave the board unchanged")
+	position := flag.String("position", bitsim.StartingPosition, "starting position of the games")
+	moverName := flag.String("mover", "yellow", "player to move in the starting position (yellow or red)")
+	flag.Parse()
+
+	board, err := bitsim.FromString(*position)
+	if err != nil {
+		fail(err)
+	}
+	start := bitsim.Game{
+		Board: board,
+		Mover: g4.Yellow,
+		Rules: bitsim.Rules{
+			NoProgressLimit: *noProgressLimit,
+			MoveLimit:       *moveLimit,
+			ForbidNoOpTilts: *forbidNoOpTilts,
+		},
+	}
+	switch *moverName {
+	case "yellow":
+	case "red":
+		start.Mover = g4.Red
+	default:
+		fail(fmt.Errorf("invalid mover: %s", *moverName))
+	}
+	if err := board.IsReachable(start.Mover); err != nil {
+		fail(err)
+	}
+
+	stats := gametree.Sample(rand.New(rand.NewSource(*seed)), start, *games, *limit)
+	if err := stats.WriteTable(os.Stdout, *bucket); err != nil {
+		fail(err)
+	}
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,113 @@
+// Command g4-tablebase generates endgame tablebases.
+//
+// Usage:
+//
+//	g4-tablebase [-k 6] [-seeds 1000] [-records games.txt] [-merge old.g4tb] -o table.g4tb
+//
+// The table solves the whole future of seed positions with at most k empty
+// squares. Seeds are drawn at random, and taken from game records, where the
+// first position with at most k empty squares of each game is used.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"g4/bitsim"
+	"g4/record"
+	"g4/tablebase"
+	"math/rand"
+	"os"
+	"strings"
+)
+
+func main() {
+	maxEmpty := flag.Int("k", 6, "maximum number of empty squares")
+	seedCount := flag.Int("seeds", 1000, "number of random seed positions")
+	seed := flag.Int64("seed", 1, "seed of the random positions")
+	recordPaths := flag.String("records", "", "comma-separated game record files to take seed positions from")
+	mergePaths := flag.String("merge", "", "comma-separated tables to merge into the output")
+	limit := flag.Int("limit", 10000000, "maximum number of positions")
+	output := flag.String("o", "", "output file")
+	flag.Parse()
+
+	if *output == "" {
+		fail(fmt.Errorf("missing output file"))
+	}
+	if *maxEmpty < 0 || *maxEmpty > 64 {
+		fail(fmt.Errorf("invalid number of empty squares: %d", *maxEmpty))
+	}
+
+	r := rand.New(rand.NewSource(*seed))
+	var seeds []bitsim.Game
+	for k := 0; k < *seedCount; k++ {
+		if g, ok := tablebase.RandomSeed(r, *maxEmpty); ok {
+			seeds = append(seeds, g)
+		}
+	}
+	for _, path := range splitList(*recordPaths) {
+		games, err := recordSeeds(path, *maxEmpty)
+		if err != nil {
+			fail(err)
+		}
+		seeds = append(seeds, games...)
+	}
+
+	table, err := tablebase.Generate(seeds, *maxEmpty, *limit)
+	if err != nil {
+		fail(err)
+	}
+	tables := []*tablebase.Table{table}
+	for _, path := range splitList(*mergePaths) {
+		other, err := tablebase.Load(path)
+		if err != nil {
+			fail(fmt.Errorf("%s: %w", path, err))
+		}
+		tables = append(tables, other)
+	}
+	table = tablebase.Merge(tables...)
+
+	if err := table.Save(*output); err != nil {
+		fail(err)
+	}
+	fmt.Printf("%d seeds, %d positions\n", len(seeds), table.Len())
+}
+
+// recordSeeds returns the first position with at most maxEmpty empty squares of each game of a record file.
+func recordSeeds(path string, maxEmpty int) ([]bitsim.Game, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	records, err := record.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var seeds []bitsim.Game
+	for k, rec := range records {
+		games, err := rec.Replay()
+		if err != nil {
+			return nil, fmt.Errorf("%s: game %d: %w", path, k+1, err)
+		}
+		for _, g := range games {
+			if 64-g.Board.Count() <= maxEmpty && g.Validate() == nil {
+				seeds = append(seeds, g)
+				break
+			}
+		}
+	}
+	return seeds, nil
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,212 @@
+// Command g4-tournament plays engines against each other and ranks them.
+//
+// Usage:
+//
+//	g4-tournament -engine name=a,level=max -engine name=b,depth=4 [-format round-robin] [-random-openings 50] [-concurrency 4]
+//	g4-tournament -engine name=new,network=new.g4nn -engine name=old -sprt 0,10
+//	g4-tournament -engine name=g4 -engine "name=other,cmd=other-engine --quiet"
+//
+// Engine specifications are lists of key=value settings, separated by commas:
+// name, level, depth, movetime (in milliseconds), threads, book, tablebase and
+// network for the built-in engine, or cmd for an external engine speaking the
+// protocol of package protocol. The cmd setting must come last, since the
+// command line takes the rest of the specification.
+//
+// Each opening is played twice by each pair of engines, colours swapped.
+// The crosstable is printed at the end, or when the SPRT concludes.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"g4/bitsim"
+	"g4/book"
+	"g4/nn"
+	"g4/protocol"
+	"g4/record"
+	"g4/search"
+	"g4/tablebase"
+	"g4/tournament"
+	"math/rand"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// specs collects repeated -engine flags.
+type specs []string
+
+func (s *specs) String() string {
+	return strings.Join(*s, " ")
+}
+
+func (s *specs) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+func main() {
+	var engineSpecs specs
+	flag.Var(&engineSpecs, "engine", "engine specification, repeated for each engine")
+	formatName := flag.String("format", "round-robin", "pairings (round-robin or gauntlet, where the first engine plays the others)")
+	openingsPath := flag.String("openings", "", "game records whose first moves are the openings")
+	randomOpenings := flag.Int("random-openings", 10, "number of random openings, when no records are given")
+	openingPlies := flag.Int("opening-plies", 4, "number of moves of the openings")
+	seed := flag.Int64("seed", 1, "seed of the random openings")
+	concurrency := flag.Int("concurrency", 1, "number of games played at the same time")
+	moveLimit := flag.Int("move-limit", tournament.DefaultMoveLimit, "draw games after this many moves past the opening")
+	moveTime := flag.Int("movetime", 100, "default thinking time per move, in milliseconds")
+	tablebasePath := flag.String("tablebase", "", "endgame tablebase adjudicating the games")
+	sprtBounds := flag.String("sprt", "", "run a SPRT of the first engine against the second, with elo0,elo1 hypotheses")
+	alpha := flag.Float64("alpha", 0.05, "false positive rate of the SPRT")
+	beta := flag.Float64("beta", 0.05, "false negative rate of the SPRT")
+	recordsPath := flag.String("records", "", "file where the game records are appended")
+	flag.Parse()
+
+	format, err := tournament.ParseFormat(*formatName)
+	if err != nil {
+		fail(err)
+	}
+	options := tournament.Options{
+		Format:      format,
+		Concurrency: *concurrency,
+		MoveLimit:   *moveLimit,
+	}
+	for k, spec := range engineSpecs {
+		engine, err := parseEngine(spec, k, time.Duration(*moveTime)*time.Millisecond)
+		if err != nil {
+			fail(fmt.Errorf("invalid engine '%s': %w", spec, err))
+		}
+		options.Engines = append(options.Engines, engine)
+	}
+
+	if *openingsPath != "" {
+		f, err := os.Open(*openingsPath)
+		if err != nil {
+			fail(err)
+		}
+		records, err := record.NewReader(f).ReadAll()
+		f.Close()
+		if err != nil {
+			fail(err)
+		}
+		options.Openings = tournament.RecordOpenings(records, *openingPlies)
+	} else {
+		options.Openings = tournament.RandomOpenings(rand.New(rand.NewSource(*seed)), *randomOpenings, *openingPlies, bitsim.Rules{})
+	}
+	if *tablebasePath != "" {
+		if options.Tablebase, err = tablebase.Load(*tablebasePath); err != nil {
+			fail(err)
+		}
+	}
+	if *sprtBounds != "" {
+		var sprt tournament.SPRT
+		if _, err := fmt.Sscanf(*sprtBounds, "%g,%g", &sprt.Elo0, &sprt.Elo1); err != nil {
+			fail(fmt.Errorf("invalid SPRT bounds '%s' (expected elo0,elo1)", *sprtBounds))
+		}
+		sprt.Alpha, sprt.Beta = *alpha, *beta
+		options.SPRT = &sprt
+	}
+
+	var w *record.Writer
+	if *recordsPath != "" {
+		f, err := os.OpenFile(*recordsPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			fail(err)
+		}
+		defer f.Close()
+		w = record.NewWriter(f)
+	}
+
+	// Interrupting the tournament still prints the crosstable of the finished games.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	date := time.Now().Format("2006.01.02")
+	played := 0
+	results, err := tournament.Run(ctx, options, func(game tournament.Game) {
+		played++
+		game.Record.Date = date
+		fmt.Fprintf(os.Stderr, "game %d: %s vs %s, opening %d: %v (%s)\n",
+			played, game.Record.Yellow, game.Record.Red, game.Opening+1, game.Record.Result, game.Record.Reason)
+		if w != nil {
+			if err := w.Write(game.Record); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}
+	})
+	if results != nil {
+		fmt.Println()
+		results.WriteCrosstable(os.Stdout)
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+// parseEngine reads an engine specification. Engines are named after their
+// index when the specification has no name.
+func parseEngine(spec string, index int, moveTime time.Duration) (tournament.Engine, error) {
+	name := fmt.Sprintf("engine%d", index+1)
+	options := search.Options{Threads: 1}
+	level := search.MaxLevel
+	limits := protocol.Go{MoveTime: moveTime}
+	command := ""
+
+	for spec != "" {
+		var setting string
+		if strings.HasPrefix(spec, "cmd=") {
+			setting, spec = spec, ""
+		} else if k := strings.Index(spec, ","); k >= 0 {
+			setting, spec = spec[:k], spec[k+1:]
+		} else {
+			setting, spec = spec, ""
+		}
+		k := strings.Index(setting, "=")
+		if k < 0 {
+			return tournament.Engine{}, fmt.Errorf("missing value of '%s'", setting)
+		}
+		key, value := setting[:k], setting[k+1:]
+
+		var err error
+		switch key {
+		case "name":
+			name = value
+		case "cmd":
+			command = value
+		case "level":
+			level, err = search.ParseLevel(value)
+		case "depth":
+			limits.Depth, err = strconv.Atoi(value)
+		case "movetime":
+			var ms int
+			ms, err = strconv.Atoi(value)
+			limits.MoveTime = time.Duration(ms) * time.Millisecond
+		case "threads":
+			options.Threads, err = strconv.Atoi(value)
+		case "book":
+			options.Book, err = book.Load(value)
+		case "tablebase":
+			options.Tablebase, err = tablebase.Load(value)
+		case "network":
+			options.Evaluator, err = nn.Load(value)
+		default:
+			err = fmt.Errorf("unknown setting '%s'", key)
+		}
+		if err != nil {
+			return tournament.Engine{}, err
+		}
+	}
+
+	if command != "" {
+		return tournament.External(name, command, limits), nil
+	}
+	return tournament.Builtin(name, options, level, search.Clock{MoveTime: limits.MoveTime, Depth: limits.Depth}), nil
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,87 @@
+// Command g4-tree exports the game tree of a position to Graphviz.
+//
+// Usage:
+//
+//	g4-tree [-mover red] [-depth 2] [-pv] [-o tree.dot] <position>
+//
+// The position uses the board notation of bitsim.FromString. The output is
+// in the DOT language, to be rendered with, for instance:
+//
+//	g4-tree -depth 2 -pv "8|8|8|8|8|8|8|8" | dot -Tsvg > tree.svg
+package main
+
+import (
+	"flag"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"g4/eval"
+	"g4/gametree"
+	"g4/nn"
+	"os"
+)
+
+func main() {
+	mover := flag.String("mover", "yellow", "player to move (yellow or red)")
+	depth := flag.Int("depth", 2, "number of plies to explore")
+	principal := flag.Bool("pv", false, "keep only the principal variations")
+	maxNodes := flag.Int("max-nodes", gametree.DefaultMaxNodes, "maximum number of positions")
+	weightsPath := flag.String("weights", "", "JSON file of evaluation weights")
+	networkPath := flag.String("network", "", "neural network evaluator, instead of the heuristic one")
+	output := flag.String("o", "", "output file, instead of the standard output")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fail(fmt.Errorf("expected a position"))
+	}
+	board, err := bitsim.FromString(flag.Arg(0))
+	if err != nil {
+		fail(err)
+	}
+	game := bitsim.Game{Board: board, Mover: g4.Yellow}
+	switch *mover {
+	case "yellow":
+	case "red":
+		game.Mover = g4.Red
+	default:
+		fail(fmt.Errorf("invalid mover: %s", *mover))
+	}
+
+	options := gametree.Options{Depth: *depth, Principal: *principal, MaxNodes: *maxNodes}
+	if *weightsPath != "" {
+		weights, err := eval.LoadWeights(*weightsPath)
+		if err != nil {
+			fail(err)
+		}
+		options.Evaluator = eval.New(weights)
+	}
+	if *networkPath != "" {
+		if options.Evaluator, err = nn.Load(*networkPath); err != nil {
+			fail(err)
+		}
+	}
+
+	tree, err := gametree.Build(game, options)
+	if err != nil {
+		fail(err)
+	}
+	w := os.Stdout
+	if *output != "" {
+		if w, err = os.Create(*output); err != nil {
+			fail(err)
+		}
+	}
+	err = tree.WriteDOT(w)
+	if closeErr := w.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fail(err)
+	}
+	fmt.Fprintf(os.Stderr, "%d positions, root %s\n", len(tree.Nodes), gametree.FormatValue(tree.Root.Value))
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
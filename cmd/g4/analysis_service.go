@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"g4/eval"
+	"g4/solver"
+	"g4/tablebase"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// AnalysisService is the opponent of the analysis mode, where the local player plays both sides.
+//
+// It analyses each position with the static evaluation and, if loaded, the endgame tablebase.
+type AnalysisService struct {
+	evaluator eval.Evaluator
+	tablebase *tablebase.Table
+}
+
+// newAnalysisService creates the opponent of the analysis mode using given evaluator. The tablebase may be nil.
+func newAnalysisService(evaluator eval.Evaluator, table *tablebase.Table) *AnalysisService {
+	return &AnalysisService{evaluator: evaluator, tablebase: table}
+}
+
+// connect builds a command that starts the analysis at once.
+func (s *AnalysisService) connect(ctx context.Context) (tea.Cmd, error) {
+	return func() tea.Msg {
+		return ConnectionSuccessful{}
+	}, nil
+}
+
+// chooseColor builds a command that gives us the first move.
+func (s *AnalysisService) chooseColor() (tea.Cmd, error) {
+	return func() tea.Msg {
+		return ColorFound(g4.Yellow)
+	}, nil
+}
+
+// sendMove builds a command that plays our move.
+func (s *AnalysisService) sendMove(move g4.Move) (tea.Cmd, error) {
+	return func() tea.Msg {
+		return move
+	}, nil
+}
+
+// receiveMove builds a command that does nothing, since we play both sides.
+func (s *AnalysisService) receiveMove(game bitsim.Game) (tea.Cmd, error) {
+	return nil, nil
+}
+
+func (s *AnalysisService) close() {}
+
+// name returns the name of the other side, which is us.
+func (s *AnalysisService) name() string {
+	return localName()
+}
+
+// viewAnalysis renders the analysis of the current position.
+func (s *AnalysisService) viewAnalysis(game bitsim.Game) string {
+	hStyle := lipgloss.NewStyle().Bold(true).Foreground(light)
+	pStyle := lipgloss.NewStyle().PaddingLeft(1).MarginBottom(1).Foreground(lighter)
+
+	mover := "yellow"
+	if game.Mover == g4.Red {
+		mover = "red"
+	}
+	if err := game.Validate(); err != nil {
+		return lipgloss.JoinVertical(lipgloss.Left, hStyle.Render("Analysis"), pStyle.Render(err.Error()))
+	}
+
+	evaluation := fmt.Sprintf("%+d for %s", s.evaluator.Evaluate(game), mover)
+
+	table := "not loaded"
+	if s.tablebase != nil {
+		switch move, value, ok := s.tablebase.BestMove(game); {
+		case !ok:
+			table = "position not found"
+		case value.Outcome == solver.Draw:
+			table = fmt.Sprintf("draw, play %v", move)
+		default:
+			table = fmt.Sprintf("%s: %v, play %v", mover, value, move)
+		}
+	}
+
+	return lipgloss.JoinVertical(
+		lipgloss.Left,
+		hStyle.Render("Evaluation"),
+		pStyle.Render(evaluation),
+		hStyle.Render("Tablebase"),
+		pStyle.Render(table),
+	)
+}
@@ -2,8 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"g4"
 	"g4/bitsim"
+	"g4/coach"
+	"g4/record"
+	"g4/search"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -17,6 +22,7 @@ const (
 	pinker  = lipgloss.Color("#F25D94")
 	light   = lipgloss.Color("#b0b0b0")
 	lighter = lipgloss.Color("#e0e0e0")
+	grey    = lipgloss.Color("#606060")
 	dark    = lipgloss.Color("#0a0a0a")
 )
 
@@ -39,15 +45,28 @@ type AppModel struct {
 	width, height int
 	keyHandler    KeyHandler
 
-	spec string
+	opponent Opponent
 
 	connStatus ConnectionStatus
 	listening  bool
 	gameStatus GameStatus
 
+	start   bitsim.Game
 	game    bitsim.Game
 	myColor g4.Color
 	history map[bitsim.Game]int
+	moves   []g4.Move
+
+	// recordPath is the file where finished games are appended, if any.
+	recordPath string
+
+	// coach configures the review of finished games, which starts at once if autoReview is set.
+	coach      coach.Options
+	autoReview bool
+	review     review
+
+	// engineInfo is the last progress report of the engine, if playing against it.
+	engineInfo *search.Info
 
 	modalContent string
 	modalHover   bool
@@ -56,10 +75,13 @@ type AppModel struct {
 }
 
 func (app AppModel) Init() tea.Cmd {
-	cmd, err := p2pService.connect(context.Background(), app.spec)
+	cmd, err := app.opponent.connect(context.Background())
 	if err != nil {
 		return handleError(err)
 	}
+	if source, ok := app.opponent.(infoSource); ok {
+		return tea.Batch(cmd, source.listen())
+	}
 	return cmd
 }
 
@@ -93,13 +115,19 @@ func (app AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case error:
 		app.debug = msg.Error()
-		if p2pService.ch != nil {
-			p2pService.ch.Close()
+		app.opponent.close()
+		var cmd tea.Cmd
+		if app.gameStatus == inProgress && len(app.moves) > 0 {
+			cmd = app.saveRecord(record.Unfinished, record.Disconnection)
 		}
 		app.connStatus = closed
 		app.gameStatus = suspended
 		app.modalContent = "Error occured:\n" + msg.Error()
-		return app, nil
+		return app, cmd
+
+	case search.Info:
+		app.engineInfo = &msg
+		return app, app.opponent.(infoSource).listen()
 
 	case tea.WindowSizeMsg:
 		app.height = msg.Height
@@ -107,7 +135,7 @@ func (app AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return app, nil
 
 	case ConnectionSuccessful:
-		cmd, err := p2pService.chooseColor()
+		cmd, err := app.opponent.chooseColor()
 		if err != nil {
 			return app, handleError(err)
 		}
@@ -116,6 +144,14 @@ func (app AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case ColorFound:
 		app.myColor = g4.Color(msg)
 		app.connStatus = connected
+		if _, ok := app.opponent.(*AnalysisService); ok {
+			app.modalContent = "Analysis mode:\nYou play both sides."
+			break
+		}
+		if puzzles, ok := app.opponent.(*PuzzleService); ok {
+			app.modalContent = puzzles.viewGoal()
+			break
+		}
 		if app.myColor == g4.Red {
 			app.modalContent = "Game on!\nYou play the red pieces."
 		}
@@ -132,40 +168,144 @@ func (app AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 		game, err := app.game.Apply(g4.Move(msg))
+		if errors.Is(err, g4.ErrorInvalidMove{}) {
+			return app, handleError(err)
+		}
 		app.game = game
+		app.moves = append(app.moves, g4.Move(msg))
+		if _, ok := app.opponent.(*AnalysisService); ok {
+			app.myColor = game.Mover
+		}
+
+		// The puzzles follow the game, and a won puzzle is solved, whoever played the last move.
+		if puzzles, ok := app.opponent.(*PuzzleService); ok {
+			puzzles.play(g4.Move(msg), game)
+			switch err.(type) {
+			case g4.YellowWins, g4.RedWins:
+				puzzles.finish()
+				app.gameStatus = yellowWins
+				if _, ok := err.(g4.RedWins); ok {
+					app.gameStatus = redWins
+				}
+				app.modalContent = "Solved!\n" + puzzles.viewTally() + "\nPlay the next puzzle with :n."
+				return app, nil
+			}
+		}
 
 		// Handle game over states.
 		switch err.(type) {
 		case g4.Draw:
 			app.modalContent = "Game over:\nThis is a draw."
 			app.gameStatus = draw
+			return app.finish(record.Conclude(game))
 		case g4.YellowWins:
 			app.modalContent = "Game over:\nYellow wins!"
 			app.gameStatus = yellowWins
+			return app.finish(record.Conclude(game))
 		case g4.RedWins:
 			app.modalContent = "Game over!\nRed wins!"
 			app.gameStatus = redWins
+			return app.finish(record.Conclude(game))
+		case g4.DrawByNoProgress:
+			app.modalContent = "Game over:\nThis is a draw, no token was dropped for too long."
+			app.gameStatus = draw
+			return app.finish(record.Conclude(game))
+		case g4.DrawByMoveLimit:
+			app.modalContent = "Game over:\nThis is a draw, the move limit is reached."
+			app.gameStatus = draw
+			return app.finish(record.Conclude(game))
 		case nil:
-			app.history[game]++
-			if app.history[game] == 3 {
+			// NB: positions are compared without the move counters.
+			position := bitsim.Game{Board: game.Board, Mover: game.Mover}
+			app.history[position]++
+			if app.history[position] == 3 {
 				app.modalContent = "Game over!\nThis is a draw by 3-fold repetition."
 				app.gameStatus = draw
+				return app.finish(record.Draw, record.Repetition)
 			}
 		default:
 			return app, handleError(err)
 		}
 
+	case ReviewDone:
+		return app.endReview(msg)
+
+	case PuzzleMiss:
+		if puzzles, ok := app.opponent.(*PuzzleService); ok {
+			puzzles.miss()
+		}
+		app.modalContent = fmt.Sprintf("Not quite:\n%v does not win in time, try again.", g4.Move(msg))
+
+	case PuzzleHint:
+		if puzzles, ok := app.opponent.(*PuzzleService); ok {
+			puzzles.showHint(msg)
+		}
+
 	case tea.KeyMsg:
 		combo := app.keyHandler.handle(msg.String())
 		app.debug = combo
 		switch combo {
 
 		case "quit":
-			if p2pService.ch != nil {
-				p2pService.ch.Close()
-			}
+			app.opponent.close()
 			return app, tea.Quit
 
+		case "level+", "level-":
+			if engine, ok := app.opponent.(*EngineService); ok {
+				if combo == "level+" {
+					engine.changeLevel(1)
+				} else {
+					engine.changeLevel(-1)
+				}
+			}
+
+		case "review":
+			if app.gameStatus == inProgress || len(app.moves) == 0 || app.modalContent != "" {
+				break
+			}
+			if app.review.done {
+				app.review.open = !app.review.open
+				break
+			}
+			return app.startReview()
+
+		case "mistake+", "mistake-":
+			if app.review.open {
+				app = app.browseReview(combo)
+			}
+
+		case "hint", "retry", "next":
+			puzzles, ok := app.opponent.(*PuzzleService)
+
+			// NB: wait for the reply of the defender, which would land in the next puzzle.
+			if !ok || app.connStatus != connected || app.listening || app.modalContent != "" {
+				break
+			}
+			switch combo {
+			case "hint":
+				if app.gameStatus == inProgress {
+					cmd, err := puzzles.giveHint()
+					if err != nil {
+						return app, handleError(err)
+					}
+					return app, cmd
+				}
+			case "retry":
+				puzzles.restart()
+				app = app.startPuzzle(puzzles)
+			case "next":
+				if !puzzles.next() {
+					app.modalContent = "No more puzzles:\n" + puzzles.viewTally() + "."
+					break
+				}
+				app = app.startPuzzle(puzzles)
+				cmd, err := puzzles.chooseColor()
+				if err != nil {
+					return app, handleError(err)
+				}
+				return app, cmd
+			}
+
 		case ":1", ":2", ":3", ":4", ":5", ":6", ":7", ":8", ":left", ":down", ":right":
 			// Do nothing if game not in progress or if modal is open.
 			if app.connStatus != connected ||
@@ -182,7 +322,7 @@ func (app AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 			// If move is legal it means it is our turn.
-			cmd, err := p2pService.sendMove(move)
+			cmd, err := app.opponent.sendMove(move)
 			if err != nil {
 				return app, handleError(err)
 			}
@@ -196,7 +336,7 @@ func (app AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		app.gameStatus == inProgress &&
 		app.myColor != app.game.Mover &&
 		!app.listening {
-		cmd, err := p2pService.receiveMove()
+		cmd, err := app.opponent.receiveMove(app.game)
 		if err != nil {
 			return app, handleError(err)
 		}
@@ -207,6 +347,17 @@ func (app AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return app, nil
 }
 
+// startPuzzle sets up the board for the current puzzle.
+func (app AppModel) startPuzzle(puzzles *PuzzleService) AppModel {
+	app.start = puzzles.current().Start
+	app.game = app.start
+	app.moves = nil
+	app.history = make(map[bitsim.Game]int)
+	app.gameStatus = inProgress
+	app.review = review{}
+	return app
+}
+
 func (app AppModel) View() string {
 	if app.width == 0 || app.height == 0 {
 		return ""
@@ -217,7 +368,22 @@ func (app AppModel) View() string {
 	if app.modalContent != "" {
 		mainSection = viewModal(app.modalContent, app.modalHover)
 	} else {
-		rightPanel := lipgloss.NewStyle().Padding(1).Render(viewKeymap(app)) // TODO responsive right panel
+		board := app.game.Board
+		panel := viewKeymap(app)
+		if analysis, ok := app.opponent.(*AnalysisService); ok {
+			panel = lipgloss.JoinVertical(lipgloss.Left, panel, analysis.viewAnalysis(app.game))
+		}
+		if engine, ok := app.opponent.(*ExternalEngineService); ok {
+			panel = lipgloss.JoinVertical(lipgloss.Left, panel, engine.viewEngine(app.engineInfo))
+		}
+		if puzzles, ok := app.opponent.(*PuzzleService); ok {
+			panel = lipgloss.JoinVertical(lipgloss.Left, panel, puzzles.viewPuzzle())
+		}
+		if app.review.open {
+			board = app.reviewPosition().Board
+			panel = lipgloss.JoinVertical(lipgloss.Left, panel, viewReview(app))
+		}
+		rightPanel := lipgloss.NewStyle().Padding(1).Render(panel) // TODO responsive right panel
 		rightPanelWidth := lipgloss.Width(rightPanel)
 		mainSection = lipgloss.JoinHorizontal(
 			lipgloss.Center,
@@ -227,7 +393,7 @@ func (app AppModel) View() string {
 				Align(lipgloss.Center).
 				Render(
 					drawBoard(
-						app.game.Board,
+						board,
 						fitBoard(app.width-rightPanelWidth, app.height-1),
 					),
 				),
@@ -272,7 +438,17 @@ func viewStatusBar(app AppModel) string {
 		if app.connStatus != connected {
 			break
 		}
-		if app.myColor == g4.Yellow {
+		if _, ok := app.opponent.(*AnalysisService); ok {
+			if app.game.Mover == g4.Yellow {
+				spans = append(spans, "Analysis, yellow to move")
+			} else {
+				spans = append(spans, "Analysis, red to move")
+			}
+			break
+		}
+		if puzzles, ok := app.opponent.(*PuzzleService); ok {
+			spans = append(spans, fmt.Sprintf("Puzzle %d of %d, %s left", puzzles.index+1, len(puzzles.puzzles), plural(puzzles.remaining, "move")))
+		} else if app.myColor == g4.Yellow {
 			spans = append(spans, "Game on, you play yellow")
 		} else {
 			spans = append(spans, "Game on, you play red")
@@ -292,6 +468,17 @@ func viewStatusBar(app AppModel) string {
 		spans = append(spans, "Game Over > Suspended")
 	}
 
+	if app.review.running {
+		spans = append(spans, "Reviewing the game...")
+	}
+
+	if engine, ok := app.opponent.(*EngineService); ok {
+		spans = append(spans, "Level: "+engine.level().Name)
+	}
+	if app.engineInfo != nil {
+		spans = append(spans, viewEngineInfo(*app.engineInfo))
+	}
+
 	return style.Render(clipStr(strings.Join(spans, " | "), app.width-2))
 }
 
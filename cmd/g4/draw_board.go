@@ -48,7 +48,7 @@ func drawBoard(board bitsim.Board, s boardSize) string {
 	size := 8*s.tokenSize + 7*s.stride
 
 	// Get the board's array representation.
-	array := toArray(board)
+	array := board.Grid()
 
 	// Draw the board on a CanvasView.
 	canvas := NewCanvas(size, size, dark)
@@ -86,42 +86,6 @@ func drawBoard(board bitsim.Board, s boardSize) string {
 	return canvas.View()
 }
 
-func toArray(b bitsim.Board) (array [8][8]g4.Color) {
-	var col, row int
-	for _, c := range b.String() {
-		switch c {
-		case '|':
-			row = 0
-			col++
-		case 'r':
-			array[7-row][col] = g4.Red
-			row++
-		case 'y':
-			array[7-row][col] = g4.Yellow
-			row++
-		case '1':
-			row += 1
-		case '2':
-			row += 2
-		case '3':
-			row += 3
-		case '4':
-			row += 4
-		case '5':
-			row += 5
-		case '6':
-			row += 6
-		case '7':
-			row += 7
-		case '8':
-			row += 8
-		default:
-			return
-		}
-	}
-	return
-}
-
 func makeSquaredPatch(size int, col lipgloss.Color) [][]lipgloss.Color {
 	patch := make([][]lipgloss.Color, size)
 	for i := range patch {
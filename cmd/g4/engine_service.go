@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"g4/search"
+	"math/rand"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// EngineService is the opponent of a game against the local engine.
+//
+// The engine searches in a command, so that the interface keeps responding
+// while it thinks. Its progress is published as search.Info messages.
+type EngineService struct {
+	controller *search.Controller
+	infos      <-chan search.Info
+	clock      search.Clock
+	ponder     bool
+	r          *rand.Rand
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// newEngineService creates an engine opponent playing at given level under given clock.
+//
+// If ponder is set, the engine keeps searching while the player thinks.
+func newEngineService(options search.Options, level search.Level, clock search.Clock, ponder bool) *EngineService {
+	controller := search.NewController(search.New(options))
+	controller.SetLevel(level)
+	return &EngineService{
+		controller: controller,
+		infos:      controller.Subscribe(),
+		clock:      clock,
+		ponder:     ponder,
+		r:          rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// connect builds a command that starts the engine.
+func (s *EngineService) connect(ctx context.Context) (tea.Cmd, error) {
+	if s.ctx != nil {
+		return nil, errors.New("engine already started")
+	}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	return func() tea.Msg {
+		return ConnectionSuccessful{}
+	}, nil
+}
+
+// chooseColor builds a command that picks our color at random.
+func (s *EngineService) chooseColor() (tea.Cmd, error) {
+	color := g4.Yellow
+	if s.r.Intn(2) == 1 {
+		color = g4.Red
+	}
+	return func() tea.Msg {
+		return ColorFound(color)
+	}, nil
+}
+
+// sendMove builds a command that plays our move.
+//
+// The engine does not need to be told about it: it receives the whole game when it has to play.
+func (s *EngineService) sendMove(move g4.Move) (tea.Cmd, error) {
+	return func() tea.Msg {
+		return move
+	}, nil
+}
+
+// receiveMove builds a command that lets the engine search for its move.
+//
+// The time spent is taken from the clock of the engine. Once the move is
+// found, the engine starts pondering on the resulting position.
+//
+// NB: only one such command runs at a time, since the player cannot move
+// while the engine thinks.
+func (s *EngineService) receiveMove(game bitsim.Game) (tea.Cmd, error) {
+	if s.ctx == nil {
+		return nil, errors.New("engine has not been started")
+	}
+	return func() tea.Msg {
+		start := time.Now()
+		result, err := s.controller.Think(s.ctx, game, s.clock)
+		if err != nil {
+			return err
+		}
+		if s.clock.Remaining > 0 {
+			s.clock.Remaining += s.clock.Increment - time.Since(start)
+			if s.clock.Remaining <= 0 {
+				// Keep a clock, even though the engine has flagged.
+				s.clock.Remaining = time.Millisecond
+			}
+		}
+		if s.ponder {
+			if next, err := game.Apply(result.Move); err == nil {
+				s.controller.Ponder(s.ctx, next)
+			}
+		}
+		return result.Move
+	}, nil
+}
+
+// level returns the strength level of the engine.
+func (s *EngineService) level() search.Level {
+	return s.controller.Level()
+}
+
+// changeLevel moves the engine up or down the strength levels by delta, within bounds.
+func (s *EngineService) changeLevel(delta int) {
+	current := s.controller.Level()
+	k := 0
+	for i, level := range search.Levels {
+		if level.Name == current.Name {
+			k = i
+		}
+	}
+	k += delta
+	if k < 0 {
+		k = 0
+	}
+	if k >= len(search.Levels) {
+		k = len(search.Levels) - 1
+	}
+	s.controller.SetLevel(search.Levels[k])
+}
+
+// listen builds a command that waits for the next progress report of the engine.
+func (s *EngineService) listen() tea.Cmd {
+	return func() tea.Msg {
+		info, ok := <-s.infos
+		if !ok {
+			return nil
+		}
+		return info
+	}
+}
+
+// close stops the engine.
+func (s *EngineService) close() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.controller.Close()
+}
+
+// name returns the name of the engine.
+func (s *EngineService) name() string {
+	return "g4 engine"
+}
+
+// viewEngineInfo returns a short description of the thinking of the engine.
+//
+// Scores are given from the point of view of the engine.
+func viewEngineInfo(info search.Info) string {
+	if info.Book {
+		return fmt.Sprintf("Engine: book move %v", info.Move)
+	}
+	score := info.Score
+	if info.Pondering {
+		score = -score
+	}
+	s := fmt.Sprintf("Engine: depth %d, %s, %dk nodes", info.Depth, viewScore(score), info.Nodes/1000)
+	if info.Pondering {
+		s += fmt.Sprintf(", expects %v", info.Move)
+	}
+	return s
+}
+
+// viewScore describes a search score for the player it is given for.
+func viewScore(score int) string {
+	switch {
+	case search.IsMate(score) && score > 0:
+		return fmt.Sprintf("wins in %d", search.Mate-score)
+	case search.IsMate(score):
+		return fmt.Sprintf("loses in %d", search.Mate+score)
+	}
+	return fmt.Sprintf("%+d", score)
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"g4/protocol"
+	"g4/search"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ExternalEngineService is the opponent of a game against an engine running
+// in a subprocess, and speaking the protocol of package protocol.
+//
+// The engine is stopped if it thinks for too long, and the game is suspended
+// if it crashes. Its progress is published as search.Info messages.
+type ExternalEngineService struct {
+	command string
+	args    []string
+	client  *protocol.Client
+	clock   search.Clock
+	infos   chan search.Info
+	r       *rand.Rand
+
+	// position is the game since its start, so that the engine knows the move counters.
+	position protocol.Position
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// newExternalEngineService creates an opponent running given command line, for a game starting at start.
+//
+// The command line is split on spaces, without any shell quoting. It fails if
+// the command line is empty.
+func newExternalEngineService(commandLine string, start bitsim.Game, clock search.Clock) (*ExternalEngineService, error) {
+	words := strings.Fields(commandLine)
+	if len(words) == 0 {
+		return nil, errors.New("empty engine command")
+	}
+	return &ExternalEngineService{
+		command:  words[0],
+		args:     words[1:],
+		clock:    clock,
+		infos:    make(chan search.Info, 16),
+		r:        rand.New(rand.NewSource(time.Now().UnixNano())),
+		position: protocol.Position{Start: start},
+	}, nil
+}
+
+// connect builds a command that starts the engine and waits for it to introduce itself.
+func (s *ExternalEngineService) connect(ctx context.Context) (tea.Cmd, error) {
+	if s.ctx != nil {
+		return nil, errors.New("engine already started")
+	}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	return func() tea.Msg {
+		client, err := protocol.Start(s.command, s.args, protocol.DefaultHandshakeTimeout)
+		if err != nil {
+			return fmt.Errorf("cannot start engine '%s': %w", s.command, err)
+		}
+		s.client = client
+		if err := client.NewGame(); err != nil {
+			return err
+		}
+		return ConnectionSuccessful{}
+	}, nil
+}
+
+// chooseColor builds a command that picks our color at random.
+func (s *ExternalEngineService) chooseColor() (tea.Cmd, error) {
+	color := g4.Yellow
+	if s.r.Intn(2) == 1 {
+		color = g4.Red
+	}
+	return func() tea.Msg {
+		return ColorFound(color)
+	}, nil
+}
+
+// sendMove builds a command that plays our move.
+func (s *ExternalEngineService) sendMove(move g4.Move) (tea.Cmd, error) {
+	s.position.Moves = append(s.position.Moves, move)
+	return func() tea.Msg {
+		return move
+	}, nil
+}
+
+// receiveMove builds a command that asks the engine for its move.
+//
+// The time spent is taken from the clock of the engine.
+func (s *ExternalEngineService) receiveMove(game bitsim.Game) (tea.Cmd, error) {
+	if s.client == nil {
+		return nil, errors.New("engine has not been started")
+	}
+	// Should the moves be out of sync, the engine still gets the right position.
+	if current, err := s.position.Game(); err != nil || current != game {
+		s.position = protocol.Position{Start: game}
+	}
+
+	// NB: searches limited by depth only have no timeout.
+	limits := protocol.Go{MoveTime: s.clock.MoveTime, Depth: s.clock.Depth}
+	var timeout time.Duration
+	if s.clock.MoveTime > 0 {
+		timeout = s.clock.MoveTime + protocol.DefaultTimeoutMargin
+	}
+	if s.clock.MoveTime == 0 && s.clock.Remaining > 0 {
+		if game.Mover == g4.Yellow {
+			limits.YellowTime, limits.YellowInc = s.clock.Remaining, s.clock.Increment
+		} else {
+			limits.RedTime, limits.RedInc = s.clock.Remaining, s.clock.Increment
+		}
+		timeout = s.clock.Remaining + protocol.DefaultTimeoutMargin
+	}
+
+	return func() tea.Msg {
+		start := time.Now()
+		move, err := s.client.Think(s.ctx, s.position, limits, timeout, func(info search.Info) {
+			if info.Elapsed == 0 {
+				info.Elapsed = time.Since(start)
+			}
+			select {
+			case s.infos <- info:
+			default:
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if s.clock.Remaining > 0 && s.clock.MoveTime == 0 {
+			s.clock.Remaining += s.clock.Increment - time.Since(start)
+			if s.clock.Remaining <= 0 {
+				s.clock.Remaining = time.Millisecond
+			}
+		}
+		s.position.Moves = append(s.position.Moves, move)
+		return move
+	}, nil
+}
+
+// listen builds a command that waits for the next progress report of the engine.
+func (s *ExternalEngineService) listen() tea.Cmd {
+	return func() tea.Msg {
+		return <-s.infos
+	}
+}
+
+// close stops the engine.
+func (s *ExternalEngineService) close() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	if s.client != nil {
+		s.client.Close()
+	}
+}
+
+// name returns the name of the engine, as it introduced itself.
+func (s *ExternalEngineService) name() string {
+	if s.client != nil && s.client.Name != "" {
+		return s.client.Name
+	}
+	return filepath.Base(s.command)
+}
+
+// viewEngine renders the last progress report of the engine.
+func (s *ExternalEngineService) viewEngine(info *search.Info) string {
+	hStyle := lipgloss.NewStyle().Bold(true).Foreground(light)
+	pStyle := lipgloss.NewStyle().PaddingLeft(1).MarginBottom(1).Foreground(lighter)
+
+	sections := []string{hStyle.Render("Engine"), pStyle.Render(s.name())}
+	if info == nil {
+		return lipgloss.JoinVertical(lipgloss.Left, sections...)
+	}
+	if info.Book {
+		sections = append(sections, hStyle.Render("Search"), pStyle.Render("book move"))
+	} else {
+		speed := ""
+		if info.Elapsed > 0 {
+			speed = fmt.Sprintf(", %.0fk/s", float64(info.Nodes)/info.Elapsed.Seconds()/1000)
+		}
+		sections = append(sections,
+			hStyle.Render("Search"),
+			pStyle.Render(fmt.Sprintf("depth %d, %s\n%dk nodes%s", info.Depth, viewScore(info.Score), info.Nodes/1000, speed)),
+		)
+	}
+	pv := make([]string, len(info.PV))
+	for k, move := range info.PV {
+		pv[k] = move.String()
+	}
+	sections = append(sections, hStyle.Render("Best line"), pStyle.Render(clipLine(strings.Join(pv, " "), 24)))
+	return lipgloss.JoinVertical(lipgloss.Left, sections...)
+}
+
+// clipLine shortens s to at most width characters.
+func clipLine(s string, width int) string {
+	if len(s) <= width {
+		return s
+	}
+	return s[:width-3] + "..."
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"g4"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -9,15 +10,52 @@ import (
 func viewKeymap(app AppModel) string {
 	hStyle := lipgloss.NewStyle().Bold(true).Foreground(light)
 	pStyle := lipgloss.NewStyle().PaddingLeft(1).MarginBottom(1).Foreground(lighter)
-	return lipgloss.JoinVertical(
-		lipgloss.Left,
+	sections := []string{
 		hStyle.Render("Token moves"),
 		pStyle.Render(":1 :2 :3 :4 :5 :6 :7 :8"),
 		hStyle.Render("Tilt moves"),
-		pStyle.Render(":left :down :right"),
+		pStyle.Render(viewTiltCombos(app)),
+	}
+	if _, ok := app.opponent.(*EngineService); ok {
+		sections = append(sections,
+			hStyle.Render("Engine level"),
+			pStyle.Render(":+ :-"),
+		)
+	}
+	if _, ok := app.opponent.(*PuzzleService); ok {
+		sections = append(sections,
+			hStyle.Render("Puzzle"),
+			pStyle.Render(":h hint, :r retry, :n next"),
+		)
+	}
+	if app.gameStatus != inProgress && len(app.moves) > 0 {
+		combos := ":v"
+		if app.review.open {
+			combos = ":v, :j and :k to browse"
+		}
+		sections = append(sections,
+			hStyle.Render("Review"),
+			pStyle.Render(combos),
+		)
+	}
+	sections = append(sections,
 		hStyle.Render("Quit"),
 		pStyle.Render(":q or ctrl+c"),
 	)
+	return lipgloss.JoinVertical(lipgloss.Left, sections...)
+}
+
+// viewTiltCombos renders the tilt combos, greying out tilts that are not allowed.
+func viewTiltCombos(app AppModel) string {
+	legalMoves, err := app.game.Generate()
+	combos := []string{":left", ":down", ":right"}
+	for k, combo := range combos {
+		// NB: when the game is over, combos are left as is.
+		if err == nil && !contains(makeMove(combo, app.game.Mover), legalMoves) {
+			combos[k] = lipgloss.NewStyle().Foreground(grey).Render(combo)
+		}
+	}
+	return strings.Join(combos, " ")
 }
 
 type KeyHandler struct {
@@ -39,6 +77,14 @@ var defaultKeymap = map[string]string{
 	": left":  ":left",
 	": down":  ":down",
 	": right": ":right",
+	": +":     "level+",
+	": -":     "level-",
+	": h":     "hint",
+	": r":     "retry",
+	": n":     "next",
+	": j":     "mistake+",
+	": k":     "mistake-",
+	": v":     "review",
 }
 
 func (h *KeyHandler) handle(key string) string {
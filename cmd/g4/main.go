@@ -5,22 +5,138 @@ import (
 	"fmt"
 	"g4"
 	"g4/bitsim"
+	"g4/book"
+	"g4/coach"
+	"g4/eval"
+	"g4/nn"
+	"g4/puzzle"
+	"g4/search"
+	"g4/tablebase"
+	"os"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "pos" {
+		os.Exit(runPos(os.Args[2:]))
+	}
+
+	recordPath := flag.String("record", "", "append the record of the game to this file")
+	noProgressLimit := flag.Int("no-progress", 0, "draw after this many consecutive moves without a token drop (0 disables)")
+	moveLimit := flag.Int("move-limit", 0, "draw after this many moves in total (0 disables)")
+	forbidNoOpTilts := flag.Bool("forbid-noop-tilts", false, "forbid tilts which leave the board unchanged")
+	engine := flag.Bool("engine", false, "play against the local engine instead of a peer")
+	engineCommand := flag.String("engine-command", "", "play against an external engine run with this command line (see g4-engine)")
+	threads := flag.Int("threads", search.DefaultThreads(), "number of threads of the engine")
+	depth := flag.Int("depth", 0, "depth limit of the engine (0 means no limit)")
+	thinkTime := flag.Duration("think", 2*time.Second, "thinking time of the engine on each move, without clock")
+	clockTime := flag.Duration("clock", 0, "clock of the engine for the whole game (0 disables)")
+	increment := flag.Duration("increment", 0, "time added to the clock of the engine after each move")
+	ponder := flag.Bool("ponder", true, "let the engine think on your time (only at max level)")
+	levelName := flag.String("level", search.MaxLevel.Name, "strength level of the engine")
+	seed := flag.Int64("seed", 0, "seed of the random choices of the engine (0 uses the seed of the level)")
+	analysis := flag.Bool("analysis", false, "play both sides and analyse the positions")
+	autoReview := flag.Bool("coach", false, "review finished games at once, and write the mistakes into their record")
+	coachDepth := flag.Int("coach-depth", coach.DefaultDepth, "search depth of the review of finished games")
+	puzzlesPath := flag.String("puzzles", "", "solve the puzzles of this file (see g4-puzzles)")
+	tablebasePath := flag.String("tablebase", "", "endgame tablebase used by the engine and the analysis")
+	networkPath := flag.String("network", "", "neural network evaluator of the engine and the analysis, instead of the heuristic one")
+	bookPath := flag.String("book", "", "opening book of the engine")
+	position := flag.String("position", bitsim.StartingPosition, "starting position (both players must use the same)")
+	moverName := flag.String("mover", "yellow", "player to move first in the starting position (yellow or red)")
 	flag.Parse()
-	spec := flag.Arg(0)
-	board, _ := bitsim.FromString(bitsim.StartingPosition)
+
+	board, mover, err := parsePosition(*position, *moverName)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	var table *tablebase.Table
+	if *tablebasePath != "" {
+		if table, err = tablebase.Load(*tablebasePath); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	var evaluator eval.Evaluator = eval.New(nil)
+	if *networkPath != "" {
+		if evaluator, err = nn.Load(*networkPath); err != nil {
+			fmt.Println(err)
+			return
+		}
+	}
+
+	rules := bitsim.Rules{
+		NoProgressLimit: *noProgressLimit,
+		MoveLimit:       *moveLimit,
+		ForbidNoOpTilts: *forbidNoOpTilts,
+	}
+	start := bitsim.Game{Board: board, Mover: mover, Rules: rules}
+	clock := search.Clock{MoveTime: *thinkTime}
+	if *clockTime > 0 {
+		clock = search.Clock{Remaining: *clockTime, Increment: *increment}
+	}
+
+	var opponent Opponent = newP2PService(flag.Arg(0))
+	switch {
+	case *puzzlesPath != "":
+		puzzles, err := puzzle.Load(*puzzlesPath)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if len(puzzles) == 0 {
+			fmt.Println("no puzzle in", *puzzlesPath)
+			return
+		}
+		start = puzzles[0].Start
+		*recordPath = ""
+		opponent = newPuzzleService(puzzles)
+	case *analysis:
+		opponent = newAnalysisService(evaluator, table)
+	case *engineCommand != "":
+		clock.Depth = *depth
+		if opponent, err = newExternalEngineService(*engineCommand, start, clock); err != nil {
+			fmt.Println(err)
+			return
+		}
+	case *engine:
+		level, err := search.ParseLevel(*levelName)
+		if err != nil {
+			fmt.Println(err)
+			return
+		}
+		if *seed != 0 {
+			level.Seed = *seed
+		}
+		options := search.Options{Threads: *threads, Depth: *depth, Evaluator: evaluator, Tablebase: table}
+		if *bookPath != "" {
+			if options.Book, err = book.Load(*bookPath); err != nil {
+				fmt.Println(err)
+				return
+			}
+		}
+		opponent = newEngineService(options, level, clock, *ponder)
+	}
 	p := tea.NewProgram(
 		AppModel{
-			spec:       spec,
-			game:       bitsim.Game{Board: board, Mover: g4.Yellow},
+			opponent:   opponent,
+			start:      start,
+			game:       start,
 			keyHandler: KeyHandler{keyMap: defaultKeymap},
 			gameStatus: inProgress,
 			connStatus: connecting,
 			history:    make(map[bitsim.Game]int),
+			recordPath: *recordPath,
+			coach: coach.Options{
+				Depth:  *coachDepth,
+				Search: search.Options{Threads: *threads, Evaluator: evaluator, Tablebase: table},
+			},
+			autoReview: *autoReview,
 		},
 		tea.WithAltScreen(),
 		tea.WithMouseAllMotion(),
@@ -29,3 +145,21 @@ func main() {
 		fmt.Println(err)
 	}
 }
+
+// parsePosition reads the starting position and the player to move, and
+// refuses the positions which no game can reach.
+func parsePosition(position, moverName string) (bitsim.Board, g4.Color, error) {
+	board, err := bitsim.FromString(position)
+	if err != nil {
+		return board, g4.Yellow, err
+	}
+	mover := g4.Yellow
+	switch moverName {
+	case "yellow":
+	case "red":
+		mover = g4.Red
+	default:
+		return board, mover, fmt.Errorf("invalid mover: %s", moverName)
+	}
+	return board, mover, board.IsReachable(mover)
+}
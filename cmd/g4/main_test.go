@@ -0,0 +1,36 @@
+package main
+
+import (
+	"g4"
+	"g4/bitsim"
+	"testing"
+)
+
+func TestParsePosition(t *testing.T) {
+	examples := []struct {
+		position, mover string
+		want            g4.Color
+		ok              bool
+	}{
+		{bitsim.StartingPosition, "yellow", g4.Yellow, true},
+		{bitsim.StartingPosition, "red", g4.Red, true},
+		{"yyyy4|8|8|8|8|8|8|8", "red", g4.Red, true},
+		{"yyyy4|8|8|8|8|8|8|yyyy4", "red", g4.Red, false},
+		{"1y6|8|8|8|8|8|8|8", "red", g4.Red, false},
+		{"9|8|8|8|8|8|8|8", "yellow", g4.Yellow, false},
+		{bitsim.StartingPosition, "blue", g4.Yellow, false},
+	}
+	for k, example := range examples {
+		board, mover, err := parsePosition(example.position, example.mover)
+		if (err == nil) != example.ok {
+			t.Errorf("example %d: got error %v reading %s", k, err, example.position)
+			continue
+		}
+		if !example.ok {
+			continue
+		}
+		if board.String() != example.position || mover != example.want {
+			t.Errorf("example %d: got %v with %v to move but want %s with %v", k, board, mover, example.position, example.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"g4"
+	"g4/bitsim"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Opponent provides factories for the commands exchanging moves with the other player.
+//
+// Such a command will itself always return either a success message or an error.
+// The main model should treat error with care and act accordingly.
+type Opponent interface {
+	// connect builds a command that reaches the opponent.
+	// It returns ConnectionSuccessful on success.
+	connect(ctx context.Context) (tea.Cmd, error)
+
+	// chooseColor builds a command that decides who plays which color.
+	// It returns ColorFound on success.
+	chooseColor() (tea.Cmd, error)
+
+	// sendMove builds a command that tells our move to the opponent.
+	// It returns the move on success.
+	sendMove(move g4.Move) (tea.Cmd, error)
+
+	// receiveMove builds a command that waits for the move of the opponent in given game.
+	// It returns the move on success.
+	receiveMove(game bitsim.Game) (tea.Cmd, error)
+
+	// close releases the resources held for the opponent.
+	close()
+
+	// name returns the name of the opponent, for game records.
+	name() string
+}
+
+// infoSource is implemented by the opponents reporting the progress of an engine.
+type infoSource interface {
+	// listen builds a command that waits for the next progress report.
+	// It returns a search.Info, or nil once there are no more reports.
+	listen() tea.Cmd
+}
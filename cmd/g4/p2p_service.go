@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"g4"
+	"g4/bitsim"
 	"g4/p2p"
 	"math/rand"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -17,28 +19,29 @@ const (
 	maxColorTries = 100
 )
 
-// service provides factories for p2p commands.
+// P2PService is the opponent of a peer-to-peer game.
 //
-// Such a command will itself always return either a success message or an error.
-// The main model should treat error with care and act accordingly.
+// We assume that it is thread-safe.
 type P2PService struct {
-	ch *p2p.Channel
-	r  *rand.Rand
+	spec string
+	ch   *p2p.Channel
+	r    *rand.Rand
 }
 
-// p2pService is a global instance of P2PService.
-//
-// We assume that it is thread-safe.
-var p2pService = &P2PService{
-	r: rand.New(rand.NewSource(time.Now().UnixNano())),
+// newP2PService creates the opponent reached through given channel spec.
+func newP2PService(spec string) *P2PService {
+	return &P2PService{
+		spec: spec,
+		r:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
 }
 
 // connect builds a command that opens the p2p connection with opponent.
-func (s *P2PService) connect(ctx context.Context, descr string) (tea.Cmd, error) {
+func (s *P2PService) connect(ctx context.Context) (tea.Cmd, error) {
 	if s.ch != nil {
 		return nil, errors.New("channel already created")
 	}
-	ch, err := p2p.New(descr)
+	ch, err := p2p.New(s.spec)
 	if err != nil {
 		return nil, fmt.Errorf("error creating channel: %w", err)
 	}
@@ -108,7 +111,7 @@ func (s *P2PService) sendMove(move g4.Move) (tea.Cmd, error) {
 }
 
 // receiveMove builds a command that receives a move from the peer.
-func (s *P2PService) receiveMove() (tea.Cmd, error) {
+func (s *P2PService) receiveMove(game bitsim.Game) (tea.Cmd, error) {
 	if s.ch == nil {
 		return nil, errors.New("channel has not been created")
 	}
@@ -121,3 +124,18 @@ func (s *P2PService) receiveMove() (tea.Cmd, error) {
 		return move
 	}, nil
 }
+
+// close closes the p2p connection, if any.
+func (s *P2PService) close() {
+	if s.ch != nil {
+		s.ch.Close()
+	}
+}
+
+// name returns the address of the peer.
+func (s *P2PService) name() string {
+	if k := strings.Index(s.spec, ":"); k >= 0 {
+		return s.spec[k+1:]
+	}
+	return s.spec
+}
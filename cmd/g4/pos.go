@@ -0,0 +1,439 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"g4/record"
+	"io"
+	"math/bits"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const posUsage = `Usage: g4 pos <command> [flags] <position> [arguments]
+
+Commands:
+  show      render the board as ASCII art
+  play      apply moves, e.g. g4 pos play 8|8|8|8|8|8|8|8 4 4 L
+  moves     list the legal moves and the positions they lead to
+  check     check the board, and list the winners and threats
+  rotate    rotate the board to the left (-times N), without gravity
+  mirror    flip the board left to right
+  swap      swap the colors of the tokens
+  gravity   make the tokens fall
+  convert   convert the position to another notation (-to board|rows|hex|ascii)
+
+Positions are read in any of these notations:
+  board     columns from left to right, bottom up, as in y7|r7|8|8|8|8|8|8
+  rows      rows from top to bottom, as in 8/8/8/8/8/8/8/yr6
+  hex       the 16 bytes of the binary encoding of the board
+
+Run g4 pos <command> -h for the flags of a command.
+`
+
+// runPos runs the `g4 pos` subcommands on args, and returns the exit status.
+func runPos(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, posUsage)
+		return 2
+	}
+	commands := map[string]func(*flag.FlagSet, []string, io.Writer) error{
+		"show":    posShow,
+		"play":    posPlay,
+		"moves":   posMoves,
+		"check":   posCheck,
+		"rotate":  posRotate,
+		"mirror":  posTransform(bitsim.Board.Mirror),
+		"swap":    posTransform(bitsim.Board.SwapColors),
+		"gravity": posTransform(bitsim.Board.ApplyGravity),
+		"convert": posConvert,
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command '%s'\n\n%s", args[0], posUsage)
+		return 2
+	}
+	flags := flag.NewFlagSet("g4 pos "+args[0], flag.ContinueOnError)
+	if err := command(flags, args[1:], os.Stdout); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		return 1
+	}
+	return 0
+}
+
+// posGameFlags declares the flags describing the game around a board.
+func posGameFlags(flags *flag.FlagSet) (mover, rules *string) {
+	mover = flags.String("mover", "yellow", "player to move (yellow or red)")
+	rules = flags.String("rules", "", "rule variant, as written in game records")
+	return
+}
+
+// posParse parses the flags, and reads the position given as first argument.
+//
+// It returns the remaining arguments.
+func posParse(flags *flag.FlagSet, args []string) (bitsim.Board, []string, error) {
+	if err := flags.Parse(args); err != nil {
+		return bitsim.Board{}, nil, err
+	}
+	if flags.NArg() == 0 {
+		return bitsim.Board{}, nil, errors.New("expected a position")
+	}
+	board, err := readBoard(flags.Arg(0))
+	return board, flags.Args()[1:], err
+}
+
+// posGame builds the game of a board from the -mover and -rules flags.
+func posGame(board bitsim.Board, mover, rules string) (bitsim.Game, error) {
+	game := bitsim.Game{Board: board, Mover: g4.Yellow}
+	switch mover {
+	case "yellow":
+	case "red":
+		game.Mover = g4.Red
+	default:
+		return game, fmt.Errorf("invalid mover: %s", mover)
+	}
+	var err error
+	game.Rules, err = bitsim.ParseRules(rules)
+	return game, err
+}
+
+func posShow(flags *flag.FlagSet, args []string, w io.Writer) error {
+	board, _, err := posParse(flags, args)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, renderBoard(board))
+	return err
+}
+
+func posPlay(flags *flag.FlagSet, args []string, w io.Writer) error {
+	mover, rules := posGameFlags(flags)
+	show := flags.Bool("show", false, "render the resulting board")
+	board, args, err := posParse(flags, args)
+	if err != nil {
+		return err
+	}
+	game, err := posGame(board, *mover, *rules)
+	if err != nil {
+		return err
+	}
+
+	// Moves may be given as separate arguments or in a single one.
+	moves := strings.Fields(strings.Join(args, " "))
+	var outcome error
+	for k, notation := range moves {
+		if outcome != nil {
+			return fmt.Errorf("move %d (%s): the game is over", k+1, notation)
+		}
+		move, err := g4.ParseMove(notation, game.Mover)
+		if err != nil {
+			return fmt.Errorf("move %d: %w", k+1, err)
+		}
+		next, err := game.Apply(move)
+		if errors.Is(err, g4.ErrorInvalidMove{}) {
+			return fmt.Errorf("move %d (%s): %w", k+1, notation, err)
+		}
+		game, outcome = next, err
+	}
+
+	fmt.Fprintln(w, game.Board)
+	if *show {
+		io.WriteString(w, renderBoard(game.Board))
+	}
+	if outcome != nil {
+		fmt.Fprintln(w, outcomeText(game))
+	} else {
+		fmt.Fprintln(w, colorText(game.Mover), "to move")
+	}
+	return nil
+}
+
+func posMoves(flags *flag.FlagSet, args []string, w io.Writer) error {
+	mover, rules := posGameFlags(flags)
+	board, _, err := posParse(flags, args)
+	if err != nil {
+		return err
+	}
+	game, err := posGame(board, *mover, *rules)
+	if err != nil {
+		return err
+	}
+	moves, err := game.Generate()
+	if err != nil {
+		return fmt.Errorf("no legal moves: %s", outcomeText(game))
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, move := range moves {
+		next, err := game.Apply(move)
+		note := ""
+		switch {
+		case err != nil:
+			note = outcomeText(next)
+		case move.Type == g4.Tilt && next.Board == game.Board:
+			note = "no-op"
+		}
+		if note != "" {
+			fmt.Fprintf(tw, "%v\t%v\t%s\n", move, next.Board, note)
+		} else {
+			fmt.Fprintf(tw, "%v\t%v\n", move, next.Board)
+		}
+	}
+	return tw.Flush()
+}
+
+func posCheck(flags *flag.FlagSet, args []string, w io.Writer) error {
+	mover, rules := posGameFlags(flags)
+	board, _, err := posParse(flags, args)
+	if err != nil {
+		return err
+	}
+	game, err := posGame(board, *mover, *rules)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "tokens:\t%d yellow, %d red\n", bits.OnesCount64(board.Tokens(g4.Yellow)), bits.OnesCount64(board.Tokens(g4.Red)))
+	if err := board.Validate(); err != nil {
+		fmt.Fprintf(tw, "board:\tinvalid, %v\n", err)
+	} else if err := board.IsReachable(game.Mover); err != nil {
+		fmt.Fprintf(tw, "board:\tunreachable with %s to move, %v\n", colorText(game.Mover), err)
+	} else {
+		fmt.Fprintf(tw, "board:\tvalid, %s to move\n", colorText(game.Mover))
+	}
+	if game.Validate() != nil {
+		fmt.Fprintf(tw, "result:\t%s\n", outcomeText(game))
+	} else {
+		fmt.Fprintf(tw, "result:\tgame in progress\n")
+	}
+
+	heights := board.Heights()
+	for _, color := range []g4.Color{g4.Yellow, g4.Red} {
+		var squares []string
+		threats := board.Threats(color)
+		for column := 0; column < 8; column++ {
+			for row := 0; row < 8; row++ {
+				if threats&(1<<(row+8*column)) == 0 {
+					continue
+				}
+				square := fmt.Sprintf("%d-%d", column+1, row+1)
+				if row == heights[column] {
+					square += " (playable)"
+				}
+				squares = append(squares, square)
+			}
+		}
+		fmt.Fprintf(tw, "%s threats:\t%s\n", colorText(color), listText(squares))
+	}
+
+	var winning []string
+	moves, _ := game.Generate()
+	for _, move := range moves {
+		_, err := game.Apply(move)
+		if (game.Mover == g4.Yellow && err == g4.YellowWins{}) || (game.Mover == g4.Red && err == g4.RedWins{}) {
+			winning = append(winning, move.String())
+		}
+	}
+	fmt.Fprintf(tw, "winning moves:\t%s\n", listText(winning))
+	return tw.Flush()
+}
+
+func posRotate(flags *flag.FlagSet, args []string, w io.Writer) error {
+	times := flags.Int("times", 1, "number of quarter turns to the left (negative turns right)")
+	board, _, err := posParse(flags, args)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, board.RotateLeft((*times%4+4)%4))
+	return err
+}
+
+// posTransform returns a command applying a transformation to the board.
+func posTransform(transform func(bitsim.Board) bitsim.Board) func(*flag.FlagSet, []string, io.Writer) error {
+	return func(flags *flag.FlagSet, args []string, w io.Writer) error {
+		board, _, err := posParse(flags, args)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, transform(board))
+		return err
+	}
+}
+
+func posConvert(flags *flag.FlagSet, args []string, w io.Writer) error {
+	to := flags.String("to", "rows", "notation of the output (board, rows, hex or ascii)")
+	board, _, err := posParse(flags, args)
+	if err != nil {
+		return err
+	}
+	switch *to {
+	case "board":
+		_, err = fmt.Fprintln(w, board)
+	case "rows":
+		_, err = fmt.Fprintln(w, rowsString(board))
+	case "hex":
+		data, _ := board.MarshalBinary()
+		_, err = fmt.Fprintln(w, hex.EncodeToString(data))
+	case "ascii":
+		_, err = io.WriteString(w, renderBoard(board))
+	default:
+		err = fmt.Errorf("invalid notation: %s", *to)
+	}
+	return err
+}
+
+// readBoard reads a board in board, rows or hex notation.
+func readBoard(s string) (bitsim.Board, error) {
+	switch {
+	case strings.Contains(s, "/"):
+		return readRows(s)
+	case len(s) == 32 && !strings.Contains(s, "|"):
+		return readHex(s)
+	}
+	return bitsim.FromString(s)
+}
+
+// readHex reads a board in hex notation.
+//
+// As with the other notations, the board is not validated here, so that the
+// commands check it the same way whatever the notation.
+func readHex(s string) (bitsim.Board, error) {
+	var board bitsim.Board
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return board, err
+	}
+	yellow, red := binary.LittleEndian.Uint64(data), binary.LittleEndian.Uint64(data[8:])
+	for column := 0; column < 8; column++ {
+		for row := 0; row < 8; row++ {
+			mask := uint64(1) << (row + 8*column)
+			switch {
+			case yellow&red&mask != 0:
+				return board, bitsim.ErrorOverlappingTokens{Column: column, Row: row}
+			case yellow&mask != 0:
+				board = board.Set(column, row, g4.Yellow)
+			case red&mask != 0:
+				board = board.Set(column, row, g4.Red)
+			}
+		}
+	}
+	return board, nil
+}
+
+// readRows reads a board written row by row, from top to bottom.
+//
+// Rows are separated by slashes, and hold 'y', 'r', '.' or digits counting empty squares.
+func readRows(s string) (bitsim.Board, error) {
+	var board bitsim.Board
+	rows := strings.Split(s, "/")
+	if len(rows) != 8 {
+		return board, fmt.Errorf("invalid number of rows: %d", len(rows))
+	}
+	for i, line := range rows {
+		row, column := 7-i, 0
+		for _, c := range line {
+			switch {
+			case c >= '1' && c <= '8':
+				column += int(c - '0')
+				continue
+			case c == 'y':
+				board = board.Set(column, row, g4.Yellow)
+			case c == 'r':
+				board = board.Set(column, row, g4.Red)
+			case c != '.':
+				return board, fmt.Errorf("invalid character '%c' in row %d", c, i+1)
+			}
+			column++
+		}
+		if column != 8 {
+			return board, fmt.Errorf("invalid length of row %d", i+1)
+		}
+	}
+	return board, nil
+}
+
+// rowsString writes a board row by row, from top to bottom.
+func rowsString(board bitsim.Board) string {
+	rows := make([]string, 8)
+	for i, line := range board.Grid() {
+		var s strings.Builder
+		void := 0
+		for _, color := range line {
+			if color == g4.Empty {
+				void++
+				continue
+			}
+			if void > 0 {
+				s.WriteString(strconv.Itoa(void))
+				void = 0
+			}
+			if color == g4.Yellow {
+				s.WriteByte('y')
+			} else {
+				s.WriteByte('r')
+			}
+		}
+		if void > 0 {
+			s.WriteString(strconv.Itoa(void))
+		}
+		rows[i] = s.String()
+	}
+	return strings.Join(rows, "/")
+}
+
+// renderBoard draws the board in ASCII, with row and column numbers.
+func renderBoard(board bitsim.Board) string {
+	var s strings.Builder
+	for i, line := range board.Grid() {
+		fmt.Fprintf(&s, "%d ", 8-i)
+		for _, color := range line {
+			switch color {
+			case g4.Yellow:
+				s.WriteString(" y")
+			case g4.Red:
+				s.WriteString(" r")
+			default:
+				s.WriteString(" .")
+			}
+		}
+		s.WriteString("\n")
+	}
+	s.WriteString("   1 2 3 4 5 6 7 8\n")
+	return s.String()
+}
+
+// outcomeText describes the outcome of a finished game.
+func outcomeText(game bitsim.Game) string {
+	result, reason := record.Conclude(game)
+	switch result {
+	case record.YellowWins:
+		return fmt.Sprintf("yellow wins (%s)", reason)
+	case record.RedWins:
+		return fmt.Sprintf("red wins (%s)", reason)
+	}
+	return fmt.Sprintf("draw (%s)", reason)
+}
+
+func colorText(color g4.Color) string {
+	if color == g4.Red {
+		return "red"
+	}
+	return "yellow"
+}
+
+func listText(items []string) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	return strings.Join(items, ", ")
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"g4/bitsim"
+	"io"
+	"strconv"
+	"testing"
+)
+
+// run runs a pos command on args, and returns its output.
+func run(t *testing.T, command func(*flag.FlagSet, []string, io.Writer) error, args ...string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	flags := flag.NewFlagSet("g4 pos", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	if err := command(flags, args, &buf); err != nil {
+		t.Fatalf("error in command %v: %v", args, err)
+	}
+	return buf.String()
+}
+
+func TestReadBoard(t *testing.T) {
+	examples := []struct {
+		s    string
+		want string
+	}{
+		{"8|8|8|8|8|8|8|8", "8|8|8|8|8|8|8|8"},
+		{"8/8/8/8/8/8/8/8", "8|8|8|8|8|8|8|8"},
+		{"8/8/8/8/8/8/8/yr6", "y7|r7|8|8|8|8|8|8"},
+		{"8/8/8/8/8/8/r7/y6r", "yr6|8|8|8|8|8|8|r7"},
+		{"8/8/8/8/8/8/......../y.......", "y7|8|8|8|8|8|8|8"},
+		{"y7/r7/y7/r7/y7/r7/y7/r7", "ryryryry|8|8|8|8|8|8|8"},
+		{"01000000000000000200000000000000", "yr6|8|8|8|8|8|8|8"},
+		{"02000000000000000000000000000000", "1y6|8|8|8|8|8|8|8"},
+	}
+	for k, example := range examples {
+		board, err := readBoard(example.s)
+		if err != nil {
+			t.Errorf("example %d: error in readBoard: %v", k, err)
+			continue
+		}
+		if got := board.String(); got != example.want {
+			t.Errorf("example %d: got %s but want %s", k, got, example.want)
+		}
+	}
+}
+
+func TestReadBoardError(t *testing.T) {
+	examples := []string{
+		"9/8/8/8/8/8/8/8",
+		"44444/8/8/8/8/8/8/8",
+		"7/8/8/8/8/8/8/8",
+		"8/8/8/8/8/8/8/y",
+		"8/8/8/8/8/8/8/yyyyyyyyy",
+		"8/8/8/8/8/8/8/0y7",
+		"8/8/8/8/8/8/8/x7",
+		"8/8/8/8/8/8/8",
+		"8/8/8/8/8/8/8/8/8",
+		"zz000000000000000000000000000000",
+		"01000000000000000100000000000000",
+		"9|8|8|8|8|8|8|8",
+	}
+	for k, s := range examples {
+		if _, err := readBoard(s); err == nil {
+			t.Errorf("example %d: got no error reading %s", k, s)
+		}
+	}
+}
+
+func TestNotationRoundTrips(t *testing.T) {
+	examples := []string{
+		"8|8|8|8|8|8|8|8",
+		"y7|r7|8|8|8|8|8|8",
+		"yr6|8|yyy5|8|rrrr4|8|8|ryryryry",
+		"yyyyyyyy|rrrrrrrr|yyyyyyyy|rrrrrrrr|yyyyyyyy|rrrrrrrr|yyyyyyyy|rrrrrrrr",
+	}
+	for k, s := range examples {
+		board, err := bitsim.FromString(s)
+		if err != nil {
+			t.Fatalf("example %d: error in FromString: %v", k, err)
+		}
+		data, err := board.MarshalBinary()
+		if err != nil {
+			t.Fatalf("example %d: error in MarshalBinary: %v", k, err)
+		}
+		for _, notation := range []string{s, rowsString(board), hex.EncodeToString(data)} {
+			got, err := readBoard(notation)
+			if err != nil {
+				t.Errorf("example %d: error reading %s: %v", k, notation, err)
+				continue
+			}
+			if got != board {
+				t.Errorf("example %d: got %v reading %s but want %v", k, got, notation, board)
+			}
+		}
+	}
+}
+
+func TestCheckNotations(t *testing.T) {
+	examples := [][]string{
+		{"yr6|8|8|8|8|8|8|r7", "8/8/8/8/8/8/r7/y6r", "01000000000000000200000000000001"},
+		{"1y6|8|8|8|8|8|8|8", "8/8/8/8/8/8/y7/8", "02000000000000000000000000000000"},
+		{"yyyy4|8|8|8|8|8|8|yyyy4", "8/8/8/8/y6y/y6y/y6y/y6y", "0f0000000000000f0000000000000000"},
+	}
+	for k, notations := range examples {
+		want := run(t, posCheck, "-mover", "red", notations[0])
+		for _, notation := range notations[1:] {
+			if got := run(t, posCheck, "-mover", "red", notation); got != want {
+				t.Errorf("example %d: got %q checking %s but want %q", k, got, notation, want)
+			}
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	examples := []struct {
+		to, want string
+	}{
+		{"board", "yr6|8|8|8|8|8|8|r7\n"},
+		{"rows", "8/8/8/8/8/8/r7/y6r\n"},
+		{"hex", "01000000000000000200000000000001\n"},
+		{"ascii", "8  . . . . . . . .\n7  . . . . . . . .\n6  . . . . . . . .\n5  . . . . . . . .\n" +
+			"4  . . . . . . . .\n3  . . . . . . . .\n2  r . . . . . . .\n1  y . . . . . . r\n   1 2 3 4 5 6 7 8\n"},
+	}
+	for k, example := range examples {
+		if got := run(t, posConvert, "-to", example.to, "yr6|8|8|8|8|8|8|r7"); got != example.want {
+			t.Errorf("example %d: got %q but want %q", k, got, example.want)
+		}
+	}
+}
+
+func TestRotate(t *testing.T) {
+	const s = "yr6|8|yyy5|8|rrrr4|8|8|ryryryry"
+	examples := []struct {
+		times int
+		want  string
+	}{
+		{0, s},
+		{1, "7y|7r|7y|7r|4r2y|2y1r2r|r1y1r2y|y1y1r2r"},
+		{5, "7y|7r|7y|7r|4r2y|2y1r2r|r1y1r2y|y1y1r2r"},
+		{4, s},
+		{-4, s},
+	}
+	for k, example := range examples {
+		got := run(t, posRotate, "-times", strconv.Itoa(example.times), s)
+		if got != example.want+"\n" {
+			t.Errorf("example %d: got %q but want %q", k, got, example.want+"\n")
+		}
+	}
+	for times := -6; times <= 6; times++ {
+		got := run(t, posRotate, "-times", strconv.Itoa(times), s)
+		if want := run(t, posRotate, "-times", strconv.Itoa(times+4), s); got != want {
+			t.Errorf("got %q rotating %d times but %q rotating %d times", got, times, want, times+4)
+		}
+	}
+	if got, want := run(t, posRotate, "-times", "-1", s), run(t, posRotate, "-times", "3", s); got != want {
+		t.Errorf("got %q rotating to the right but want %q", got, want)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	examples := []struct {
+		command   string
+		transform func(bitsim.Board) bitsim.Board
+		s, want   string
+	}{
+		{"mirror", bitsim.Board.Mirror, "yr6|8|y7|8|8|8|8|8", "8|8|8|8|8|y7|8|yr6"},
+		{"swap", bitsim.Board.SwapColors, "yr6|8|y7|8|8|8|8|8", "ry6|8|r7|8|8|8|8|8"},
+		{"gravity", bitsim.Board.ApplyGravity, "y7/8/8/8/8/8/8/8", "y7|8|8|8|8|8|8|8"},
+		{"gravity", bitsim.Board.ApplyGravity, "8/8/8/8/8/8/8/8", "8|8|8|8|8|8|8|8"},
+	}
+	for k, example := range examples {
+		if got := run(t, posTransform(example.transform), example.s); got != example.want+"\n" {
+			t.Errorf("example %d: got %q from %s but want %q", k, got, example.command, example.want+"\n")
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"g4/puzzle"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// PuzzleMiss is the message of a move which does not solve the puzzle.
+type PuzzleMiss g4.Move
+
+// PuzzleHint is the message of a winning move found for a hint, in given position.
+type PuzzleHint struct {
+	Game bitsim.Game
+	Move g4.Move
+}
+
+// PuzzleService is the opponent of the puzzle mode, where the player looks for the winning moves.
+//
+// Moves which do not win in time are refused, and the service plays the
+// replies of the defender, choosing those which resist the longest.
+//
+// The searches run in commands, so that the interface keeps responding, and
+// they stop when the service is closed. Their results update the service
+// when the main model receives them.
+type PuzzleService struct {
+	puzzles []puzzle.Puzzle
+	index   int
+
+	// game is the current position, and remaining the number of moves left to win.
+	game      bitsim.Game
+	remaining int
+
+	// misses and hints count the wrong moves and the hints of the current puzzle.
+	misses, hints int
+	hint          string
+
+	// Tally of the puzzles.
+	tried, solved, clean int
+	done                 bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// newPuzzleService creates the opponent of the puzzle mode, starting with the first puzzle.
+func newPuzzleService(puzzles []puzzle.Puzzle) *PuzzleService {
+	s := &PuzzleService{puzzles: puzzles}
+	s.restart()
+	return s
+}
+
+// current returns the current puzzle.
+func (s *PuzzleService) current() puzzle.Puzzle {
+	return s.puzzles[s.index]
+}
+
+// restart sets up the current puzzle from its start.
+func (s *PuzzleService) restart() {
+	s.game = s.current().Start
+	s.remaining = s.current().Moves
+	s.hint = ""
+}
+
+// next moves on to the next puzzle. It returns false after the last one.
+func (s *PuzzleService) next() bool {
+	if s.index+1 >= len(s.puzzles) {
+		return false
+	}
+	s.index++
+	s.misses, s.hints, s.done = 0, 0, false
+	s.restart()
+	return true
+}
+
+// finish records that the current puzzle is solved.
+func (s *PuzzleService) finish() {
+	if s.done {
+		return
+	}
+	s.done = true
+	s.solved++
+	if s.misses == 0 && s.hints == 0 {
+		s.clean++
+	}
+}
+
+// connect builds a command that starts the first puzzle at once.
+func (s *PuzzleService) connect(ctx context.Context) (tea.Cmd, error) {
+	if s.ctx != nil {
+		return nil, errors.New("puzzles already started")
+	}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	return func() tea.Msg {
+		return ConnectionSuccessful{}
+	}, nil
+}
+
+// chooseColor builds a command that gives us the side of the attacker.
+func (s *PuzzleService) chooseColor() (tea.Cmd, error) {
+	s.tried++
+	color := s.current().Start.Mover
+	return func() tea.Msg {
+		return ColorFound(color)
+	}, nil
+}
+
+// sendMove builds a command that plays our move if it still wins in time, or reports a miss.
+func (s *PuzzleService) sendMove(move g4.Move) (tea.Cmd, error) {
+	if s.ctx == nil {
+		return nil, errors.New("puzzles have not been started")
+	}
+	ctx, game, remaining := s.ctx, s.game, s.remaining
+	return func() tea.Msg {
+		wins, err := puzzle.MoveWinsIn(ctx, game, move, remaining)
+		if err != nil {
+			return err
+		}
+		if !wins {
+			return PuzzleMiss(move)
+		}
+		return move
+	}, nil
+}
+
+// receiveMove builds a command that plays the reply of the defender.
+//
+// Along the solution of the puzzle, it plays the recorded reply.
+func (s *PuzzleService) receiveMove(game bitsim.Game) (tea.Cmd, error) {
+	if s.ctx == nil {
+		return nil, errors.New("puzzles have not been started")
+	}
+	ctx, remaining := s.ctx, s.remaining
+	reply, ok := s.solutionMove(game)
+	return func() tea.Msg {
+		if ok {
+			return reply
+		}
+		reply, _, err := puzzle.Defend(ctx, game, remaining)
+		if err != nil {
+			return err
+		}
+		return reply
+	}, nil
+}
+
+// play records a move of the game, which leads to given position.
+func (s *PuzzleService) play(move g4.Move, game bitsim.Game) {
+	if move.Color == s.current().Start.Mover {
+		s.remaining--
+		s.hint = ""
+	}
+	s.game = game
+}
+
+// miss records a move which does not win in time.
+func (s *PuzzleService) miss() {
+	s.misses++
+}
+
+// solutionMove returns the move of the solution in given position, if the game followed it.
+func (s *PuzzleService) solutionMove(game bitsim.Game) (g4.Move, bool) {
+	p := s.current()
+	g := p.Start
+	for _, move := range p.Solution {
+		if g == game {
+			return move, true
+		}
+		g, _ = g.Apply(move)
+	}
+	return g4.Move{}, false
+}
+
+// giveHint builds a command that looks for the winning move to hint at.
+//
+// Along the solution of the puzzle, it finds the recorded move.
+func (s *PuzzleService) giveHint() (tea.Cmd, error) {
+	if s.ctx == nil {
+		return nil, errors.New("puzzles have not been started")
+	}
+	ctx, game, remaining := s.ctx, s.game, s.remaining
+	move, ok := s.solutionMove(game)
+	return func() tea.Msg {
+		if ok {
+			wins, err := puzzle.MoveWinsIn(ctx, game, move, remaining)
+			if err != nil {
+				return err
+			}
+			if wins {
+				return PuzzleHint{Game: game, Move: move}
+			}
+		}
+		moves, _ := game.Generate()
+		for _, candidate := range moves {
+			wins, err := puzzle.MoveWinsIn(ctx, game, candidate, remaining)
+			if err != nil {
+				return err
+			}
+			if wins {
+				return PuzzleHint{Game: game, Move: candidate}
+			}
+		}
+		return PuzzleHint{Game: game, Move: move}
+	}, nil
+}
+
+// showHint reveals the kind of the winning move of a hint, then the move itself.
+//
+// Hints found in another position than the current one are dropped.
+func (s *PuzzleService) showHint(hint PuzzleHint) {
+	if hint.Game != s.game {
+		return
+	}
+	s.hints++
+	switch {
+	case s.hint == "" && hint.Move.Type == g4.Tilt:
+		s.hint = "The winning move is a tilt."
+	case s.hint == "":
+		s.hint = "The winning move drops a token."
+	default:
+		s.hint = fmt.Sprintf("Play %v.", hint.Move)
+	}
+}
+
+// close stops the searches of the service.
+func (s *PuzzleService) close() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+// name returns the name of the defender.
+func (s *PuzzleService) name() string {
+	return "puzzle"
+}
+
+// viewGoal describes the goal of the current puzzle.
+func (s *PuzzleService) viewGoal() string {
+	mover := "Yellow"
+	if s.current().Start.Mover == g4.Red {
+		mover = "Red"
+	}
+	return fmt.Sprintf("Puzzle %d of %d:\n%s to play and win in %d.", s.index+1, len(s.puzzles), mover, s.current().Moves)
+}
+
+// viewTally describes the results of the puzzles so far.
+func (s *PuzzleService) viewTally() string {
+	return fmt.Sprintf("Solved %d of %d, %d at first try", s.solved, s.tried, s.clean)
+}
+
+// viewPuzzle renders the side panel of the puzzle mode.
+func (s *PuzzleService) viewPuzzle() string {
+	hStyle := lipgloss.NewStyle().Bold(true).Foreground(light)
+	pStyle := lipgloss.NewStyle().PaddingLeft(1).MarginBottom(1).Foreground(lighter)
+
+	progress := plural(s.remaining, "move") + " left"
+	if s.done {
+		progress = "Solved!"
+	}
+	if s.misses > 0 {
+		progress += ", " + plural(s.misses, "miss")
+	}
+	sections := []string{
+		hStyle.Render(fmt.Sprintf("Puzzle %d of %d", s.index+1, len(s.puzzles))),
+		pStyle.Render(fmt.Sprintf("Win in %d\n%s", s.current().Moves, progress)),
+	}
+	if s.hint != "" {
+		sections = append(sections, hStyle.Render("Hint"), pStyle.Render(s.hint))
+	}
+	sections = append(sections, hStyle.Render("Tally"), pStyle.Render(s.viewTally()))
+	return lipgloss.JoinVertical(lipgloss.Left, sections...)
+}
+
+// plural formats a count of things, with a naive plural.
+func plural(n int, thing string) string {
+	switch {
+	case n == 1:
+		return "1 " + thing
+	case strings.HasSuffix(thing, "s"):
+		return fmt.Sprintf("%d %ses", n, thing)
+	default:
+		return fmt.Sprintf("%d %ss", n, thing)
+	}
+}
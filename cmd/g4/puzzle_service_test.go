@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"g4"
+	"g4/internal/g4test"
+	"g4/puzzle"
+	"testing"
+)
+
+func TestPuzzleServiceClose(t *testing.T) {
+	game := g4test.Game(t, "yyrryy2|yyyrr3|ryrr4|rryy4|yr6|y7|8|8", g4.Red)
+	p, ok := puzzle.Find(context.Background(), game, puzzle.Options{MaxMoves: 2})
+	if !ok {
+		t.Fatalf("no puzzle found")
+	}
+	s := newPuzzleService([]puzzle.Puzzle{p})
+	if _, err := s.connect(context.Background()); err != nil {
+		t.Fatalf("error in connect: %v", err)
+	}
+	s.close()
+
+	// The defender has to search for its reply off the solution.
+	game, err := game.Apply(g4test.Move(t, "8", g4.Red))
+	if err != nil {
+		t.Fatalf("error in Apply: %v", err)
+	}
+	cmd, err := s.receiveMove(game)
+	if err != nil {
+		t.Fatalf("error in receiveMove: %v", err)
+	}
+	if msg := cmd(); msg != context.Canceled {
+		t.Errorf("got %v but want %v", msg, context.Canceled)
+	}
+}
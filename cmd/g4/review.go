@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"g4/coach"
+	"g4/record"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ReviewDone is the message carrying the mistakes found by the review of the game.
+type ReviewDone []coach.Annotation
+
+// review is the state of the review of a finished game.
+type review struct {
+	running, done bool
+	annotations   []coach.Annotation
+
+	// open tells whether the review screen is shown, and selected is the mistake it shows.
+	open     bool
+	selected int
+
+	// save tells whether the record waits for the review, with its result and reason.
+	save   bool
+	result record.Result
+	reason record.Reason
+}
+
+// finish ends the game and saves its record, after reviewing it if the coach is on.
+func (app AppModel) finish(result record.Result, reason record.Reason) (AppModel, tea.Cmd) {
+	if !app.autoReview {
+		return app, app.saveRecord(result, reason)
+	}
+	app.review.save, app.review.result, app.review.reason = true, result, reason
+	return app.startReview()
+}
+
+// startReview builds a command that reviews the game.
+func (app AppModel) startReview() (AppModel, tea.Cmd) {
+	if app.review.running || app.review.done {
+		return app, nil
+	}
+	app.review.running = true
+	start, moves, options := app.start, app.moves, app.coach
+	return app, func() tea.Msg {
+		annotations, err := coach.Review(context.Background(), start, moves, options)
+		if err != nil {
+			return fmt.Errorf("error reviewing game: %w", err)
+		}
+		return ReviewDone(annotations)
+	}
+}
+
+// endReview shows the mistakes found, and saves the record if it waited for them.
+func (app AppModel) endReview(annotations []coach.Annotation) (AppModel, tea.Cmd) {
+	app.review.running, app.review.done = false, true
+	app.review.annotations = annotations
+	app.review.open, app.review.selected = true, 0
+	if !app.review.save {
+		return app, nil
+	}
+	app.review.save = false
+	return app, app.saveRecord(app.review.result, app.review.reason)
+}
+
+// browseReview selects the next or the previous mistake.
+func (app AppModel) browseReview(combo string) AppModel {
+	n := len(app.review.annotations)
+	if n == 0 {
+		return app
+	}
+	if combo == "mistake+" {
+		app.review.selected = (app.review.selected + 1) % n
+	} else {
+		app.review.selected = (app.review.selected + n - 1) % n
+	}
+	return app
+}
+
+// reviewPosition returns the position where the selected mistake was played.
+func (app AppModel) reviewPosition() bitsim.Game {
+	if len(app.review.annotations) == 0 {
+		return app.game
+	}
+	game := app.start
+	for _, move := range app.moves[:app.review.annotations[app.review.selected].Ply] {
+		game, _ = game.Apply(move)
+	}
+	return game
+}
+
+// viewReview renders the side panel of the review screen.
+func viewReview(app AppModel) string {
+	hStyle := lipgloss.NewStyle().Bold(true).Foreground(light)
+	pStyle := lipgloss.NewStyle().PaddingLeft(1).MarginBottom(1).Foreground(lighter)
+	selectedStyle := lipgloss.NewStyle().Foreground(pinker)
+
+	annotations := app.review.annotations
+	if len(annotations) == 0 {
+		return lipgloss.JoinVertical(lipgloss.Left,
+			hStyle.Render("Review"),
+			pStyle.Render("No mistake found."),
+		)
+	}
+	lines := make([]string, len(annotations))
+	for k, a := range annotations {
+		lines[k] = fmt.Sprintf("%d. %s %v: %v", a.Ply+1, colorName(a.Move.Color), a.Move, a.Kind)
+		if k == app.review.selected {
+			lines[k] = selectedStyle.Render(lines[k])
+		}
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		hStyle.Render("Review: "+plural(len(annotations), "mistake")),
+		pStyle.Render(strings.Join(lines, "\n")),
+		hStyle.Render(fmt.Sprintf("Move %d", annotations[app.review.selected].Ply+1)),
+		pStyle.Copy().Width(32).Render(annotations[app.review.selected].Comment()),
+	)
+}
+
+// colorName returns the name of a player.
+func colorName(color g4.Color) string {
+	if color == g4.Red {
+		return "red"
+	}
+	return "yellow"
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"g4/coach"
+	"g4/record"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// saveRecord builds a command that appends the record of the game to the record file.
+//
+// It does nothing if no record file was requested.
+func (app AppModel) saveRecord(result record.Result, reason record.Reason) tea.Cmd {
+	if app.recordPath == "" {
+		return nil
+	}
+
+	rec := &record.Record{
+		Date:    time.Now().Format("2006.01.02"),
+		Variant: app.game.Rules.String(),
+		Mover:   app.start.Mover,
+		Result:  result,
+		Reason:  reason,
+	}
+	if start := app.start.Board.String(); start != bitsim.StartingPosition {
+		rec.Start = start
+	}
+	me, peer := localName(), app.opponent.name()
+	if app.myColor == g4.Yellow {
+		rec.Yellow, rec.Red = me, peer
+	} else {
+		rec.Yellow, rec.Red = peer, me
+	}
+	for _, move := range app.moves {
+		rec.Moves = append(rec.Moves, record.Node{Move: move})
+	}
+	coach.Annotate(rec, app.review.annotations)
+
+	path := app.recordPath
+	return func() tea.Msg {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("error saving game: %w", err)
+		}
+		defer f.Close()
+		if err := record.NewWriter(f).Write(rec); err != nil {
+			return fmt.Errorf("error saving game: %w", err)
+		}
+		return nil
+	}
+}
+
+// localName returns the name of the local player.
+func localName() string {
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "local"
+}
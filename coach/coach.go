@@ -0,0 +1,250 @@
+// Package coach reviews finished g4 games and flags the mistakes of the players.
+//
+// Every move of the game is compared with the alternatives of the mover,
+// scored by the engine at a fixed depth. Moves losing a won game are missed
+// wins, moves leaving a connect-4 of the opponent on the board are missed
+// blocks, and moves losing too much of the score are blunders. Each of them
+// comes with the best alternative.
+package coach
+
+import (
+	"context"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"g4/gametree"
+	"g4/record"
+	"g4/search"
+	"strings"
+)
+
+const (
+	// DefaultDepth is the default search depth of the review.
+	DefaultDepth = 6
+
+	// DefaultThreshold is the default loss of score making a blunder.
+	DefaultThreshold = 40
+)
+
+// Kind is the kind of a mistake.
+type Kind int
+
+const (
+	// Blunder is a move losing much more than the best one.
+	Blunder Kind = iota
+
+	// MissedWin is a move which lets a forced win slip away.
+	MissedWin
+
+	// MissedBlock is a move which leaves a threat of the opponent to connect four.
+	MissedBlock
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Blunder:
+		return "Blunder"
+	case MissedWin:
+		return "Missed win"
+	case MissedBlock:
+		return "Missed block"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Options configures the review.
+type Options struct {
+	// Depth is the search depth used to score the moves. It defaults to DefaultDepth.
+	Depth int
+
+	// Threshold is the loss of score, compared with the best move, making a
+	// blunder. It defaults to DefaultThreshold.
+	Threshold int
+
+	// Search configures the engine. Its depth is ignored.
+	Search search.Options
+}
+
+// Annotation describes a mistake.
+type Annotation struct {
+	// Ply is the index of the move in the game.
+	Ply  int
+	Move g4.Move
+	Kind Kind
+
+	// Score is the score of Move for its player, and BestScore the score of Best.
+	Score int
+
+	// Best is the best alternative to Move.
+	Best      g4.Move
+	BestScore int
+
+	// Connects tells whether the opponent connects four right after Move, with
+	// Reply. When Move itself completes the connect-4 of the opponent, which
+	// tilts can do, Reply is the empty move.
+	Connects bool
+	Reply    g4.Move
+}
+
+// Review returns the mistakes of the game played from start with given moves, in order.
+//
+// It fails if a move is illegal, or if ctx is done before the end of the review.
+func Review(ctx context.Context, start bitsim.Game, moves []g4.Move, options Options) ([]Annotation, error) {
+	if options.Depth <= 0 {
+		options.Depth = DefaultDepth
+	}
+	if options.Threshold <= 0 {
+		options.Threshold = DefaultThreshold
+	}
+	searcher := search.New(options.Search)
+
+	var annotations []Annotation
+	g := start
+	for ply, move := range moves {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		scores, _, err := searcher.ScoreMoves(ctx, g, options.Depth)
+		if err != nil {
+			return nil, err
+		}
+		if a, ok := judge(g, move, scores, options.Threshold); ok {
+			a.Ply = ply
+			annotations = append(annotations, a)
+		}
+		next, err := g.Apply(move)
+		if err != nil && !isOutcome(err) {
+			return nil, err
+		}
+		g = next
+	}
+	return annotations, nil
+}
+
+// judge returns the mistake made by playing move in g, if any.
+func judge(g bitsim.Game, move g4.Move, scores []search.MoveScore, threshold int) (Annotation, bool) {
+	a := Annotation{Move: move}
+	found := false
+	for k, s := range scores {
+		if s.Move == move {
+			a.Score, found = s.Score, true
+		}
+		if k == 0 || s.Score > a.BestScore {
+			a.Best, a.BestScore = s.Move, s.Score
+		}
+	}
+	if !found {
+		return Annotation{}, false // Illegal moves are reported by Review.
+	}
+	if a.Score >= a.BestScore || loses(a.BestScore) {
+		return Annotation{}, false
+	}
+
+	child, err := g.Apply(move)
+	opponent := g4.Yellow
+	if g.Mover == g4.Yellow {
+		opponent = g4.Red
+	}
+	switch {
+	case err == nil:
+		a.Reply, a.Connects = connect(child)
+	case g4.IsWin(err, opponent):
+		a.Connects = true
+	}
+
+	switch {
+	case wins(a.BestScore) && !wins(a.Score):
+		a.Kind = MissedWin
+	case a.Connects && a.Reply != (g4.Move{}) && threatens(g, a.Reply):
+		a.Kind = MissedBlock
+	case a.Connects || loses(a.Score) || a.BestScore-a.Score >= threshold:
+		a.Kind = Blunder
+	default:
+		return Annotation{}, false
+	}
+	return a, true
+}
+
+// connect returns a move of the mover of g which wins at once, if any.
+func connect(g bitsim.Game) (g4.Move, bool) {
+	moves, _ := g.Generate()
+	for _, move := range moves {
+		if _, err := g.Apply(move); g4.IsWin(err, g.Mover) {
+			return move, true
+		}
+	}
+	return g4.Move{}, false
+}
+
+// threatens returns whether the opponent of the mover of g could already win
+// with move, if it had the turn.
+func threatens(g bitsim.Game, move g4.Move) bool {
+	passed := bitsim.Game{Board: g.Board, Mover: move.Color}
+	_, err := passed.Apply(move)
+	return g4.IsWin(err, move.Color)
+}
+
+func isOutcome(err error) bool {
+	switch err.(type) {
+	case g4.YellowWins, g4.RedWins, g4.Draw, g4.DrawByNoProgress, g4.DrawByMoveLimit:
+		return true
+	}
+	return false
+}
+
+func wins(score int) bool {
+	return search.IsMate(score) && score > 0
+}
+
+func loses(score int) bool {
+	return search.IsMate(score) && score < 0
+}
+
+// Comment explains the mistake in a sentence, ending with the best alternative.
+func (a Annotation) Comment() string {
+	var reason string
+	opponent := "red"
+	if a.Move.Color == g4.Red {
+		opponent = "yellow"
+	}
+	switch {
+	case a.Kind == MissedWin:
+		reason = "the game was won, but this move " + describe(a.Score)
+	case a.Kind == MissedBlock:
+		reason = fmt.Sprintf("does not stop %s from connecting four with %v", opponent, a.Reply)
+	case a.Connects && a.Reply == (g4.Move{}):
+		reason = "this tilt completes a connect-4 of " + opponent
+	case a.Connects && a.Move.Type == g4.Tilt:
+		reason = fmt.Sprintf("this tilt gives %s a connect-4 with %v", opponent, a.Reply)
+	case a.Connects:
+		reason = fmt.Sprintf("gives %s a connect-4 with %v", opponent, a.Reply)
+	default:
+		reason = describe(a.Score)
+	}
+	return fmt.Sprintf("%v: %s. Best was %v (%s).", a.Kind, reason, a.Best, gametree.FormatValue(a.BestScore))
+}
+
+// describe tells how a move scoring given value plays.
+func describe(score int) string {
+	if search.IsMate(score) {
+		return gametree.FormatValue(score)
+	}
+	return "scores " + gametree.FormatValue(score)
+}
+
+// Annotate writes the annotations into the comments of the main line of rec,
+// with the best alternatives as variations.
+func Annotate(rec *record.Record, annotations []Annotation) {
+	for _, a := range annotations {
+		if a.Ply >= len(rec.Moves) {
+			continue
+		}
+		node := &rec.Moves[a.Ply]
+		comments := []string{a.Comment()}
+		if node.Comment != "" {
+			comments = append([]string{node.Comment}, comments...)
+		}
+		node.Comment = strings.Join(comments, " ")
+		node.Variations = append(node.Variations, []record.Node{{Move: a.Best}})
+	}
+}
@@ -0,0 +1,108 @@
+package coach_test
+
+import (
+	"context"
+	"g4"
+	"g4/bitsim"
+	"g4/coach"
+	"g4/internal/g4test"
+	"g4/record"
+	"strings"
+	"testing"
+)
+
+func TestReview(t *testing.T) {
+	examples := []struct {
+		board    string
+		mover    g4.Color
+		move     string
+		kind     coach.Kind
+		best     string
+		reply    string
+		contains string
+	}{
+		{"yyy5|r7|r7|8|8|8|8|8", g4.Red, "8", coach.MissedBlock, "1", "1", "does not stop yellow from connecting four with 1"},
+		{"yyy5|r7|r7|r7|8|8|8|8", g4.Yellow, "8", coach.MissedWin, "1", "5", "the game was won, but this move loses in 2"},
+		{"8|8|8|8|8|r7|ryy5|yrryy3", g4.Red, "L", coach.Blunder, "7", "", "this tilt completes a connect-4 of yellow"},
+		{"yryr4|yr6|8|r7|8|8|8|8", g4.Yellow, "D", coach.Blunder, "4", "6", "this tilt gives red a connect-4 with 6"},
+	}
+	for k, example := range examples {
+		game := g4test.Game(t, example.board, example.mover)
+		move := g4test.Move(t, example.move, example.mover)
+		annotations, err := coach.Review(context.Background(), game, []g4.Move{move}, coach.Options{Depth: 4})
+		if err != nil {
+			t.Fatalf("example %d: error in Review: %v", k, err)
+		}
+		if len(annotations) != 1 {
+			t.Fatalf("example %d: got %d annotations but want 1", k, len(annotations))
+		}
+		a := annotations[0]
+		if a.Kind != example.kind {
+			t.Errorf("example %d: got kind %v but want %v", k, a.Kind, example.kind)
+		}
+		if a.Best.String() != example.best {
+			t.Errorf("example %d: got best move %v but want %s", k, a.Best, example.best)
+		}
+		if !a.Connects {
+			t.Errorf("example %d: got no connect-4 of the opponent", k)
+		}
+		if example.reply != "" && a.Reply.String() != example.reply {
+			t.Errorf("example %d: got reply %v but want %s", k, a.Reply, example.reply)
+		}
+		if !strings.Contains(a.Comment(), example.contains) {
+			t.Errorf("example %d: got comment %q but want it to contain %q", k, a.Comment(), example.contains)
+		}
+	}
+}
+
+func TestReviewGoodMoves(t *testing.T) {
+	game := g4test.Game(t, bitsim.StartingPosition, g4.Yellow)
+	moves := []g4.Move{g4test.Move(t, "4", g4.Yellow), g4test.Move(t, "5", g4.Red)}
+	annotations, err := coach.Review(context.Background(), game, moves, coach.Options{Depth: 2})
+	if err != nil {
+		t.Fatalf("error in Review: %v", err)
+	}
+	if len(annotations) != 0 {
+		t.Errorf("got %d annotations but want none: %v", len(annotations), annotations)
+	}
+}
+
+func TestReviewIllegalMove(t *testing.T) {
+	game := g4test.Game(t, "yryryryr|ryryryry|8|8|8|8|8|8", g4.Yellow)
+	moves := []g4.Move{g4test.Move(t, "4", g4.Yellow), g4test.Move(t, "1", g4.Red)}
+	if _, err := coach.Review(context.Background(), game, moves, coach.Options{Depth: 1}); err == nil {
+		t.Errorf("got no error but want one")
+	}
+}
+
+func TestAnnotate(t *testing.T) {
+	game := g4test.Game(t, "yyy5|r7|r7|8|8|8|8|8", g4.Red)
+	rec := &record.Record{
+		Start: game.Board.String(),
+		Mover: g4.Red,
+		Moves: []record.Node{
+			{Move: g4test.Move(t, "8", g4.Red), Comment: "Hmm."},
+			{Move: g4test.Move(t, "1", g4.Yellow)},
+		},
+	}
+	moves := []g4.Move{rec.Moves[0].Move, rec.Moves[1].Move}
+	annotations, err := coach.Review(context.Background(), game, moves, coach.Options{Depth: 2})
+	if err != nil {
+		t.Fatalf("error in Review: %v", err)
+	}
+	coach.Annotate(rec, annotations)
+
+	want := "Hmm. " + annotations[0].Comment()
+	if got := rec.Moves[0].Comment; got != want {
+		t.Errorf("got comment %q but want %q", got, want)
+	}
+	if len(rec.Moves[0].Variations) != 1 || rec.Moves[0].Variations[0][0].Move != annotations[0].Best {
+		t.Errorf("got variations %v but want the best move %v", rec.Moves[0].Variations, annotations[0].Best)
+	}
+	if rec.Moves[1].Comment != "" {
+		t.Errorf("got comment %q on the winning move but want none", rec.Moves[1].Comment)
+	}
+	if _, err := rec.Replay(); err != nil {
+		t.Errorf("error in Replay of the annotated record: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Package eval provides static evaluation of g4 positions.
+//
+// The main evaluator, Heuristic, is a weighted sum of independent terms. Terms
+// and weights are pluggable, and the contribution of each term can be shown to
+// understand and tune the evaluation.
+package eval
+
+import (
+	"fmt"
+	"g4/bitsim"
+	"strings"
+	"text/tabwriter"
+)
+
+// Evaluator scores positions from the point of view of the player with the move.
+//
+// Positive scores are good for the mover. Evaluators are meant for live
+// positions: finished games are left to the search.
+type Evaluator interface {
+	Evaluate(g bitsim.Game) int
+}
+
+// Term is a single heuristic of the evaluation.
+type Term interface {
+	// Name identifies the term in weight files and breakdowns.
+	Name() string
+
+	// Eval returns the raw value of the term for the player with the move.
+	Eval(g bitsim.Game) int
+}
+
+// Weights maps term names to their weights.
+type Weights map[string]int
+
+// Heuristic is an evaluator made of weighted terms.
+type Heuristic struct {
+	Terms   []Term
+	Weights Weights
+}
+
+// New returns a heuristic evaluator using the default terms and given weights.
+//
+// Terms missing from weights get their default weight.
+func New(weights Weights) *Heuristic {
+	merged := make(Weights)
+	for name, weight := range DefaultWeights {
+		merged[name] = weight
+	}
+	for name, weight := range weights {
+		merged[name] = weight
+	}
+	return &Heuristic{Terms: DefaultTerms(), Weights: merged}
+}
+
+// Evaluate returns the weighted sum of the terms.
+func (h *Heuristic) Evaluate(g bitsim.Game) int {
+	score := 0
+	for _, term := range h.Terms {
+		if weight := h.Weights[term.Name()]; weight != 0 {
+			score += weight * term.Eval(g)
+		}
+	}
+	return score
+}
+
+// Contribution details the part of a term in an evaluation.
+type Contribution struct {
+	Name   string
+	Raw    int
+	Weight int
+}
+
+// Score returns the weighted value of the term.
+func (c Contribution) Score() int {
+	return c.Raw * c.Weight
+}
+
+// Breakdown lists the contributions of all the terms of an evaluation.
+type Breakdown []Contribution
+
+// Breakdown evaluates each term separately.
+func (h *Heuristic) Breakdown(g bitsim.Game) Breakdown {
+	breakdown := make(Breakdown, 0, len(h.Terms))
+	for _, term := range h.Terms {
+		breakdown = append(breakdown, Contribution{
+			Name:   term.Name(),
+			Raw:    term.Eval(g),
+			Weight: h.Weights[term.Name()],
+		})
+	}
+	return breakdown
+}
+
+// Total returns the sum of the contributions, that is to say the evaluation.
+func (b Breakdown) Total() int {
+	total := 0
+	for _, c := range b {
+		total += c.Score()
+	}
+	return total
+}
+
+// String returns the breakdown as a table.
+func (b Breakdown) String() string {
+	var s strings.Builder
+	w := tabwriter.NewWriter(&s, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "term\traw\tweight\tscore\t")
+	for _, c := range b {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t\n", c.Name, c.Raw, c.Weight, c.Score())
+	}
+	fmt.Fprintf(w, "total\t\t\t%d\t\n", b.Total())
+	w.Flush()
+	return s.String()
+}
@@ -0,0 +1,77 @@
+package eval_test
+
+import (
+	"g4"
+	"g4/bitsim"
+	"g4/eval"
+	"strings"
+	"testing"
+)
+
+func TestNewMergesWeights(t *testing.T) {
+	h := eval.New(eval.Weights{"centre": 7})
+	if h.Weights["centre"] != 7 {
+		t.Errorf("got centre weight %d but want 7", h.Weights["centre"])
+	}
+	if h.Weights["open-threes"] != eval.DefaultWeights["open-threes"] {
+		t.Errorf("got open-threes weight %d but want default", h.Weights["open-threes"])
+	}
+}
+
+// Tests that evaluations are the same for both players, up to the sign.
+func TestEvaluateSymmetry(t *testing.T) {
+	examples := []struct {
+		in      string
+		swapped string
+	}{
+		{
+			in:      "yyy5|rr6|r7|r7|8|8|8|8",
+			swapped: "rrr5|yy6|y7|y7|8|8|8|8",
+		},
+		{
+			in:      "8|8|yr6|yyr5|ryr5|r7|8|8",
+			swapped: "8|8|ry6|rry5|yry5|y7|8|8",
+		},
+	}
+	h := eval.New(nil)
+	for k, ex := range examples {
+		board, _ := bitsim.FromString(ex.in)
+		swapped, _ := bitsim.FromString(ex.swapped)
+		for _, mover := range []g4.Color{g4.Yellow, g4.Red} {
+			other := g4.Red
+			if mover == g4.Red {
+				other = g4.Yellow
+			}
+			got := h.Evaluate(bitsim.Game{Board: board, Mover: mover})
+			want := h.Evaluate(bitsim.Game{Board: swapped, Mover: other})
+			if got != want {
+				t.Errorf("example %d: got %d but want %d", k, got, want)
+			}
+		}
+	}
+}
+
+func TestBreakdown(t *testing.T) {
+	board, _ := bitsim.FromString("8|y7|y7|y7|8|8|8|8")
+	game := bitsim.Game{Board: board, Mover: g4.Red}
+	h := eval.New(eval.Weights{"open-threes": 10, "tilt-threats": 1, "centre": 0, "parity": 3})
+
+	breakdown := h.Breakdown(game)
+	if len(breakdown) != 4 {
+		t.Fatalf("got %d contributions but want 4", len(breakdown))
+	}
+	if got, want := breakdown.Total(), h.Evaluate(game); got != want {
+		t.Errorf("got total %d but evaluation is %d", got, want)
+	}
+	if breakdown[0].Name != "open-threes" || breakdown[0].Raw != -2 || breakdown[0].Score() != -20 {
+		t.Errorf("wrong first contribution: %+v", breakdown[0])
+	}
+
+	lines := strings.Split(strings.TrimSpace(breakdown.String()), "\n")
+	if len(lines) != 6 {
+		t.Errorf("got %d lines but want 6:\n%s", len(lines), breakdown)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(lines[len(lines)-1]), "total") {
+		t.Errorf("last line should be the total: %s", lines[len(lines)-1])
+	}
+}
@@ -0,0 +1,111 @@
+package eval
+
+import (
+	"g4/bitsim"
+	"math/bits"
+)
+
+const (
+	evenRows uint64 = 0x5555555555555555
+	oddRows  uint64 = ^evenRows
+)
+
+// centreRings holds, for k = 1, 2, 3, the squares at least k squares away from every edge.
+var centreRings = [...]uint64{
+	0x007e7e7e7e7e7e00,
+	0x00003c3c3c3c0000,
+	0x0000001818000000,
+}
+
+// DefaultWeights holds the weights used when none are given.
+var DefaultWeights = Weights{
+	"open-threes":  8,
+	"tilt-threats": 2,
+	"centre":       1,
+	"parity":       4,
+}
+
+// DefaultTerms returns the terms of the default evaluation.
+func DefaultTerms() []Term {
+	return []Term{
+		OpenThrees{},
+		TiltThreats{},
+		Centre{},
+		Parity{},
+	}
+}
+
+// OpenThrees counts the empty squares completing a connect 4, for the mover minus the opponent.
+type OpenThrees struct{}
+
+func (OpenThrees) Name() string {
+	return "open-threes"
+}
+
+func (OpenThrees) Eval(g bitsim.Game) int {
+	return threatBalance(g.Board, g)
+}
+
+// TiltThreats sums the balance of open threes over the boards obtained by each tilt.
+//
+// It measures how much the mover stands to gain from tilting, or to fear from
+// the opponent tilting.
+type TiltThreats struct{}
+
+func (TiltThreats) Name() string {
+	return "tilt-threats"
+}
+
+func (TiltThreats) Eval(g bitsim.Game) int {
+	total := 0
+	for times := 1; times < 4; times++ {
+		total += threatBalance(g.Board.RotateLeft(times).ApplyGravity(), g)
+	}
+	return total
+}
+
+// Centre rewards tokens far from the edges, for the mover minus the opponent.
+//
+// Since tilts turn any edge into the bottom, distance is measured to all four edges.
+type Centre struct{}
+
+func (Centre) Name() string {
+	return "centre"
+}
+
+func (Centre) Eval(g bitsim.Game) int {
+	own := g.Board.Tokens(g.Mover)
+	other := g.Board.Tokens(g.Mover.Opponent())
+	total := 0
+	for _, ring := range centreRings {
+		total += bits.OnesCount64(own&ring) - bits.OnesCount64(other&ring)
+	}
+	return total
+}
+
+// Parity counts the open threes lying on rows which the player would get if the board filled up.
+//
+// When both players only drop tokens, the player with the move gets every
+// other row of each column, starting with the row of same parity as the
+// number of tokens on the board. This is the classic connect-4 zugzwang
+// heuristic, which tilts make less reliable.
+type Parity struct{}
+
+func (Parity) Name() string {
+	return "parity"
+}
+
+func (Parity) Eval(g bitsim.Game) int {
+	moverRows := evenRows
+	if g.Board.Count()%2 == 1 {
+		moverRows = oddRows
+	}
+	own := g.Board.Threats(g.Mover) & moverRows
+	other := g.Board.Threats(g.Mover.Opponent()) &^ moverRows
+	return bits.OnesCount64(own) - bits.OnesCount64(other)
+}
+
+// threatBalance returns the number of threats of g.Mover minus the ones of the opponent on board b.
+func threatBalance(b bitsim.Board, g bitsim.Game) int {
+	return bits.OnesCount64(b.Threats(g.Mover)) - bits.OnesCount64(b.Threats(g.Mover.Opponent()))
+}
@@ -0,0 +1,59 @@
+package eval_test
+
+import (
+	"g4"
+	"g4/bitsim"
+	"g4/eval"
+	"testing"
+)
+
+func TestTerms(t *testing.T) {
+	examples := []struct {
+		in    string
+		mover g4.Color
+		term  eval.Term
+		out   int
+	}{
+		{in: bitsim.StartingPosition, mover: g4.Yellow, term: eval.OpenThrees{}, out: 0},
+		{in: "yyy5|8|8|8|8|8|8|8", mover: g4.Yellow, term: eval.OpenThrees{}, out: 1},
+		{in: "yyy5|8|8|8|8|8|8|8", mover: g4.Red, term: eval.OpenThrees{}, out: -1},
+		{in: "yyy5|rr6|r7|r7|8|8|8|8", mover: g4.Yellow, term: eval.OpenThrees{}, out: 0},
+		{in: "8|y7|y7|y7|8|8|8|8", mover: g4.Yellow, term: eval.OpenThrees{}, out: 2},
+
+		// Tilting left or right turns the line into a column (1 threat), down keeps it (2 threats).
+		{in: "8|y7|y7|y7|8|8|8|8", mover: g4.Yellow, term: eval.TiltThreats{}, out: 4},
+		{in: "8|y7|y7|y7|8|8|8|8", mover: g4.Red, term: eval.TiltThreats{}, out: -4},
+
+		{in: bitsim.StartingPosition, mover: g4.Yellow, term: eval.Centre{}, out: 0},
+		{in: "y7|8|8|8|8|8|8|8", mover: g4.Yellow, term: eval.Centre{}, out: 0},
+		{in: "8|8|8|yyyy4|8|8|8|8", mover: g4.Yellow, term: eval.Centre{}, out: 6},
+		{in: "8|8|8|yyyy4|rrrr4|8|8|8", mover: g4.Red, term: eval.Centre{}, out: 0},
+
+		// Yellow to move with 3 tokens gets odd rows: the threat on row 3 counts.
+		{in: "yyy5|8|8|8|8|8|8|8", mover: g4.Yellow, term: eval.Parity{}, out: 1},
+		{in: "yyy5|r7|8|8|8|8|8|8", mover: g4.Yellow, term: eval.Parity{}, out: 0},
+		{in: "yyy5|r7|8|8|8|8|8|8", mover: g4.Red, term: eval.Parity{}, out: -1},
+	}
+	for k, ex := range examples {
+		board, err := bitsim.FromString(ex.in)
+		if err != nil {
+			t.Errorf("example %d: error in FromString: %v", k, err)
+		}
+		got := ex.term.Eval(bitsim.Game{Board: board, Mover: ex.mover})
+		if got != ex.out {
+			t.Errorf("example %d (%s): got %d but want %d", k, ex.term.Name(), got, ex.out)
+		}
+	}
+}
+
+func TestDefaultTerms(t *testing.T) {
+	terms := eval.DefaultTerms()
+	if len(terms) != len(eval.DefaultWeights) {
+		t.Errorf("got %d terms but %d default weights", len(terms), len(eval.DefaultWeights))
+	}
+	for _, term := range terms {
+		if _, ok := eval.DefaultWeights[term.Name()]; !ok {
+			t.Errorf("term '%s' has no default weight", term.Name())
+		}
+	}
+}
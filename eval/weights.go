@@ -0,0 +1,41 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ReadWeights reads weights in JSON format, such as:
+//
+//	{"open-threes": 10, "centre": 2}
+//
+// Only the default terms are accepted, and missing terms get their default weight.
+func ReadWeights(r io.Reader) (Weights, error) {
+	var read Weights
+	if err := json.NewDecoder(r).Decode(&read); err != nil {
+		return nil, fmt.Errorf("error decoding weights: %w", err)
+	}
+	weights := make(Weights)
+	for name, weight := range DefaultWeights {
+		weights[name] = weight
+	}
+	for name, weight := range read {
+		if _, ok := DefaultWeights[name]; !ok {
+			return nil, fmt.Errorf("unknown term '%s'", name)
+		}
+		weights[name] = weight
+	}
+	return weights, nil
+}
+
+// LoadWeights reads weights from a JSON file.
+func LoadWeights(path string) (Weights, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadWeights(f)
+}
@@ -0,0 +1,50 @@
+package eval_test
+
+import (
+	"g4/eval"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadWeights(t *testing.T) {
+	weights, err := eval.ReadWeights(strings.NewReader(`{"open-threes": 10, "centre": -1}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if weights["open-threes"] != 10 || weights["centre"] != -1 {
+		t.Errorf("wrong weights: %v", weights)
+	}
+	if weights["parity"] != eval.DefaultWeights["parity"] {
+		t.Errorf("missing weight should default: %v", weights)
+	}
+}
+
+func TestReadWeightsError(t *testing.T) {
+	examples := []string{
+		``,
+		`{"open-threes": "ten"}`,
+		`{"mobility": 3}`,
+		`[1, 2, 3]`,
+	}
+	for k, ex := range examples {
+		if weights, err := eval.ReadWeights(strings.NewReader(ex)); err == nil {
+			t.Errorf("example %d: got (%v, %v) but expected error", k, weights, err)
+		}
+	}
+}
+
+func TestLoadWeights(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weights.json")
+	if err := os.WriteFile(path, []byte(`{"parity": 0}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	weights, err := eval.LoadWeights(path)
+	if err != nil || weights["parity"] != 0 {
+		t.Errorf("got (%v, %v)", weights, err)
+	}
+	if _, err := eval.LoadWeights(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Errorf("expected error for missing file")
+	}
+}
@@ -0,0 +1,259 @@
+// Package fairplay compares the moves of players with the choices of the engine.
+//
+// Every move of the recorded games is scored against the alternatives of the
+// mover at several search depths. A player matching the top choices of the
+// engine far more often than others, while hardly ever losing any score, may
+// have been helped by an engine. The statistics are hints for a human review,
+// not a proof: strong players match the engine too, and short or forced games
+// say little.
+package fairplay
+
+import (
+	"context"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"g4/record"
+	"g4/search"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	// DefaultSkipPlies is the default number of opening moves left out of the statistics.
+	DefaultSkipPlies = 4
+
+	// MaxLoss caps the score lost by a single move, so that a few blunders in
+	// won or lost positions do not outweigh the rest of the games.
+	MaxLoss = 200
+
+	// unknownPlayer names players missing from the records.
+	unknownPlayer = "?"
+)
+
+// DefaultDepths are the default search depths of the comparison.
+var DefaultDepths = []int{2, 4, 6}
+
+// Options configures the comparison.
+type Options struct {
+	// Depths are the search depths at which moves are compared with the top
+	// choices of the engine, in increasing order. They default to DefaultDepths.
+	Depths []int
+
+	// SkipPlies is the number of opening moves of each game left out, since
+	// they are often played from memory. It defaults to DefaultSkipPlies, and
+	// negative values keep all moves.
+	SkipPlies int
+
+	// Search configures the engine. Its depth is replaced by each entry of
+	// Depths in turn, and its transposition table is cleared between depths.
+	Search search.Options
+}
+
+// Thresholds tell which players are flagged.
+type Thresholds struct {
+	// MatchRate is the lowest rate of matches with the top choice at the
+	// greatest depth which is flagged.
+	MatchRate float64
+
+	// AverageLoss is the greatest average score loss which is flagged.
+	AverageLoss float64
+
+	// MinMoves is the number of moves needed to flag a player.
+	MinMoves int
+}
+
+// DefaultThresholds are the thresholds used when none are given.
+var DefaultThresholds = Thresholds{MatchRate: 0.9, AverageLoss: 5, MinMoves: 40}
+
+// Player holds the statistics of a player.
+type Player struct {
+	Name string
+
+	// Games is the number of games of the player, and Moves the number of its
+	// moves compared with the engine.
+	Games, Moves int
+
+	// Matches counts the moves which were top choices of the engine, at each depth.
+	Matches []int
+
+	// Loss is the total score lost by the moves, at the greatest depth.
+	Loss int
+}
+
+// MatchRate returns the rate of matches at the depth of index k.
+func (p *Player) MatchRate(k int) float64 {
+	if p.Moves == 0 {
+		return 0
+	}
+	return float64(p.Matches[k]) / float64(p.Moves)
+}
+
+// AverageLoss returns the average score lost by a move.
+func (p *Player) AverageLoss() float64 {
+	if p.Moves == 0 {
+		return 0
+	}
+	return float64(p.Loss) / float64(p.Moves)
+}
+
+// Flagged tells whether the player crosses the thresholds.
+func (p *Player) Flagged(thresholds Thresholds) bool {
+	return p.Moves >= thresholds.MinMoves &&
+		p.MatchRate(len(p.Matches)-1) >= thresholds.MatchRate &&
+		p.AverageLoss() <= thresholds.AverageLoss
+}
+
+// Report holds the statistics of all players, sorted by name.
+type Report struct {
+	Depths  []int
+	Players []*Player
+}
+
+// Analyze compares the moves of the records with the top choices of the engine.
+//
+// Only the main lines are read. It fails if a record cannot be replayed, or
+// if ctx is done before the end of the analysis.
+func Analyze(ctx context.Context, records []*record.Record, options Options) (*Report, error) {
+	if len(options.Depths) == 0 {
+		options.Depths = DefaultDepths
+	}
+	if options.SkipPlies == 0 {
+		options.SkipPlies = DefaultSkipPlies
+	}
+	searcher := search.New(options.Search)
+
+	players := make(map[string]*Player)
+	player := func(name string) *Player {
+		if name == "" {
+			name = unknownPlayer
+		}
+		p, ok := players[name]
+		if !ok {
+			p = &Player{Name: name, Matches: make([]int, len(options.Depths))}
+			players[name] = p
+		}
+		return p
+	}
+
+	for k, rec := range records {
+		games, err := rec.Replay()
+		if err != nil {
+			return nil, fmt.Errorf("game %d: %w", k+1, err)
+		}
+		yellow, red := player(rec.Yellow), player(rec.Red)
+		yellow.Games++
+		if red != yellow {
+			red.Games++
+		}
+		for ply, node := range rec.Moves {
+			if ply < options.SkipPlies {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			p := yellow
+			if node.Move.Color == g4.Red {
+				p = red
+			}
+			matches, loss, ok, err := compare(ctx, searcher, games[ply], node.Move, options.Depths)
+			if err != nil {
+				return nil, fmt.Errorf("game %d, ply %d: %w", k+1, ply, err)
+			}
+			if !ok {
+				continue
+			}
+			p.Moves++
+			for d, match := range matches {
+				if match {
+					p.Matches[d]++
+				}
+			}
+			p.Loss += loss
+		}
+	}
+
+	report := &Report{Depths: options.Depths}
+	for _, p := range players {
+		report.Players = append(report.Players, p)
+	}
+	sort.Slice(report.Players, func(a, b int) bool {
+		return report.Players[a].Name < report.Players[b].Name
+	})
+	return report, nil
+}
+
+// compare tells whether move is a top choice of the engine at each depth, and
+// the score it loses at the greatest depth.
+//
+// The transposition table is cleared before each depth, so that scores never
+// come from deeper searches.
+//
+// Positions with a single legal move, or where every move loses by force,
+// leave no choice and are left out.
+func compare(ctx context.Context, searcher *search.Searcher, g bitsim.Game, move g4.Move, depths []int) ([]bool, int, bool, error) {
+	matches := make([]bool, len(depths))
+	var loss int
+	for d, depth := range depths {
+		searcher.Clear()
+		scores, _, err := searcher.ScoreMoves(ctx, g, depth)
+		if err != nil {
+			return nil, 0, false, err
+		}
+		if len(scores) < 2 {
+			return nil, 0, false, nil
+		}
+		best, played, found := scores[0].Score, 0, false
+		for _, s := range scores {
+			if s.Score > best {
+				best = s.Score
+			}
+			if s.Move == move {
+				played, found = s.Score, true
+			}
+		}
+		if !found {
+			return nil, 0, false, fmt.Errorf("illegal move %v", move)
+		}
+		if search.IsMate(best) && best < 0 {
+			return nil, 0, false, nil
+		}
+		matches[d] = played == best
+		loss = best - played
+		if loss > MaxLoss {
+			loss = MaxLoss
+		}
+	}
+	return matches, loss, true, nil
+}
+
+// WriteTable writes the statistics of the players, flagging those crossing the thresholds.
+func (r *Report) WriteTable(w io.Writer, thresholds Thresholds) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	header := []string{"Player", "Games", "Moves"}
+	for _, depth := range r.Depths {
+		header = append(header, fmt.Sprintf("Depth %d", depth))
+	}
+	header = append(header, "Loss", "")
+	fmt.Fprintln(tw, strings.Join(header, "\t")+"\t")
+	for _, p := range r.Players {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t", p.Name, p.Games, p.Moves)
+		for k := range r.Depths {
+			fmt.Fprintf(tw, "%.1f%%\t", 100*p.MatchRate(k))
+		}
+		flag := ""
+		if p.Flagged(thresholds) {
+			flag = "flagged"
+		}
+		fmt.Fprintf(tw, "%.1f\t%s\t\n", p.AverageLoss(), flag)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\nFlagged: at least %d moves, %.1f%% matches at depth %d and an average loss of at most %.1f.\n",
+		thresholds.MinMoves, 100*thresholds.MatchRate, r.Depths[len(r.Depths)-1], thresholds.AverageLoss)
+	return err
+}
@@ -0,0 +1,147 @@
+package fairplay_test
+
+import (
+	"bytes"
+	"context"
+	"g4"
+	"g4/fairplay"
+	"g4/record"
+	"g4/search"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// playGame records a game between the top choices of the engine at depth 2,
+// for yellow, and random moves, for red.
+func playGame(t *testing.T, seed int64) *record.Record {
+	t.Helper()
+	r := rand.New(rand.NewSource(seed))
+	searcher := search.New(search.Options{})
+	rec := &record.Record{Yellow: "engine", Red: "random"}
+	game, err := rec.StartingGame()
+	if err != nil {
+		t.Fatalf("error in StartingGame: %v", err)
+	}
+	for game.Validate() == nil {
+		moves, _ := game.Generate()
+		move := moves[r.Intn(len(moves))]
+		if len(rec.Moves)%2 == 0 {
+			scores, _, err := searcher.ScoreMoves(context.Background(), game, 2)
+			if err != nil {
+				t.Fatalf("error in ScoreMoves: %v", err)
+			}
+			best := scores[0]
+			for _, s := range scores {
+				if s.Score > best.Score {
+					best = s
+				}
+			}
+			move = best.Move
+		}
+		rec.Moves = append(rec.Moves, record.Node{Move: move})
+		game, _ = game.Apply(move)
+	}
+	rec.Result, rec.Reason = record.Conclude(game)
+	return rec
+}
+
+func TestAnalyze(t *testing.T) {
+	var records []*record.Record
+	for seed := int64(1); seed <= 4; seed++ {
+		records = append(records, playGame(t, seed))
+	}
+	options := fairplay.Options{Depths: []int{1, 2}, SkipPlies: -1}
+	report, err := fairplay.Analyze(context.Background(), records, options)
+	if err != nil {
+		t.Fatalf("error in Analyze: %v", err)
+	}
+	if len(report.Players) != 2 {
+		t.Fatalf("got %d players but want 2", len(report.Players))
+	}
+	engine, random := report.Players[0], report.Players[1]
+	if engine.Name != "engine" || random.Name != "random" {
+		t.Fatalf("got players %q and %q but want engine and random", engine.Name, random.Name)
+	}
+	if engine.Games != 4 || random.Games != 4 {
+		t.Errorf("got %d and %d games but want 4", engine.Games, random.Games)
+	}
+	if got := engine.MatchRate(1); got != 1 {
+		t.Errorf("got match rate %v at depth 2 for the engine but want 1", got)
+	}
+	if got := engine.AverageLoss(); got != 0 {
+		t.Errorf("got average loss %v for the engine but want 0", got)
+	}
+	if random.MatchRate(1) >= engine.MatchRate(1) || random.AverageLoss() <= 0 {
+		t.Errorf("got match rate %v and average loss %v for random moves", random.MatchRate(1), random.AverageLoss())
+	}
+
+	thresholds := fairplay.Thresholds{MatchRate: 0.95, AverageLoss: 1, MinMoves: 10}
+	if !engine.Flagged(thresholds) {
+		t.Errorf("got engine not flagged but want it flagged")
+	}
+	if random.Flagged(thresholds) {
+		t.Errorf("got random moves flagged but want them not flagged")
+	}
+	thresholds.MinMoves = engine.Moves + 1
+	if engine.Flagged(thresholds) {
+		t.Errorf("got engine flagged with too few moves")
+	}
+}
+
+func TestAnalyzeDepths(t *testing.T) {
+	// The move 5 looks best statically, but loses by force.
+	rec := &record.Record{Yellow: "yellow", Red: "red", Start: "8|y7|yrr5|y7|8|r7|8|rryy4", Mover: g4.Red}
+	rec.Moves = []record.Node{{Move: g4.TokenMove(g4.Red, 4)}}
+	report, err := fairplay.Analyze(context.Background(), []*record.Record{rec}, fairplay.Options{Depths: []int{1, 4}, SkipPlies: -1})
+	if err != nil {
+		t.Fatalf("error in Analyze: %v", err)
+	}
+	red := report.Players[0]
+	if red.Name != "red" || red.Moves != 1 {
+		t.Fatalf("got player %s with %d moves but want red with 1 move", red.Name, red.Moves)
+	}
+	if red.Matches[0] != 1 || red.Matches[1] != 0 {
+		t.Errorf("got matches %v but want [1 0]", red.Matches)
+	}
+	if red.Loss != fairplay.MaxLoss {
+		t.Errorf("got loss %d but want %d", red.Loss, fairplay.MaxLoss)
+	}
+}
+
+func TestAnalyzeSkipPlies(t *testing.T) {
+	records := []*record.Record{playGame(t, 1)}
+	report, err := fairplay.Analyze(context.Background(), records, fairplay.Options{Depths: []int{1}, SkipPlies: 1000})
+	if err != nil {
+		t.Fatalf("error in Analyze: %v", err)
+	}
+	for _, p := range report.Players {
+		if p.Moves != 0 || p.Games != 1 {
+			t.Errorf("player %s: got %d games and %d moves but want 1 game and no moves", p.Name, p.Games, p.Moves)
+		}
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	report := &fairplay.Report{
+		Depths: []int{2, 4},
+		Players: []*fairplay.Player{
+			{Name: "alice", Games: 3, Moves: 50, Matches: []int{48, 49}, Loss: 10},
+			{Name: "bob", Games: 3, Moves: 50, Matches: []int{30, 25}, Loss: 900},
+		},
+	}
+	var buf bytes.Buffer
+	if err := report.WriteTable(&buf, fairplay.DefaultThresholds); err != nil {
+		t.Fatalf("error in WriteTable: %v", err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if !strings.Contains(lines[0], "Depth 4") {
+		t.Errorf("got header %q but want the depths", lines[0])
+	}
+	if !strings.Contains(lines[1], "alice") || !strings.Contains(lines[1], "98.0%") || !strings.Contains(lines[1], "flagged") {
+		t.Errorf("got line %q but want alice flagged with 98.0%% matches", lines[1])
+	}
+	if !strings.Contains(lines[2], "bob") || strings.Contains(lines[2], "flagged") {
+		t.Errorf("got line %q but want bob not flagged", lines[2])
+	}
+}
@@ -0,0 +1,79 @@
+package gametree
+
+import (
+	"bufio"
+	"fmt"
+	"g4"
+	"g4/record"
+	"g4/search"
+	"io"
+)
+
+// WriteDOT writes the tree in the DOT language of Graphviz.
+//
+// Nodes are labelled with their board, mover and value, and finished games
+// with their result. Edges are labelled with their move, and principal edges
+// are drawn in bold.
+func (t *Tree) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph g4 {")
+	fmt.Fprintln(bw, "\tnode [shape=box, fontname=\"monospace\"];")
+	for _, node := range t.Nodes {
+		fmt.Fprintf(bw, "\t%s [label=\"%s\"%s];\n", nodeID(node), nodeLabel(node), nodeStyle(node, node == t.Root))
+	}
+	for _, node := range t.Nodes {
+		for _, edge := range node.Edges {
+			style := ""
+			if edge.Principal {
+				style = ", style=bold"
+			}
+			fmt.Fprintf(bw, "\t%s -> %s [label=\"%v\"%s];\n", nodeID(node), nodeID(edge.To), edge.Move, style)
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+func nodeID(node *Node) string {
+	return fmt.Sprintf("n%016x", node.Hash)
+}
+
+func nodeLabel(node *Node) string {
+	board := node.Game.Board.String()
+	if node.Outcome != nil {
+		result, reason := record.Conclude(node.Game)
+		return fmt.Sprintf("%s\\n%v (%s)", board, result, reason)
+	}
+	mover := "yellow"
+	if node.Game.Mover == g4.Red {
+		mover = "red"
+	}
+	return fmt.Sprintf("%s\\n%s to move\\n%s", board, mover, FormatValue(node.Value))
+}
+
+func nodeStyle(node *Node, root bool) string {
+	switch {
+	case root:
+		return ", penwidth=2"
+	case node.Outcome == nil:
+		return ""
+	}
+	switch node.Outcome.(type) {
+	case g4.YellowWins:
+		return ", style=filled, fillcolor=\"#f0f080\""
+	case g4.RedWins:
+		return ", style=filled, fillcolor=\"#f08080\""
+	}
+	return ", style=filled, fillcolor=\"#d0d0d0\""
+}
+
+// FormatValue writes the value of a node, with forced results in plies.
+func FormatValue(value int) string {
+	switch {
+	case search.IsMate(value) && value > 0:
+		return fmt.Sprintf("wins in %d", search.Mate-value)
+	case search.IsMate(value):
+		return fmt.Sprintf("loses in %d", search.Mate+value)
+	}
+	return fmt.Sprintf("%+d", value)
+}
@@ -0,0 +1,202 @@
+// Package gametree explores the game tree of g4 positions.
+//
+// The tree is built breadth first up to a depth, and transpositions share a
+// single node, so that it is really a graph. Nodes are valued by negamax over
+// the explored tree, with the static evaluation at the leaves.
+package gametree
+
+import (
+	"errors"
+	"g4"
+	"g4/bitsim"
+	"g4/eval"
+	"g4/search"
+	"sort"
+)
+
+// DefaultMaxNodes is the default limit on the size of a tree.
+const DefaultMaxNodes = 100000
+
+// ErrorTooLarge is returned when a tree would hold more nodes than allowed.
+var ErrorTooLarge = errors.New("game tree too large")
+
+// Options configures the exploration of a tree.
+type Options struct {
+	// Depth is the number of plies explored from the root.
+	Depth int
+
+	// Principal prunes the tree to its principal variations: only the best
+	// moves of each node are kept, all of them in case of ties.
+	Principal bool
+
+	// Evaluator values the leaves. It defaults to eval.New(nil).
+	Evaluator eval.Evaluator
+
+	// MaxNodes limits the number of nodes. It defaults to DefaultMaxNodes.
+	MaxNodes int
+}
+
+// Node is a position of the tree.
+type Node struct {
+	Game bitsim.Game
+
+	// Hash identifies the position. Transpositions have the same hash.
+	Hash uint64
+
+	// Ply is the length of the shortest path from the root.
+	Ply int
+
+	// Outcome is the error returned by Validate when the game is over, and nil otherwise.
+	Outcome error
+
+	// Value is the score of the node for its mover, in the units of package
+	// search. Forced results are counted in plies from the node.
+	Value int
+
+	Edges []Edge
+}
+
+// Edge is a move from a node to another.
+type Edge struct {
+	Move g4.Move
+	To   *Node
+
+	// Principal tells whether the move is one of the best of its node.
+	Principal bool
+}
+
+// Tree is an explored game tree.
+type Tree struct {
+	Root *Node
+
+	// Nodes lists all nodes, by increasing ply.
+	Nodes []*Node
+}
+
+// Build explores the tree of g.
+//
+// Nodes deeper than the depth, and finished games, are leaves. Moves going
+// back to shallower nodes, which happens when tilts repeat a position, do not
+// contribute their subtree: they get the static evaluation of their target.
+func Build(g bitsim.Game, options Options) (*Tree, error) {
+	if options.Evaluator == nil {
+		options.Evaluator = eval.New(nil)
+	}
+	if options.MaxNodes <= 0 {
+		options.MaxNodes = DefaultMaxNodes
+	}
+
+	root := &Node{Game: g, Hash: g.Hash(), Outcome: g.Validate()}
+	tree := &Tree{Root: root, Nodes: []*Node{root}}
+	nodes := map[uint64]*Node{root.Hash: root}
+	for k := 0; k < len(tree.Nodes); k++ {
+		node := tree.Nodes[k]
+		if node.Outcome != nil || node.Ply >= options.Depth {
+			continue
+		}
+		moves, _ := node.Game.Generate()
+		for _, move := range moves {
+			child, err := node.Game.Apply(move)
+			if errors.Is(err, g4.ErrorInvalidMove{}) {
+				continue
+			}
+			hash := child.Hash()
+			target, ok := nodes[hash]
+			if !ok {
+				if len(tree.Nodes) >= options.MaxNodes {
+					return nil, ErrorTooLarge
+				}
+				target = &Node{Game: child, Hash: hash, Ply: node.Ply + 1, Outcome: err}
+				nodes[hash] = target
+				tree.Nodes = append(tree.Nodes, target)
+			}
+			node.Edges = append(node.Edges, Edge{Move: move, To: target})
+		}
+	}
+
+	tree.evaluate(options.Evaluator)
+	if options.Principal {
+		tree.prune()
+	}
+	return tree, nil
+}
+
+// evaluate values the nodes from the deepest to the root, and marks the principal edges.
+func (t *Tree) evaluate(evaluator eval.Evaluator) {
+	for k := len(t.Nodes) - 1; k >= 0; k-- {
+		node := t.Nodes[k]
+		switch {
+		case node.Outcome != nil:
+			node.Value = outcomeValue(node)
+		case len(node.Edges) == 0:
+			node.Value = evaluator.Evaluate(node.Game)
+		default:
+			node.Value = -search.Mate - 1
+			for _, edge := range node.Edges {
+				if value := edgeValue(node, edge, evaluator); value > node.Value {
+					node.Value = value
+				}
+			}
+			for k, edge := range node.Edges {
+				node.Edges[k].Principal = edgeValue(node, edge, evaluator) == node.Value
+			}
+		}
+	}
+}
+
+// edgeValue returns the value of a move for the mover of node.
+func edgeValue(node *Node, edge Edge, evaluator eval.Evaluator) int {
+	child := edge.To
+	if child.Outcome == nil && child.Ply <= node.Ply {
+		return -evaluator.Evaluate(child.Game)
+	}
+	value := -child.Value
+	// Forced results are one ply further from node than from child.
+	if value > 0 && search.IsMate(value) {
+		value--
+	} else if value < 0 && search.IsMate(value) {
+		value++
+	}
+	return value
+}
+
+// outcomeValue returns the value of a finished game for its mover.
+func outcomeValue(node *Node) int {
+	switch node.Outcome.(type) {
+	case g4.YellowWins:
+		if node.Game.Mover == g4.Yellow {
+			return search.Mate
+		}
+		return -search.Mate
+	case g4.RedWins:
+		if node.Game.Mover == g4.Red {
+			return search.Mate
+		}
+		return -search.Mate
+	}
+	return 0
+}
+
+// prune keeps the principal edges only, and the nodes they reach from the root.
+func (t *Tree) prune() {
+	reached := map[*Node]bool{t.Root: true}
+	nodes := []*Node{t.Root}
+	for k := 0; k < len(nodes); k++ {
+		var edges []Edge
+		for _, edge := range nodes[k].Edges {
+			if !edge.Principal {
+				continue
+			}
+			edges = append(edges, edge)
+			if !reached[edge.To] {
+				reached[edge.To] = true
+				nodes = append(nodes, edge.To)
+			}
+		}
+		nodes[k].Edges = edges
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].Ply < nodes[j].Ply
+	})
+	t.Nodes = nodes
+}
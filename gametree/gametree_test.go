@@ -0,0 +1,120 @@
+package gametree_test
+
+import (
+	"bytes"
+	"g4"
+	"g4/bitsim"
+	"g4/gametree"
+	"g4/internal/g4test"
+	"g4/search"
+	"strings"
+	"testing"
+)
+
+func TestBuildTranspositions(t *testing.T) {
+	start := g4test.Game(t, bitsim.StartingPosition, g4.Yellow)
+	tree, err := gametree.Build(start, gametree.Options{Depth: 1})
+	if err != nil {
+		t.Fatalf("error in Build: %v", err)
+	}
+	// The three tilts of the empty board lead to the same position.
+	if len(tree.Root.Edges) != 11 || len(tree.Nodes) != 10 {
+		t.Errorf("got %d edges and %d nodes but want 11 and 10", len(tree.Root.Edges), len(tree.Nodes))
+	}
+
+	tree, err = gametree.Build(start, gametree.Options{Depth: 3})
+	if err != nil {
+		t.Fatalf("error in Build: %v", err)
+	}
+	seen := make(map[uint64]bool)
+	for k, node := range tree.Nodes {
+		if seen[node.Hash] {
+			t.Errorf("node %d is a duplicate", k)
+		}
+		seen[node.Hash] = true
+		if k > 0 && node.Ply < tree.Nodes[k-1].Ply {
+			t.Errorf("node %d at ply %d comes after ply %d", k, node.Ply, tree.Nodes[k-1].Ply)
+		}
+		for _, edge := range node.Edges {
+			if edge.To.Ply > node.Ply+1 {
+				t.Errorf("edge %v goes from ply %d to ply %d", edge.Move, node.Ply, edge.To.Ply)
+			}
+		}
+	}
+}
+
+func TestBuildPrincipal(t *testing.T) {
+	examples := []struct {
+		board string
+		mover g4.Color
+		depth int
+		moves []string
+		value int
+	}{
+		{"yyy5|rr6|r7|8|8|8|8|8", g4.Yellow, 1, []string{"1"}, search.Mate - 1},
+		{"yyy5|rr6|r7|8|8|8|8|8", g4.Yellow, 2, []string{"1"}, search.Mate - 1},
+		{"yyy5|rrr5|8|8|8|8|8|8", g4.Red, 2, []string{"2"}, search.Mate - 1},
+		{"yy6|r7|r7|8|8|8|8|8", g4.Yellow, 1, nil, 0}, // Whatever the evaluation.
+	}
+	for k, example := range examples {
+		game := g4test.Game(t, example.board, example.mover)
+		tree, err := gametree.Build(game, gametree.Options{Depth: example.depth, Principal: true})
+		if err != nil {
+			t.Fatalf("example %d: error in Build: %v", k, err)
+		}
+		for _, node := range tree.Nodes {
+			for _, edge := range node.Edges {
+				if !edge.Principal {
+					t.Errorf("example %d: edge %v is not principal", k, edge.Move)
+				}
+			}
+		}
+		if example.moves == nil {
+			continue
+		}
+		var moves []string
+		for _, edge := range tree.Root.Edges {
+			moves = append(moves, edge.Move.String())
+		}
+		if strings.Join(moves, " ") != strings.Join(example.moves, " ") || tree.Root.Value != example.value {
+			t.Errorf("example %d: got %v (%s) but want %v (%s)", k, moves,
+				gametree.FormatValue(tree.Root.Value), example.moves, gametree.FormatValue(example.value))
+		}
+	}
+}
+
+func TestBuildTooLarge(t *testing.T) {
+	start := g4test.Game(t, bitsim.StartingPosition, g4.Yellow)
+	if _, err := gametree.Build(start, gametree.Options{Depth: 3, MaxNodes: 50}); err != gametree.ErrorTooLarge {
+		t.Errorf("got %v but want %v", err, gametree.ErrorTooLarge)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	game := g4test.Game(t, "yyy5|rr6|r7|8|8|8|8|8", g4.Yellow)
+	tree, err := gametree.Build(game, gametree.Options{Depth: 1})
+	if err != nil {
+		t.Fatalf("error in Build: %v", err)
+	}
+	var b bytes.Buffer
+	if err := tree.WriteDOT(&b); err != nil {
+		t.Fatalf("error in WriteDOT: %v", err)
+	}
+	dot := b.String()
+	if !strings.HasPrefix(dot, "digraph g4 {\n") || !strings.HasSuffix(dot, "}\n") {
+		t.Errorf("got malformed graph:\n%s", dot)
+	}
+	if got := strings.Count(dot, " -> "); got != len(tree.Root.Edges) {
+		t.Errorf("got %d edges but want %d", got, len(tree.Root.Edges))
+	}
+	for _, want := range []string{
+		`label="yyy5|rr6|r7|8|8|8|8|8\nyellow to move\nwins in 1"`,
+		`label="yyyy4|rr6|r7|8|8|8|8|8\n1-0 (connect-4)"`,
+		`[label="1", style=bold]`,
+		`[label="L"]`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("missing %s in:\n%s", want, dot)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package gametree
+
+import (
+	"errors"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"io"
+	"math/rand"
+	"sort"
+	"text/tabwriter"
+)
+
+// DefaultGameLimit is the default number of plies after which random games are abandoned.
+const DefaultGameLimit = 1000
+
+// Stats measures the game tree along random games.
+type Stats struct {
+	// Games is the number of games played, and Abandoned the number of games
+	// stopped at the limit of plies.
+	Games, Abandoned int
+
+	// YellowWins, RedWins and Draws count the results of the finished games.
+	YellowWins, RedWins, Draws int
+
+	// Repetitions counts the draws by 3-fold repetition, among Draws.
+	Repetitions int
+
+	// Positions is the number of live positions met, and Moves the sum of their legal moves.
+	Positions, Moves int
+
+	// Tilts is the number of tilts tried in these positions, whether legal or not.
+	Tilts int
+
+	// NoOpTilts counts the tilts leaving the board unchanged.
+	NoOpTilts int
+
+	// DoubleConnect4s counts the tilts giving a connect-4 to both players,
+	// which is a draw. TiltWins and TiltLosses count the tilts giving a
+	// connect-4 to the mover only, or to the opponent only.
+	DoubleConnect4s, TiltWins, TiltLosses int
+
+	// Lengths maps numbers of plies to the number of finished games of that length.
+	Lengths map[int]int
+}
+
+// Sample plays games of random moves from start, and measures every position met.
+//
+// Games are drawn when a position appears for the third time, as in g4, and
+// abandoned after limit plies, or DefaultGameLimit if limit is not positive.
+func Sample(r *rand.Rand, start bitsim.Game, games, limit int) Stats {
+	if limit <= 0 {
+		limit = DefaultGameLimit
+	}
+	stats := Stats{Lengths: make(map[int]int)}
+	for k := 0; k < games; k++ {
+		stats.Games++
+		game, err := start, start.Validate()
+		history := make(map[bitsim.Game]int)
+		repeated := false
+		ply := 0
+		for ; err == nil && !repeated && ply < limit; ply++ {
+			stats.measure(game)
+			moves, _ := game.Generate()
+			game, err = game.Apply(moves[r.Intn(len(moves))])
+
+			// NB: positions are compared without the move counters.
+			position := bitsim.Game{Board: game.Board, Mover: game.Mover}
+			history[position]++
+			repeated = history[position] == 3
+		}
+		switch err.(type) {
+		case nil:
+			if !repeated {
+				stats.Abandoned++
+				continue
+			}
+			stats.Draws++
+			stats.Repetitions++
+		case g4.YellowWins:
+			stats.YellowWins++
+		case g4.RedWins:
+			stats.RedWins++
+		default:
+			stats.Draws++
+		}
+		stats.Lengths[ply]++
+	}
+	return stats
+}
+
+// measure adds the statistics of a live position.
+func (s *Stats) measure(g bitsim.Game) {
+	moves, _ := g.Generate()
+	s.Positions++
+	s.Moves += len(moves)
+	for _, direction := range []g4.Direction{g4.LEFT, g4.DOWN, g4.RIGHT} {
+		s.Tilts++
+		child, err := g.Apply(g4.TiltMove(g.Mover, direction))
+		if errors.Is(err, g4.ErrorNoOpTilt{}) || (err == nil && child.Board == g.Board) {
+			s.NoOpTilts++
+			continue
+		}
+		switch err.(type) {
+		case g4.Draw:
+			if child.Board.Count() < 64 {
+				s.DoubleConnect4s++
+			}
+		case g4.YellowWins:
+			if g.Mover == g4.Yellow {
+				s.TiltWins++
+			} else {
+				s.TiltLosses++
+			}
+		case g4.RedWins:
+			if g.Mover == g4.Red {
+				s.TiltWins++
+			} else {
+				s.TiltLosses++
+			}
+		}
+	}
+}
+
+// BranchingFactor returns the average number of legal moves of the positions.
+func (s Stats) BranchingFactor() float64 {
+	return ratio(s.Moves, s.Positions)
+}
+
+// Finished returns the number of games which ended by the rules.
+func (s Stats) Finished() int {
+	return s.Games - s.Abandoned
+}
+
+// MeanLength returns the average number of plies of the finished games.
+func (s Stats) MeanLength() float64 {
+	total := 0
+	for length, count := range s.Lengths {
+		total += length * count
+	}
+	return ratio(total, s.Finished())
+}
+
+// LengthPercentile returns the smallest length such that p percent of the
+// finished games are at most as long.
+func (s Stats) LengthPercentile(p float64) int {
+	lengths := s.sortedLengths()
+	seen := 0
+	for _, length := range lengths {
+		seen += s.Lengths[length]
+		if float64(seen) >= p/100*float64(s.Finished()) {
+			return length
+		}
+	}
+	return 0
+}
+
+func (s Stats) sortedLengths() []int {
+	var lengths []int
+	for length := range s.Lengths {
+		lengths = append(lengths, length)
+	}
+	sort.Ints(lengths)
+	return lengths
+}
+
+// WriteTable writes the statistics as a table, followed by the distribution
+// of the game lengths in buckets of given number of plies.
+func (s Stats) WriteTable(w io.Writer, bucket int) error {
+	if bucket <= 0 {
+		bucket = 1
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "games\t%d\t\n", s.Games)
+	fmt.Fprintf(tw, "abandoned\t%d\t%s\t\n", s.Abandoned, percent(s.Abandoned, s.Games))
+	fmt.Fprintf(tw, "yellow wins\t%d\t%s\t\n", s.YellowWins, percent(s.YellowWins, s.Finished()))
+	fmt.Fprintf(tw, "red wins\t%d\t%s\t\n", s.RedWins, percent(s.RedWins, s.Finished()))
+	fmt.Fprintf(tw, "draws\t%d\t%s\t\n", s.Draws, percent(s.Draws, s.Finished()))
+	fmt.Fprintf(tw, "repetition draws\t%d\t%s\t\n", s.Repetitions, percent(s.Repetitions, s.Finished()))
+	fmt.Fprintf(tw, "positions\t%d\t\n", s.Positions)
+	fmt.Fprintf(tw, "branching factor\t%.2f\t\n", s.BranchingFactor())
+	fmt.Fprintf(tw, "tilts\t%d\t\n", s.Tilts)
+	fmt.Fprintf(tw, "no-op tilts\t%d\t%s\t\n", s.NoOpTilts, percent(s.NoOpTilts, s.Tilts))
+	fmt.Fprintf(tw, "double connect-4 tilts\t%d\t%s\t\n", s.DoubleConnect4s, percent(s.DoubleConnect4s, s.Tilts))
+	fmt.Fprintf(tw, "winning tilts\t%d\t%s\t\n", s.TiltWins, percent(s.TiltWins, s.Tilts))
+	fmt.Fprintf(tw, "losing tilts\t%d\t%s\t\n", s.TiltLosses, percent(s.TiltLosses, s.Tilts))
+	fmt.Fprintf(tw, "mean length\t%.1f\t\n", s.MeanLength())
+	for _, p := range []float64{10, 50, 90} {
+		fmt.Fprintf(tw, "length, %.0fth percentile\t%d\t\n", p, s.LengthPercentile(p))
+	}
+	fmt.Fprintln(tw)
+
+	buckets := make(map[int]int)
+	for length, count := range s.Lengths {
+		buckets[length/bucket] += count
+	}
+	var keys []int
+	for key := range buckets {
+		keys = append(keys, key)
+	}
+	sort.Ints(keys)
+	fmt.Fprintln(tw, "length\tgames\tshare\t")
+	for _, key := range keys {
+		plies := fmt.Sprint(key * bucket)
+		if bucket > 1 {
+			plies = fmt.Sprintf("%d-%d", key*bucket, (key+1)*bucket-1)
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t\n", plies, buckets[key], percent(buckets[key], s.Finished()))
+	}
+	return tw.Flush()
+}
+
+func ratio(a, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
+
+func percent(a, b int) string {
+	return fmt.Sprintf("%.2f%%", 100*ratio(a, b))
+}
@@ -0,0 +1,86 @@
+package gametree_test
+
+import (
+	"bytes"
+	"g4"
+	"g4/bitsim"
+	"g4/gametree"
+	"g4/internal/g4test"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSampleTilts(t *testing.T) {
+	examples := []struct {
+		board                               string
+		mover                               g4.Color
+		rules                               bitsim.Rules
+		moves, noOps, doubles, wins, losses int
+	}{
+		{bitsim.StartingPosition, g4.Yellow, bitsim.Rules{}, 11, 3, 0, 0, 0},
+		{bitsim.StartingPosition, g4.Yellow, bitsim.Rules{ForbidNoOpTilts: true}, 8, 3, 0, 0, 0},
+		{"y7|8|8|8|8|8|8|8", g4.Red, bitsim.Rules{}, 11, 1, 0, 0, 0},
+		{"y7|y7|y7|rr6|y7|r7|yyrr4|ryyyry2", g4.Red, bitsim.Rules{}, 11, 0, 1, 0, 0},
+	}
+	for k, example := range examples {
+		game := g4test.Game(t, example.board, example.mover)
+		game.Rules = example.rules
+		// With a limit of one ply, only the starting position is measured.
+		stats := gametree.Sample(rand.New(rand.NewSource(1)), game, 4, 1)
+		got := []int{stats.Positions, stats.Moves, stats.Tilts, stats.NoOpTilts, stats.DoubleConnect4s, stats.TiltWins, stats.TiltLosses}
+		want := []int{4, 4 * example.moves, 12, 4 * example.noOps, 4 * example.doubles, 4 * example.wins, 4 * example.losses}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("example %d: got %v but want %v", k, got, want)
+		}
+	}
+}
+
+func TestSample(t *testing.T) {
+	start := bitsim.Game{Mover: g4.Yellow}
+	stats := gametree.Sample(rand.New(rand.NewSource(1)), start, 200, 0)
+	if again := gametree.Sample(rand.New(rand.NewSource(1)), start, 200, 0); !reflect.DeepEqual(stats, again) {
+		t.Errorf("same seed gave %+v then %+v", stats, again)
+	}
+
+	if stats.Games != 200 || stats.YellowWins+stats.RedWins+stats.Draws != stats.Finished() {
+		t.Errorf("got %d games and %d+%d+%d results", stats.Games, stats.YellowWins, stats.RedWins, stats.Draws)
+	}
+	if stats.Repetitions == 0 || stats.Repetitions > stats.Draws {
+		t.Errorf("got %d draws by repetition among %d draws", stats.Repetitions, stats.Draws)
+	}
+	total := 0
+	for _, count := range stats.Lengths {
+		total += count
+	}
+	if total != stats.Finished() {
+		t.Errorf("got %d lengths but %d finished games", total, stats.Finished())
+	}
+	if stats.BranchingFactor() <= 8 || stats.BranchingFactor() > 11 {
+		t.Errorf("got branching factor %.2f", stats.BranchingFactor())
+	}
+	p10, p50, p90 := stats.LengthPercentile(10), stats.LengthPercentile(50), stats.LengthPercentile(90)
+	if !(0 < p10 && p10 <= p50 && p50 <= p90) {
+		t.Errorf("got percentiles %d, %d, %d", p10, p50, p90)
+	}
+
+	var b bytes.Buffer
+	if err := stats.WriteTable(&b, 10); err != nil {
+		t.Fatalf("error in WriteTable: %v", err)
+	}
+	for _, want := range []string{"branching factor", "no-op tilts", "double connect-4 tilts", "repetition draws", "0-9"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("missing %s in:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestSampleLimit(t *testing.T) {
+	// Games reaching the limit are abandoned, and left out of the lengths.
+	start := bitsim.Game{Mover: g4.Yellow}
+	stats := gametree.Sample(rand.New(rand.NewSource(1)), start, 10, 3)
+	if stats.Abandoned != 10 || len(stats.Lengths) != 0 {
+		t.Errorf("got %d abandoned games and lengths %v", stats.Abandoned, stats.Lengths)
+	}
+}
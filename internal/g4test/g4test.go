@@ -0,0 +1,30 @@
+// Package g4test provides helpers for the tests of the g4 packages.
+package g4test
+
+import (
+	"g4"
+	"g4/bitsim"
+	"testing"
+)
+
+// Game returns the game of the board in string notation, with the given
+// mover. It stops the test if the board cannot be read.
+func Game(t testing.TB, position string, mover g4.Color) bitsim.Game {
+	t.Helper()
+	board, err := bitsim.FromString(position)
+	if err != nil {
+		t.Fatalf("error in FromString: %v", err)
+	}
+	return bitsim.Game{Board: board, Mover: mover}
+}
+
+// Move parses a move of the given color. It stops the test if the move
+// cannot be read.
+func Move(t testing.TB, s string, color g4.Color) g4.Move {
+	t.Helper()
+	move, err := g4.ParseMove(s, color)
+	if err != nil {
+		t.Fatalf("error in ParseMove: %v", err)
+	}
+	return move
+}
@@ -0,0 +1,162 @@
+package nn
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// magic starts every network file.
+const magic = "G4NN\x01"
+
+const (
+	// maxLayerSize bounds the number of inputs and outputs of layers read from files.
+	maxLayerSize = 1 << 16
+
+	// maxLayers bounds the number of layers of networks read from files.
+	maxLayers = 64
+
+	// maxWeights bounds the number of weights of layers read from files.
+	maxWeights = 1 << 22
+)
+
+// Write writes the network in binary form.
+//
+// The file starts with a magic string, the scale and the number of layers.
+// Each layer follows with its numbers of inputs and outputs, its weights in
+// input-major order and its biases. Counts are unsigned varints, and scale,
+// weights and biases are little-endian float32.
+func (n *Network) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	var buf [binary.MaxVarintLen64]byte
+	writeUvarint := func(x int) {
+		bw.Write(buf[:binary.PutUvarint(buf[:], uint64(x))])
+	}
+	writeFloat := func(x float32) {
+		binary.LittleEndian.PutUint32(buf[:4], math.Float32bits(x))
+		bw.Write(buf[:4])
+	}
+
+	bw.WriteString(magic)
+	writeFloat(n.Scale)
+	writeUvarint(len(n.Layers))
+	for _, layer := range n.Layers {
+		writeUvarint(layer.Inputs)
+		writeUvarint(layer.Outputs)
+		for _, x := range layer.Weights {
+			writeFloat(x)
+		}
+		for _, x := range layer.Biases {
+			writeFloat(x)
+		}
+	}
+	return bw.Flush()
+}
+
+// Read reads a network written by Write, and validates it.
+func Read(r io.Reader) (*Network, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(magic))
+	if _, err := io.ReadFull(br, header); err != nil || string(header) != magic {
+		return nil, errors.New("not a network file")
+	}
+	readUvarint := func() (int, error) {
+		x, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if x > maxLayerSize {
+			return 0, fmt.Errorf("size too large: %d", x)
+		}
+		return int(x), err
+	}
+	readFloats := func(xs []float32) error {
+		data := make([]byte, 4*len(xs))
+		if _, err := io.ReadFull(br, data); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		for k := range xs {
+			xs[k] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*k:]))
+		}
+		return nil
+	}
+
+	n := &Network{}
+	scale := make([]float32, 1)
+	if err := readFloats(scale); err != nil {
+		return nil, err
+	}
+	n.Scale = scale[0]
+	count, err := readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	if count > maxLayers {
+		return nil, fmt.Errorf("too many layers: %d", count)
+	}
+
+	// NB: shapes are checked before allocating anything, since files may be corrupt.
+	inputs := Inputs
+	for k := 0; k < count; k++ {
+		var layer Layer
+		if layer.Inputs, err = readUvarint(); err != nil {
+			return nil, fmt.Errorf("layer %d: %w", k, err)
+		}
+		if layer.Inputs != inputs {
+			return nil, fmt.Errorf("layer %d: got %d inputs but want %d", k, layer.Inputs, inputs)
+		}
+		if layer.Outputs, err = readUvarint(); err != nil {
+			return nil, fmt.Errorf("layer %d: %w", k, err)
+		}
+		if layer.Outputs <= 0 || layer.Inputs*layer.Outputs > maxWeights {
+			return nil, fmt.Errorf("layer %d: invalid number of outputs: %d", k, layer.Outputs)
+		}
+		inputs = layer.Outputs
+		layer.Weights = make([]float32, layer.Inputs*layer.Outputs)
+		layer.Biases = make([]float32, layer.Outputs)
+		if err := readFloats(layer.Weights); err != nil {
+			return nil, fmt.Errorf("layer %d: %w", k, err)
+		}
+		if err := readFloats(layer.Biases); err != nil {
+			return nil, fmt.Errorf("layer %d: %w", k, err)
+		}
+		n.Layers = append(n.Layers, layer)
+	}
+	if _, err := br.ReadByte(); err != io.EOF {
+		return nil, errors.New("trailing data after the layers")
+	}
+	if err := n.Validate(); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// Load reads a network from a file.
+func Load(path string) (*Network, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Save writes the network to a file.
+func (n *Network) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := n.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
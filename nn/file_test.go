@@ -0,0 +1,67 @@
+package nn_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"g4/nn"
+	"os"
+	"testing"
+)
+
+func TestWriteRead(t *testing.T) {
+	data, err := os.ReadFile("testdata/small.g4nn")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	network, err := nn.Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if network.Scale != 100 || len(network.Layers) != 3 {
+		t.Errorf("got scale %v and %d layers", network.Scale, len(network.Layers))
+	}
+	var buf bytes.Buffer
+	if err := network.Write(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("got different bytes when writing the network again")
+	}
+}
+
+func TestReadError(t *testing.T) {
+	data, err := os.ReadFile("testdata/small.g4nn")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// A single layer of 128 inputs: the file is well-formed but the network is not.
+	invalid := &nn.Network{Layers: []nn.Layer{{Inputs: 128, Outputs: 1, Weights: make([]float32, 128), Biases: make([]float32, 1)}}}
+	var buf bytes.Buffer
+	invalid.Write(&buf)
+
+	// Headers announcing huge layers, with no weights behind them.
+	header := func(counts ...uint64) []byte {
+		data := []byte("G4NN\x01\x00\x00\x80\x3f")
+		var buf [binary.MaxVarintLen64]byte
+		for _, x := range counts {
+			data = append(data, buf[:binary.PutUvarint(buf[:], x)]...)
+		}
+		return data
+	}
+
+	examples := [][]byte{
+		header(1<<40, nn.Inputs, 1),
+		header(1, nn.Inputs, 1<<16),
+		header(2, 1<<16, 1<<16),
+		nil,
+		[]byte("G4BK\x01"),
+		data[:len(data)-1],
+		append(append([]byte{}, data...), 0),
+		buf.Bytes(),
+	}
+	for k, ex := range examples {
+		if _, err := nn.Read(bytes.NewReader(ex)); err == nil {
+			t.Errorf("example %d: expected error", k)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Package nn implements a small neural network evaluator for g4.
+//
+// The network is a multilayer perceptron: fully connected layers with ReLU
+// activations, except for the last one which is linear and has a single
+// output. It runs on the CPU in pure Go, and is trained elsewhere, for
+// instance on data from package selfplay.
+package nn
+
+import (
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"math"
+	"math/bits"
+	"sync"
+)
+
+// Inputs is the number of inputs of a network.
+//
+// Input i is 1 if square i holds a yellow token and input 64+i is 1 if it
+// holds a red token, squares being numbered as in bitsim.Board.Tokens. Input
+// 128 is 1 if yellow is to move. All other inputs are 0.
+const Inputs = 129
+
+// Layer is a fully connected layer.
+type Layer struct {
+	Inputs, Outputs int
+
+	// Weights holds Inputs*Outputs weights, input-major: the weight from input
+	// i to output o is Weights[i*Outputs+o].
+	Weights []float32
+
+	// Biases holds Outputs biases.
+	Biases []float32
+}
+
+// Network is a multilayer perceptron evaluating positions for the player to move.
+//
+// It is safe for concurrent use.
+type Network struct {
+	Layers []Layer
+
+	// Scale converts the output of the network to evaluation units.
+	Scale float32
+
+	// buffers recycles the activations of evaluations.
+	buffers sync.Pool
+}
+
+// Validate checks that the layers fit together, from Inputs inputs to a single output.
+func (n *Network) Validate() error {
+	if len(n.Layers) == 0 {
+		return fmt.Errorf("network has no layers")
+	}
+	inputs := Inputs
+	for k, layer := range n.Layers {
+		if layer.Inputs != inputs {
+			return fmt.Errorf("layer %d: got %d inputs but want %d", k, layer.Inputs, inputs)
+		}
+		if layer.Outputs <= 0 {
+			return fmt.Errorf("layer %d: invalid number of outputs: %d", k, layer.Outputs)
+		}
+		if len(layer.Weights) != layer.Inputs*layer.Outputs || len(layer.Biases) != layer.Outputs {
+			return fmt.Errorf("layer %d: invalid number of parameters", k)
+		}
+		inputs = layer.Outputs
+	}
+	if inputs != 1 {
+		return fmt.Errorf("network has %d outputs but want 1", inputs)
+	}
+	return nil
+}
+
+// Forward returns the raw output of the network for a position.
+func (n *Network) Forward(g bitsim.Game) float32 {
+	size := 0
+	for _, layer := range n.Layers {
+		size += layer.Outputs
+	}
+	buffer, _ := n.buffers.Get().(*[]float32)
+	if buffer == nil || len(*buffer) < size {
+		buffer = new([]float32)
+		*buffer = make([]float32, size)
+	}
+	defer n.buffers.Put(buffer)
+
+	// The first layer only sums the weights of the active inputs.
+	first := n.Layers[0]
+	activations := (*buffer)[:first.Outputs]
+	copy(activations, first.Biases)
+	addInputs(activations, first, g.Board.Tokens(g4.Yellow), 0)
+	addInputs(activations, first, g.Board.Tokens(g4.Red), 64)
+	if g.Mover == g4.Yellow {
+		addRow(activations, first, 128)
+	}
+
+	offset := first.Outputs
+	for _, layer := range n.Layers[1:] {
+		inputs := activations
+		activations = (*buffer)[offset : offset+layer.Outputs]
+		copy(activations, layer.Biases)
+		for i, x := range inputs {
+			// ReLU: inactive units contribute nothing.
+			if x <= 0 {
+				continue
+			}
+			row := layer.Weights[i*layer.Outputs : (i+1)*layer.Outputs]
+			for o, w := range row {
+				activations[o] += x * w
+			}
+		}
+		offset += layer.Outputs
+	}
+	return activations[0]
+}
+
+// Evaluate returns the output of the network in evaluation units.
+func (n *Network) Evaluate(g bitsim.Game) int {
+	return int(math.Round(float64(n.Forward(g) * n.Scale)))
+}
+
+// addInputs adds the rows of the inputs set in squares, numbered from offset.
+func addInputs(activations []float32, layer Layer, squares uint64, offset int) {
+	for ; squares != 0; squares &= squares - 1 {
+		addRow(activations, layer, offset+bits.TrailingZeros64(squares))
+	}
+}
+
+// addRow adds the weights of input i to the activations.
+func addRow(activations []float32, layer Layer, i int) {
+	row := layer.Weights[i*layer.Outputs : (i+1)*layer.Outputs]
+	for o, w := range row {
+		activations[o] += w
+	}
+}
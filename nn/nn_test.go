@@ -0,0 +1,127 @@
+package nn_test
+
+import (
+	"encoding/json"
+	"g4"
+	"g4/bitsim"
+	"g4/nn"
+	"math"
+	"os"
+	"sync"
+	"testing"
+)
+
+// The fixtures hold the outputs of testdata/small.g4nn, a network with layers
+// of 16 and 8 units, computed by an independent reference implementation.
+type fixture struct {
+	Board  string  `json:"board"`
+	Mover  string  `json:"mover"`
+	Output float64 `json:"output"`
+	Score  int     `json:"score"`
+}
+
+func loadFixtures(t *testing.T) (*nn.Network, []fixture) {
+	t.Helper()
+	network, err := nn.Load("testdata/small.g4nn")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile("testdata/fixtures.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var fixtures []fixture
+	if err := json.Unmarshal(data, &fixtures); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return network, fixtures
+}
+
+func (f fixture) game(t *testing.T) bitsim.Game {
+	board, err := bitsim.FromString(f.Board)
+	if err != nil {
+		t.Fatalf("error in FromString: %v", err)
+	}
+	g := bitsim.Game{Board: board, Mover: g4.Yellow}
+	if f.Mover == "red" {
+		g.Mover = g4.Red
+	}
+	return g
+}
+
+func TestReference(t *testing.T) {
+	network, fixtures := loadFixtures(t)
+	for k, f := range fixtures {
+		g := f.game(t)
+		if got := network.Forward(g); math.Abs(float64(got)-f.Output) > 1e-5 {
+			t.Errorf("example %d: got output %v but want %v", k, got, f.Output)
+		}
+		if got := network.Evaluate(g); got != f.Score {
+			t.Errorf("example %d: got score %d but want %d", k, got, f.Score)
+		}
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	network, fixtures := loadFixtures(t)
+	var wg sync.WaitGroup
+	errors := make(chan string, len(fixtures))
+	for k := range fixtures {
+		wg.Add(1)
+		go func(f fixture, g bitsim.Game) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				if got := network.Evaluate(g); got != f.Score {
+					errors <- f.Board
+					return
+				}
+			}
+		}(fixtures[k], fixtures[k].game(t))
+	}
+	wg.Wait()
+	close(errors)
+	for board := range errors {
+		t.Errorf("%s: wrong score under concurrent use", board)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	layer := func(inputs, outputs int) nn.Layer {
+		return nn.Layer{
+			Inputs:  inputs,
+			Outputs: outputs,
+			Weights: make([]float32, inputs*outputs),
+			Biases:  make([]float32, outputs),
+		}
+	}
+	examples := []struct {
+		in    []nn.Layer
+		valid bool
+	}{
+		{in: []nn.Layer{layer(nn.Inputs, 1)}, valid: true},
+		{in: []nn.Layer{layer(nn.Inputs, 4), layer(4, 1)}, valid: true},
+		{in: nil, valid: false},
+		{in: []nn.Layer{layer(128, 1)}, valid: false},
+		{in: []nn.Layer{layer(nn.Inputs, 4), layer(3, 1)}, valid: false},
+		{in: []nn.Layer{layer(nn.Inputs, 4)}, valid: false},
+		{in: []nn.Layer{{Inputs: nn.Inputs, Outputs: 1, Weights: make([]float32, 3), Biases: make([]float32, 1)}}, valid: false},
+	}
+	for k, ex := range examples {
+		err := (&nn.Network{Layers: ex.in}).Validate()
+		if (err == nil) != ex.valid {
+			t.Errorf("example %d: got error %v but want valid=%v", k, err, ex.valid)
+		}
+	}
+}
+
+func BenchmarkEvaluate(b *testing.B) {
+	network, err := nn.Load("testdata/small.g4nn")
+	if err != nil {
+		b.Fatalf("unexpected error: %v", err)
+	}
+	board, _ := bitsim.FromString("ryryryry|yryryryr|8|yy6|rr6|8|yryr4|r7")
+	g := bitsim.Game{Board: board, Mover: g4.Red}
+	for i := 0; i < b.N; i++ {
+		network.Evaluate(g)
+	}
+}
@@ -0,0 +1,44 @@
+package g4
+
+import "fmt"
+
+// String returns the text notation of the move.
+//
+// Token moves are written as the 1-based column number ("1" to "8") and tilt
+// moves as the initial of their direction ("L", "D" or "R"). The color is not
+// part of the notation, it is always implied by the player with the move.
+func (m Move) String() string {
+	switch m.Type {
+	case Token:
+		if m.Column >= 0 && m.Column < 8 {
+			return string(rune('1' + m.Column))
+		}
+	case Tilt:
+		switch m.Direction {
+		case LEFT:
+			return "L"
+		case DOWN:
+			return "D"
+		case RIGHT:
+			return "R"
+		}
+	}
+	return "?"
+}
+
+// ParseMove reads a move in text notation and attributes it to `color`.
+//
+// See Move.String for the description of the notation.
+func ParseMove(s string, color Color) (Move, error) {
+	switch s {
+	case "1", "2", "3", "4", "5", "6", "7", "8":
+		return TokenMove(color, int(s[0]-'1')), nil
+	case "L":
+		return TiltMove(color, LEFT), nil
+	case "D":
+		return TiltMove(color, DOWN), nil
+	case "R":
+		return TiltMove(color, RIGHT), nil
+	}
+	return Move{}, fmt.Errorf("invalid move notation '%s'", s)
+}
@@ -0,0 +1,45 @@
+package g4_test
+
+import (
+	"g4"
+	"testing"
+)
+
+func TestMoveString(t *testing.T) {
+	examples := []struct {
+		in  g4.Move
+		out string
+	}{
+		{in: g4.TokenMove(g4.Yellow, 0), out: "1"},
+		{in: g4.TokenMove(g4.Red, 7), out: "8"},
+		{in: g4.TiltMove(g4.Yellow, g4.LEFT), out: "L"},
+		{in: g4.TiltMove(g4.Red, g4.DOWN), out: "D"},
+		{in: g4.TiltMove(g4.Yellow, g4.RIGHT), out: "R"},
+		{in: g4.TokenMove(g4.Yellow, 8), out: "?"},
+		{in: g4.TiltMove(g4.Yellow, 8), out: "?"},
+	}
+	for k, ex := range examples {
+		if got := ex.in.String(); got != ex.out {
+			t.Errorf("example %d: got '%s' but want '%s'", k, got, ex.out)
+		}
+	}
+}
+
+func TestParseMove(t *testing.T) {
+	for _, color := range []g4.Color{g4.Yellow, g4.Red} {
+		for _, s := range []string{"1", "2", "3", "4", "5", "6", "7", "8", "L", "D", "R"} {
+			move, err := g4.ParseMove(s, color)
+			if err != nil {
+				t.Errorf("'%s': unexpected error %v", s, err)
+			}
+			if move.Color != color || move.String() != s {
+				t.Errorf("'%s': got %v which does not round-trip", s, move)
+			}
+		}
+	}
+	for _, s := range []string{"", "0", "9", "l", "LD", "12"} {
+		if move, err := g4.ParseMove(s, g4.Yellow); err == nil {
+			t.Errorf("'%s': got (%v, %v) but expected error", s, move, err)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package protocol
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"g4"
+	"g4/search"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHandshakeTimeout is the default time given to an engine to start.
+	DefaultHandshakeTimeout = 5 * time.Second
+
+	// DefaultTimeoutMargin is the time an engine may exceed its thinking time
+	// before its search times out.
+	DefaultTimeoutMargin = time.Second
+
+	// stopGrace is the time given to an engine to answer after being told to
+	// stop, or to exit after being told to quit, before it is killed.
+	stopGrace = time.Second
+
+	// stderrLines is the number of lines of the error output of the engine kept for crash reports.
+	stderrLines = 5
+)
+
+var (
+	// ErrorTimeout is returned when an engine does not answer in time.
+	ErrorTimeout = errors.New("engine timed out")
+
+	// ErrorCrashed is returned when an engine exits unexpectedly.
+	ErrorCrashed = errors.New("engine crashed")
+
+	// ErrorNoMove is returned when an engine has no move to play.
+	ErrorNoMove = errors.New("engine has no move")
+)
+
+// Client drives an engine speaking the protocol in a subprocess.
+//
+// Its methods must be called from a single goroutine.
+type Client struct {
+	// Name and Author identify the engine, as it introduced itself.
+	Name, Author string
+
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	lines  chan string
+	stderr *tail
+
+	// exited is closed once the process has exited, with exitErr set.
+	exited  chan struct{}
+	exitErr error
+}
+
+// Start runs an engine and waits for it to introduce itself.
+//
+// The engine is killed if it does not answer within timeout.
+func Start(command string, args []string, timeout time.Duration) (*Client, error) {
+	c := &Client{
+		cmd:    exec.Command(command, args...),
+		lines:  make(chan string, 64),
+		stderr: &tail{max: stderrLines},
+		exited: make(chan struct{}),
+	}
+	c.cmd.Stderr = c.stderr
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if c.stdin, err = c.cmd.StdinPipe(); err != nil {
+		return nil, err
+	}
+	if err := c.cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			c.lines <- scanner.Text()
+		}
+		c.exitErr = c.cmd.Wait()
+		close(c.exited)
+		close(c.lines)
+	}()
+
+	if err := c.handshake(timeout); err != nil {
+		c.kill()
+		return nil, err
+	}
+	return c, nil
+}
+
+// handshake introduces the client to the engine and waits until it is ready.
+func (c *Client) handshake(timeout time.Duration) error {
+	deadline := time.After(timeout)
+	if err := c.send("g4"); err != nil {
+		return err
+	}
+	for {
+		select {
+		case line, ok := <-c.lines:
+			if !ok {
+				return c.crashError()
+			}
+			switch {
+			case strings.HasPrefix(line, "id name "):
+				c.Name = strings.TrimPrefix(line, "id name ")
+			case strings.HasPrefix(line, "id author "):
+				c.Author = strings.TrimPrefix(line, "id author ")
+			case line == "g4ok":
+				return nil
+			}
+		case <-deadline:
+			return fmt.Errorf("%w: no answer to the handshake", ErrorTimeout)
+		}
+	}
+}
+
+// SetOption sets an option of the engine.
+func (c *Client) SetOption(name, value string) error {
+	return c.send(fmt.Sprintf("setoption name %s value %s", name, value))
+}
+
+// NewGame tells the engine that a new game starts.
+func (c *Client) NewGame() error {
+	return c.send("newgame")
+}
+
+// Think asks the engine for its move in a position, within given limits.
+//
+// Progress reports of the engine are passed to onInfo, if not nil. If the
+// engine has not answered after timeout (zero means no timeout), or when ctx
+// is done, it is told to stop, and killed if it still does not answer.
+// After a timeout, the move given in answer to stop is accepted.
+func (c *Client) Think(ctx context.Context, p Position, limits Go, timeout time.Duration, onInfo func(search.Info)) (g4.Move, error) {
+	game, err := p.Game()
+	if err != nil {
+		return g4.Move{}, err
+	}
+	moves, err := game.Generate()
+	if err != nil {
+		return g4.Move{}, err
+	}
+
+	// Forget what the engine said between two searches.
+	for drained := false; !drained; {
+		select {
+		case _, ok := <-c.lines:
+			if !ok {
+				return g4.Move{}, c.crashError()
+			}
+		default:
+			drained = true
+		}
+	}
+
+	if err := c.send(p.String()); err != nil {
+		return g4.Move{}, err
+	}
+	if err := c.send(limits.String()); err != nil {
+		return g4.Move{}, err
+	}
+
+	var deadline, kill <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+	done := ctx.Done()
+	for {
+		select {
+		case line, ok := <-c.lines:
+			if !ok {
+				return g4.Move{}, c.crashError()
+			}
+			words := strings.Fields(line)
+			if len(words) == 0 {
+				continue
+			}
+			switch words[0] {
+			case "info":
+				if info, err := ParseInfo(words[1:], game.Mover); err == nil && onInfo != nil {
+					onInfo(info)
+				}
+			case "bestmove":
+				if err := ctx.Err(); err != nil {
+					return g4.Move{}, err
+				}
+				if len(words) < 2 || words[1] == "none" {
+					return g4.Move{}, ErrorNoMove
+				}
+				move, err := g4.ParseMove(words[1], game.Mover)
+				if err != nil {
+					return g4.Move{}, fmt.Errorf("engine answered '%s': %w", line, err)
+				}
+				for _, legal := range moves {
+					if move == legal {
+						return move, nil
+					}
+				}
+				return g4.Move{}, fmt.Errorf("engine played illegal move %v: %w", move, g4.ErrorInvalidMove{})
+			}
+		case <-deadline:
+			deadline = nil
+			c.send("stop")
+			kill = time.After(stopGrace)
+		case <-done:
+			done = nil
+			c.send("stop")
+			kill = time.After(stopGrace)
+		case <-kill:
+			c.kill()
+			if err := ctx.Err(); err != nil {
+				return g4.Move{}, err
+			}
+			return g4.Move{}, fmt.Errorf("%w: no move after %v", ErrorTimeout, timeout)
+		}
+	}
+}
+
+// Close tells the engine to quit, and kills it if it does not.
+func (c *Client) Close() error {
+	c.send("quit")
+	c.stdin.Close()
+	if !c.waitExit(stopGrace) {
+		c.kill()
+	}
+	return nil
+}
+
+// waitExit discards the output of the engine until it exits, and reports
+// whether it did within timeout.
+func (c *Client) waitExit(timeout time.Duration) bool {
+	deadline := time.After(timeout)
+	for {
+		select {
+		case _, ok := <-c.lines:
+			if !ok {
+				return true
+			}
+		case <-deadline:
+			return false
+		}
+	}
+}
+
+// send writes a command to the engine.
+func (c *Client) send(line string) error {
+	if _, err := io.WriteString(c.stdin, line+"\n"); err != nil {
+		select {
+		case <-c.exited:
+			return c.crashError()
+		case <-time.After(stopGrace):
+			return fmt.Errorf("%w: %v", ErrorCrashed, err)
+		}
+	}
+	return nil
+}
+
+// kill kills the engine and waits for the process to exit.
+func (c *Client) kill() {
+	c.cmd.Process.Kill()
+	for range c.lines {
+	}
+}
+
+// crashError describes the exit of the engine, once it has exited.
+func (c *Client) crashError() error {
+	<-c.exited
+	status := "exited"
+	if c.exitErr != nil {
+		status = c.exitErr.Error()
+	}
+	if output := c.stderr.String(); output != "" {
+		return fmt.Errorf("%w (%s): %s", ErrorCrashed, status, output)
+	}
+	return fmt.Errorf("%w (%s)", ErrorCrashed, status)
+}
+
+// tail is a writer keeping the last lines written to it.
+type tail struct {
+	mu      sync.Mutex
+	max     int
+	lines   []string
+	partial string
+}
+
+func (t *tail) Write(data []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	parts := strings.Split(t.partial+string(data), "\n")
+	t.partial = parts[len(parts)-1]
+	t.lines = append(t.lines, parts[:len(parts)-1]...)
+	if len(t.lines) > t.max {
+		t.lines = t.lines[len(t.lines)-t.max:]
+	}
+	return len(data), nil
+}
+
+// String returns the last lines, joined by " / ".
+func (t *tail) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lines := t.lines
+	if t.partial != "" {
+		lines = append(append([]string{}, lines...), t.partial)
+	}
+	return strings.Join(lines, " / ")
+}
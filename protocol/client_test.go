@@ -0,0 +1,129 @@
+package protocol_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"g4"
+	"g4/protocol"
+	"g4/search"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMain lets the test binary act as an engine, selected by G4_TEST_ENGINE.
+func TestMain(m *testing.M) {
+	mode := os.Getenv("G4_TEST_ENGINE")
+	if mode == "" {
+		os.Exit(m.Run())
+	}
+	if mode == "server" {
+		protocol.NewServer(search.Options{TableSize: 1 << 14}).Serve(os.Stdin, os.Stdout)
+		os.Exit(0)
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		switch command := strings.Fields(scanner.Text() + " x")[0]; {
+		case mode == "silent":
+		case command == "g4":
+			fmt.Println("id name " + mode)
+			fmt.Println("g4ok")
+		case command == "go" && mode == "crash":
+			fmt.Fprintln(os.Stderr, "something went wrong")
+			os.Exit(3)
+		case command == "go" && mode == "illegal":
+			fmt.Println("bestmove 1")
+		}
+	}
+	os.Exit(0)
+}
+
+func startEngine(t *testing.T, mode string, timeout time.Duration) (*protocol.Client, error) {
+	t.Setenv("G4_TEST_ENGINE", mode)
+	return protocol.Start(os.Args[0], []string{"-test.run=^$"}, timeout)
+}
+
+func TestClient(t *testing.T) {
+	c, err := startEngine(t, "server", protocol.DefaultHandshakeTimeout)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer c.Close()
+	if c.Name != "g4" || c.Author == "" {
+		t.Errorf("got name '%s' and author '%s'", c.Name, c.Author)
+	}
+
+	p, _ := protocol.ParsePosition(strings.Fields("board yyy5|rr6|r7|8|8|8|8|8 yellow"))
+	var infos []search.Info
+	move, err := c.Think(context.Background(), p, protocol.Go{Depth: 3}, time.Second, func(info search.Info) {
+		infos = append(infos, info)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if move != g4.TokenMove(g4.Yellow, 0) {
+		t.Errorf("got move %v but want 1", move)
+	}
+	if len(infos) == 0 || !search.IsMate(infos[len(infos)-1].Score) {
+		t.Errorf("got infos %+v", infos)
+	}
+
+	p, _ = protocol.ParsePosition(strings.Fields("board yyyy4|8|8|8|8|8|8|8 red"))
+	if _, err := c.Think(context.Background(), p, protocol.Go{Depth: 3}, time.Second, nil); err == nil {
+		t.Errorf("expected error for a finished game")
+	}
+
+	// An infinite search is stopped at the timeout, and its move accepted.
+	start := time.Now()
+	p, _ = protocol.ParsePosition([]string{"startpos"})
+	if _, err := c.Think(context.Background(), p, protocol.Go{Infinite: true}, 100*time.Millisecond, nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("stopping took %v", elapsed)
+	}
+
+	// Cancelling the context stops the search too.
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := c.Think(ctx, p, protocol.Go{Infinite: true}, 0, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v but want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	if _, err := startEngine(t, "silent", 100*time.Millisecond); !errors.Is(err, protocol.ErrorTimeout) {
+		t.Errorf("silent engine: got error %v", err)
+	}
+	if _, err := protocol.Start("/nonexistent/engine", nil, time.Second); err == nil {
+		t.Errorf("expected error for a missing engine")
+	}
+
+	p, _ := protocol.ParsePosition(strings.Fields("startpos moves 1 1 1 1 1 1 1 1"))
+	examples := []struct {
+		mode string
+		want error
+	}{
+		{mode: "crash", want: protocol.ErrorCrashed},
+		{mode: "illegal", want: g4.ErrorInvalidMove{}},
+		{mode: "stubborn", want: protocol.ErrorTimeout},
+	}
+	for k, ex := range examples {
+		c, err := startEngine(t, ex.mode, protocol.DefaultHandshakeTimeout)
+		if err != nil {
+			t.Errorf("example %d: unexpected error: %v", k, err)
+			continue
+		}
+		_, err = c.Think(context.Background(), p, protocol.Go{MoveTime: 10 * time.Millisecond}, 50*time.Millisecond, nil)
+		if !errors.Is(err, ex.want) {
+			t.Errorf("example %d: got error %v but want %v", k, err, ex.want)
+		}
+		if ex.mode == "crash" && !strings.Contains(err.Error(), "something went wrong") {
+			t.Errorf("example %d: error output missing from '%v'", k, err)
+		}
+		c.Close()
+	}
+}
@@ -0,0 +1,362 @@
+// Package protocol implements a text protocol between g4 engines and their clients.
+//
+// The protocol is modelled on UCI. The client sends commands to the engine on
+// its standard input, one per line, and the engine answers on its standard
+// output:
+//
+//	g4                      the engine answers "id name ...", "id author ...",
+//	                        "option name ..." lines, then "g4ok"
+//	isready                 the engine answers "readyok"
+//	setoption name N value V
+//	newgame                 forget previous searches
+//	position startpos [rules R] [moves M...]
+//	position board B yellow|red [rules R] [moves M...]
+//	go [depth N] [movetime MS] [ytime MS] [rtime MS] [yinc MS] [rinc MS] [movestogo N] [infinite]
+//	stop                    stop searching and answer at once
+//	quit
+//
+// Boards use the notation of bitsim.FromString, rules the variant names of
+// bitsim.ParseRules, and moves the notation of g4.ParseMove ("1" to "8" for
+// tokens, "L", "D" and "R" for tilts). The moves of the position command are
+// played from the board in order.
+//
+// While searching, the engine reports its progress with info lines, and ends
+// with the best move:
+//
+//	info depth D score cp S nodes N time MS nps N pv M...
+//	info depth D score mate P nodes N time MS nps N pv M...
+//	info book pv M
+//	bestmove M
+//
+// Scores are given for the mover. Mate scores count plies, and are negative
+// when the mover loses. Book moves are reported with "book". When the game is
+// over, the engine answers "bestmove none". Errors and other messages are
+// reported with "info string ..." lines.
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"g4/search"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Position is the position given to the engine: a starting game and the moves played since.
+type Position struct {
+	Start bitsim.Game
+	Moves []g4.Move
+}
+
+// String returns the position command describing p.
+func (p Position) String() string {
+	words := []string{"position"}
+	if p.Start.Board == (bitsim.Board{}) && p.Start.Mover == g4.Yellow {
+		words = append(words, "startpos")
+	} else {
+		words = append(words, "board", p.Start.Board.String(), colorName(p.Start.Mover))
+	}
+	if p.Start.Rules != (bitsim.Rules{}) {
+		words = append(words, "rules", p.Start.Rules.String())
+	}
+	if len(p.Moves) > 0 {
+		words = append(words, "moves")
+		for _, move := range p.Moves {
+			words = append(words, move.String())
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// ParsePosition reads the arguments of a position command.
+func ParsePosition(args []string) (Position, error) {
+	var p Position
+	switch {
+	case len(args) >= 1 && args[0] == "startpos":
+		p.Start.Mover = g4.Yellow
+		args = args[1:]
+	case len(args) >= 3 && args[0] == "board":
+		board, err := bitsim.FromString(args[1])
+		if err != nil {
+			return p, err
+		}
+		mover, err := parseColor(args[2])
+		if err != nil {
+			return p, err
+		}
+		p.Start.Board, p.Start.Mover = board, mover
+		args = args[3:]
+	default:
+		return p, errors.New("expected 'startpos' or 'board <board> <mover>'")
+	}
+
+	if len(args) >= 2 && args[0] == "rules" {
+		rules, err := bitsim.ParseRules(args[1])
+		if err != nil {
+			return p, err
+		}
+		p.Start.Rules = rules
+		args = args[2:]
+	}
+
+	if len(args) == 0 {
+		return p, nil
+	}
+	if args[0] != "moves" {
+		return p, fmt.Errorf("unexpected '%s'", args[0])
+	}
+	color := p.Start.Mover
+	for _, word := range args[1:] {
+		move, err := g4.ParseMove(word, color)
+		if err != nil {
+			return p, err
+		}
+		p.Moves = append(p.Moves, move)
+		color = color.Opponent()
+	}
+	return p, nil
+}
+
+// Game plays the moves from the starting game and returns the resulting game.
+//
+// It fails if the starting position cannot be reached in a game, on illegal
+// moves, and on moves played after the end of the game.
+func (p Position) Game() (bitsim.Game, error) {
+	game := p.Start
+	if err := game.Board.IsReachable(game.Mover); err != nil {
+		return game, err
+	}
+	for k, move := range p.Moves {
+		if err := game.Validate(); err != nil {
+			return game, fmt.Errorf("move %d: game is already over: %w", k+1, err)
+		}
+		var err error
+		game, err = game.Apply(move)
+		if errors.Is(err, g4.ErrorInvalidMove{}) {
+			return game, fmt.Errorf("move %d: %w", k+1, err)
+		}
+	}
+	return game, nil
+}
+
+// Go holds the limits of a go command.
+type Go struct {
+	Depth               int
+	MoveTime            time.Duration
+	YellowTime, RedTime time.Duration
+	YellowInc, RedInc   time.Duration
+	MovesToGo           int
+	Infinite            bool
+}
+
+// String returns the go command describing c.
+func (c Go) String() string {
+	words := []string{"go"}
+	addInt := func(name string, x int) {
+		if x > 0 {
+			words = append(words, name, strconv.Itoa(x))
+		}
+	}
+	addDuration := func(name string, d time.Duration) {
+		addInt(name, int(d/time.Millisecond))
+	}
+	addInt("depth", c.Depth)
+	addDuration("movetime", c.MoveTime)
+	addDuration("ytime", c.YellowTime)
+	addDuration("rtime", c.RedTime)
+	addDuration("yinc", c.YellowInc)
+	addDuration("rinc", c.RedInc)
+	addInt("movestogo", c.MovesToGo)
+	if c.Infinite {
+		words = append(words, "infinite")
+	}
+	return strings.Join(words, " ")
+}
+
+// ParseGo reads the arguments of a go command.
+func ParseGo(args []string) (Go, error) {
+	var c Go
+	for k := 0; k < len(args); k++ {
+		if args[k] == "infinite" {
+			c.Infinite = true
+			continue
+		}
+		if k+1 >= len(args) {
+			return c, fmt.Errorf("missing value for '%s'", args[k])
+		}
+		value, err := strconv.Atoi(args[k+1])
+		if err != nil || value < 0 {
+			return c, fmt.Errorf("invalid value for '%s': %s", args[k], args[k+1])
+		}
+		d := time.Duration(value) * time.Millisecond
+		switch args[k] {
+		case "depth":
+			c.Depth = value
+		case "movetime":
+			c.MoveTime = d
+		case "ytime":
+			c.YellowTime = d
+		case "rtime":
+			c.RedTime = d
+		case "yinc":
+			c.YellowInc = d
+		case "rinc":
+			c.RedInc = d
+		case "movestogo":
+			c.MovesToGo = value
+		default:
+			return c, fmt.Errorf("unknown limit '%s'", args[k])
+		}
+		k++
+	}
+	return c, nil
+}
+
+// Clock returns the limits of c for the engine playing given color.
+func (c Go) Clock(color g4.Color) search.Clock {
+	if c.Infinite {
+		return search.Clock{}
+	}
+	clock := search.Clock{MoveTime: c.MoveTime, Depth: c.Depth, MovesToGo: c.MovesToGo}
+	if color == g4.Yellow {
+		clock.Remaining, clock.Increment = c.YellowTime, c.YellowInc
+	} else {
+		clock.Remaining, clock.Increment = c.RedTime, c.RedInc
+	}
+	return clock
+}
+
+// FormatInfo returns the info line reporting the progress of a search.
+func FormatInfo(info search.Info) string {
+	words := []string{"info"}
+	if info.Book {
+		words = append(words, "book")
+	} else {
+		words = append(words,
+			"depth", strconv.Itoa(info.Depth),
+			"score", formatScore(info.Score),
+			"nodes", strconv.FormatInt(info.Nodes, 10),
+			"time", strconv.FormatInt(int64(info.Elapsed/time.Millisecond), 10),
+		)
+		if info.Elapsed > 0 {
+			nps := float64(info.Nodes) / info.Elapsed.Seconds()
+			words = append(words, "nps", strconv.FormatInt(int64(nps), 10))
+		}
+	}
+	if len(info.PV) > 0 {
+		words = append(words, "pv")
+		for _, move := range info.PV {
+			words = append(words, move.String())
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// ParseInfo reads an info line, given without its leading "info".
+//
+// Moves of the principal variation are attributed to the players starting
+// with mover. The first one is the move of the result. Unknown fields are
+// ignored, but "info string" lines are rejected.
+func ParseInfo(args []string, mover g4.Color) (search.Info, error) {
+	var info search.Info
+	for k := 0; k < len(args); k++ {
+		field := args[k]
+		switch field {
+		case "string":
+			return info, errors.New("info string line")
+		case "book":
+			info.Book = true
+			continue
+		case "pv":
+			color := mover
+			for _, word := range args[k+1:] {
+				move, err := g4.ParseMove(word, color)
+				if err != nil {
+					return info, err
+				}
+				info.PV = append(info.PV, move)
+				color = color.Opponent()
+			}
+			if len(info.PV) > 0 {
+				info.Move = info.PV[0]
+			}
+			return info, nil
+		case "score":
+			if k+2 >= len(args) {
+				return info, errors.New("missing score")
+			}
+			score, err := parseScore(args[k+1], args[k+2])
+			if err != nil {
+				return info, err
+			}
+			info.Score = score
+			k += 2
+			continue
+		}
+		if k+1 >= len(args) {
+			return info, fmt.Errorf("missing value for '%s'", field)
+		}
+		value, err := strconv.ParseInt(args[k+1], 10, 64)
+		if err != nil {
+			return info, fmt.Errorf("invalid value for '%s': %s", field, args[k+1])
+		}
+		switch field {
+		case "depth":
+			info.Depth = int(value)
+		case "nodes":
+			info.Nodes = value
+		case "time":
+			info.Elapsed = time.Duration(value) * time.Millisecond
+		}
+		k++
+	}
+	return info, nil
+}
+
+// formatScore returns the score in the form "cp S" or "mate P".
+func formatScore(score int) string {
+	switch {
+	case search.IsMate(score) && score > 0:
+		return "mate " + strconv.Itoa(search.Mate-score)
+	case search.IsMate(score):
+		return "mate " + strconv.Itoa(-search.Mate-score)
+	}
+	return "cp " + strconv.Itoa(score)
+}
+
+func parseScore(kind, value string) (int, error) {
+	x, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid score: %s", value)
+	}
+	switch kind {
+	case "cp":
+		return x, nil
+	case "mate":
+		if x > 0 {
+			return search.Mate - x, nil
+		}
+		return -search.Mate - x, nil
+	}
+	return 0, fmt.Errorf("invalid score kind: %s", kind)
+}
+
+func colorName(color g4.Color) string {
+	if color == g4.Red {
+		return "red"
+	}
+	return "yellow"
+}
+
+func parseColor(s string) (g4.Color, error) {
+	switch s {
+	case "yellow":
+		return g4.Yellow, nil
+	case "red":
+		return g4.Red, nil
+	}
+	return g4.Empty, fmt.Errorf("invalid color '%s'", s)
+}
@@ -0,0 +1,185 @@
+package protocol_test
+
+import (
+	"g4"
+	"g4/bitsim"
+	"g4/protocol"
+	"g4/search"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPosition(t *testing.T) {
+	examples := []string{
+		"position startpos",
+		"position startpos moves 4 5 L",
+		"position board y7|8|8|8|8|8|8|8 red moves 1 R",
+		"position startpos rules no-progress=4,forbid-noop-tilts moves D",
+		"position board yyy5|rr6|r7|8|8|8|8|8 yellow rules move-limit=50",
+	}
+	for k, ex := range examples {
+		words := strings.Fields(ex)
+		p, err := protocol.ParsePosition(words[1:])
+		if err != nil {
+			t.Errorf("example %d: unexpected error: %v", k, err)
+			continue
+		}
+		if got := p.String(); got != ex {
+			t.Errorf("example %d: got '%s' but want '%s'", k, got, ex)
+		}
+	}
+}
+
+func TestPositionGame(t *testing.T) {
+	p, err := protocol.ParsePosition(strings.Fields("board y7|8|8|8|8|8|8|8 red moves 2 L"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Moves[0] != g4.TokenMove(g4.Red, 1) || p.Moves[1] != g4.TiltMove(g4.Yellow, g4.LEFT) {
+		t.Errorf("got moves %v", p.Moves)
+	}
+	game, err := p.Game()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := game.Board.String(); got != "8|8|8|8|8|8|8|yr6" || game.Mover != g4.Red {
+		t.Errorf("got board '%s' and mover %v", got, game.Mover)
+	}
+}
+
+func TestPositionError(t *testing.T) {
+	examples := []string{
+		"",
+		"board",
+		"board 9|8|8|8|8|8|8|8 yellow",
+		"board 8|8|8|8|8|8|8|8 blue",
+		"startpos rules unknown=3",
+		"startpos moves 9",
+		"startpos extra",
+	}
+	for k, ex := range examples {
+		if _, err := protocol.ParsePosition(strings.Fields(ex)); err == nil {
+			t.Errorf("example %d: expected error", k)
+		}
+	}
+
+	examples = []string{
+		"board 1y6|8|8|8|8|8|8|8 red",
+		"board yyyy4|8|8|8|8|8|8|8 red moves 1",
+		"board yyyyyyyy|8|8|8|8|8|8|8 red moves 2 1",
+	}
+	for k, ex := range examples {
+		p, err := protocol.ParsePosition(strings.Fields(ex))
+		if err != nil {
+			t.Errorf("example %d: unexpected error: %v", k, err)
+			continue
+		}
+		if _, err := p.Game(); err == nil {
+			t.Errorf("example %d: expected error", k)
+		}
+	}
+}
+
+func TestGo(t *testing.T) {
+	examples := []struct {
+		in   string
+		want protocol.Go
+	}{
+		{in: "go", want: protocol.Go{}},
+		{in: "go depth 6", want: protocol.Go{Depth: 6}},
+		{in: "go movetime 500", want: protocol.Go{MoveTime: 500 * time.Millisecond}},
+		{
+			in: "go ytime 60000 rtime 55000 yinc 1000 rinc 1000 movestogo 20",
+			want: protocol.Go{
+				YellowTime: time.Minute, RedTime: 55 * time.Second,
+				YellowInc: time.Second, RedInc: time.Second, MovesToGo: 20,
+			},
+		},
+		{in: "go infinite", want: protocol.Go{Infinite: true}},
+	}
+	for k, ex := range examples {
+		got, err := protocol.ParseGo(strings.Fields(ex.in)[1:])
+		if err != nil {
+			t.Errorf("example %d: unexpected error: %v", k, err)
+			continue
+		}
+		if got != ex.want {
+			t.Errorf("example %d: got %+v but want %+v", k, got, ex.want)
+		}
+		if s := got.String(); s != ex.in {
+			t.Errorf("example %d: got '%s' but want '%s'", k, s, ex.in)
+		}
+	}
+
+	for k, ex := range []string{"depth", "depth x", "movetime -1", "nodes 100"} {
+		if _, err := protocol.ParseGo(strings.Fields(ex)); err == nil {
+			t.Errorf("example %d: expected error", k)
+		}
+	}
+}
+
+func TestGoClock(t *testing.T) {
+	c := protocol.Go{Depth: 3, YellowTime: time.Minute, RedTime: time.Second, RedInc: 10 * time.Millisecond}
+	want := search.Clock{Remaining: time.Second, Increment: 10 * time.Millisecond, Depth: 3}
+	if got := c.Clock(g4.Red); got != want {
+		t.Errorf("got %+v but want %+v", got, want)
+	}
+	if got := (protocol.Go{Infinite: true, Depth: 3}).Clock(g4.Yellow); got != (search.Clock{}) {
+		t.Errorf("got %+v for infinite search", got)
+	}
+}
+
+func TestInfo(t *testing.T) {
+	pv := []g4.Move{g4.TokenMove(g4.Red, 3), g4.TiltMove(g4.Yellow, g4.LEFT)}
+	examples := []struct {
+		in   search.Info
+		want string
+	}{
+		{
+			in:   search.Info{Result: search.Result{Move: pv[0], Score: -12, Depth: 5, Nodes: 3000, PV: pv}, Elapsed: 2 * time.Second},
+			want: "info depth 5 score cp -12 nodes 3000 time 2000 nps 1500 pv 4 L",
+		},
+		{
+			in:   search.Info{Result: search.Result{Move: pv[0], Score: search.Mate - 3, Depth: 3, PV: pv}},
+			want: "info depth 3 score mate 3 nodes 0 time 0 pv 4 L",
+		},
+		{
+			in:   search.Info{Result: search.Result{Move: pv[0], Score: -search.Mate + 2, Depth: 2, PV: pv}},
+			want: "info depth 2 score mate -2 nodes 0 time 0 pv 4 L",
+		},
+		{
+			in:   search.Info{Result: search.Result{Move: pv[0], PV: pv[:1], Book: true}},
+			want: "info book pv 4",
+		},
+	}
+	for k, ex := range examples {
+		got := protocol.FormatInfo(ex.in)
+		if got != ex.want {
+			t.Errorf("example %d: got '%s' but want '%s'", k, got, ex.want)
+		}
+		info, err := protocol.ParseInfo(strings.Fields(got)[1:], g4.Red)
+		if err != nil {
+			t.Errorf("example %d: unexpected error: %v", k, err)
+			continue
+		}
+		if info.Move != ex.in.Move || info.Score != ex.in.Score || info.Depth != ex.in.Depth ||
+			info.Nodes != ex.in.Nodes || info.Elapsed != ex.in.Elapsed || info.Book != ex.in.Book ||
+			len(info.PV) != len(ex.in.PV) {
+			t.Errorf("example %d: got %+v but want %+v", k, info, ex.in)
+		}
+	}
+
+	for k, ex := range []string{"string hello", "depth", "score cp", "score elo 3", "pv 9"} {
+		if _, err := protocol.ParseInfo(strings.Fields(ex), g4.Yellow); err == nil {
+			t.Errorf("example %d: expected error", k)
+		}
+	}
+}
+
+func TestPositionStartpos(t *testing.T) {
+	p := protocol.Position{Start: bitsim.Game{Mover: g4.Red}}
+	if got := p.String(); got != "position board 8|8|8|8|8|8|8|8 red" {
+		t.Errorf("got '%s'", got)
+	}
+}
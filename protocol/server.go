@@ -0,0 +1,241 @@
+package protocol
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"g4/book"
+	"g4/search"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Server is an engine speaking the protocol, backed by a search.Controller.
+type Server struct {
+	// Name and Author identify the engine.
+	Name, Author string
+
+	options    search.Options
+	level      search.Level
+	controller *search.Controller
+	infos      <-chan search.Info
+	position   Position
+
+	// positionErr is the error of the last position command, if it was invalid.
+	positionErr error
+
+	mu sync.Mutex
+	w  *bufio.Writer
+
+	// cancel stops the current search, and done is closed when it has answered.
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewServer creates an engine searching with given options, at MaxLevel.
+func NewServer(options search.Options) *Server {
+	if options.Threads <= 0 {
+		options.Threads = 1
+	}
+	s := &Server{Name: "g4", Author: "the g4 authors", options: options, level: search.MaxLevel}
+	s.position = startPosition()
+	s.reset()
+	return s
+}
+
+// SetLevel changes the strength of the engine.
+func (s *Server) SetLevel(level search.Level) {
+	s.level = level
+	s.controller.SetLevel(level)
+}
+
+// reset creates a new controller from the options.
+func (s *Server) reset() {
+	if s.controller != nil {
+		s.controller.Close()
+	}
+	s.controller = search.NewController(search.New(s.options))
+	s.controller.SetLevel(s.level)
+	s.infos = s.controller.Subscribe()
+}
+
+// Serve reads commands from r and answers on w, until the quit command or the end of r.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.w = bufio.NewWriter(w)
+	defer s.controller.Close()
+	defer s.stop()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		words := strings.Fields(scanner.Text())
+		if len(words) == 0 {
+			continue
+		}
+		command, args := words[0], words[1:]
+		switch command {
+		case "g4":
+			s.identify()
+		case "isready":
+			s.send("readyok")
+		case "setoption":
+			s.stop()
+			if err := s.setOption(args); err != nil {
+				s.send("info string error: " + err.Error())
+			}
+		case "newgame":
+			s.stop()
+			s.reset()
+			s.position, s.positionErr = startPosition(), nil
+		case "position":
+			s.stop()
+			s.position, s.positionErr = ParsePosition(args)
+			if s.positionErr != nil {
+				s.send("info string error: " + s.positionErr.Error())
+			}
+		case "go":
+			s.stop()
+			limits, err := ParseGo(args)
+			if err != nil {
+				s.send("info string error: " + err.Error())
+				continue
+			}
+			s.think(limits)
+		case "stop":
+			s.stop()
+		case "quit":
+			return nil
+		default:
+			s.send(fmt.Sprintf("info string unknown command '%s'", command))
+		}
+	}
+	return scanner.Err()
+}
+
+// identify answers the g4 command.
+func (s *Server) identify() {
+	names := make([]string, len(search.Levels))
+	for k, level := range search.Levels {
+		names[k] = "var " + level.Name
+	}
+	s.send("id name " + s.Name)
+	s.send("id author " + s.Author)
+	s.send(fmt.Sprintf("option name Threads type spin default %d min 1 max 256", s.options.Threads))
+	s.send(fmt.Sprintf("option name Level type combo default %s %s", s.level.Name, strings.Join(names, " ")))
+	s.send("option name Book type string default <empty>")
+	s.send("g4ok")
+}
+
+// setOption applies a setoption command.
+func (s *Server) setOption(args []string) error {
+	if len(args) < 4 || args[0] != "name" || args[2] != "value" {
+		return fmt.Errorf("expected 'name <name> value <value>'")
+	}
+	name, value := args[1], strings.Join(args[3:], " ")
+	switch name {
+	case "Threads":
+		threads, err := strconv.Atoi(value)
+		if err != nil || threads < 1 || threads > 256 {
+			return fmt.Errorf("invalid number of threads: %s", value)
+		}
+		s.options.Threads = threads
+		s.reset()
+	case "Level":
+		level, err := search.ParseLevel(value)
+		if err != nil {
+			return err
+		}
+		s.SetLevel(level)
+	case "Book":
+		s.options.Book = nil
+		if value != "<empty>" {
+			b, err := book.Load(value)
+			if err != nil {
+				return err
+			}
+			s.options.Book = b
+		}
+		s.reset()
+	default:
+		return fmt.Errorf("unknown option '%s'", name)
+	}
+	return nil
+}
+
+// startPosition returns the position of a new game.
+func startPosition() Position {
+	return Position{Start: bitsim.Game{Mover: g4.Yellow}}
+}
+
+// think starts searching the current position in the background.
+func (s *Server) think(limits Go) {
+	game, err := s.position.Game()
+	if s.positionErr != nil {
+		err = s.positionErr
+	}
+	if err != nil {
+		s.send("info string error: " + err.Error())
+		s.send("bestmove none")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	s.cancel, s.done = cancel, done
+	go func() {
+		defer close(done)
+		type answer struct {
+			result search.Result
+			err    error
+		}
+		answers := make(chan answer, 1)
+		go func() {
+			result, err := s.controller.Think(ctx, game, limits.Clock(game.Mover))
+			answers <- answer{result, err}
+		}()
+
+		for {
+			select {
+			case info := <-s.infos:
+				s.send(FormatInfo(info))
+			case a := <-answers:
+				// Infos are published before Think returns: send the last ones first.
+				for drained := false; !drained; {
+					select {
+					case info := <-s.infos:
+						s.send(FormatInfo(info))
+					default:
+						drained = true
+					}
+				}
+				if a.err != nil {
+					s.send("bestmove none")
+				} else {
+					s.send("bestmove " + a.result.Move.String())
+				}
+				return
+			}
+		}
+	}()
+}
+
+// stop stops the current search, if any, and waits for its answer.
+func (s *Server) stop() {
+	if s.cancel != nil {
+		s.cancel()
+		<-s.done
+		s.cancel, s.done = nil, nil
+	}
+}
+
+// send writes a line to the client.
+func (s *Server) send(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.w.WriteString(line)
+	s.w.WriteByte('\n')
+	s.w.Flush()
+}
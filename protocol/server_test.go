@@ -0,0 +1,108 @@
+package protocol_test
+
+import (
+	"bufio"
+	"g4/protocol"
+	"g4/search"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// runServer sends commands to a server and returns its answers.
+func runServer(t *testing.T, commands string) []string {
+	t.Helper()
+	server := protocol.NewServer(search.Options{TableSize: 1 << 14})
+	var out strings.Builder
+	if err := server.Serve(strings.NewReader(commands), &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+}
+
+func TestServerHandshake(t *testing.T) {
+	lines := runServer(t, "g4\nisready\nquit\n")
+	if lines[0] != "id name g4" || lines[len(lines)-2] != "g4ok" || lines[len(lines)-1] != "readyok" {
+		t.Errorf("got %q", lines)
+	}
+}
+
+func TestServerGo(t *testing.T) {
+	examples := []struct {
+		in   string
+		want string
+	}{
+		{
+			in:   "position board yyy5|rr6|r7|8|8|8|8|8 yellow\ngo depth 3\n",
+			want: "bestmove 1",
+		},
+		{
+			in:   "position startpos moves 1 2 1 2 1 2\ngo movetime 100\n",
+			want: "bestmove 1",
+		},
+		{
+			in:   "position board yyyy4|8|8|8|8|8|8|8 red\ngo depth 3\n",
+			want: "bestmove none",
+		},
+		{
+			in:   "position startpos moves 1 9\ngo depth 1\n",
+			want: "bestmove none",
+		},
+	}
+	for k, ex := range examples {
+		lines := runServer(t, ex.in)
+		// The server stops searching at the end of the input and answers.
+		if got := lines[len(lines)-1]; got != ex.want {
+			t.Errorf("example %d: got '%s' but want '%s' (%q)", k, got, ex.want, lines)
+		}
+	}
+}
+
+func TestServerInfo(t *testing.T) {
+	lines := runServer(t, "position startpos\ngo depth 4\nisready\n")
+	depths := 0
+	for _, line := range lines {
+		if strings.HasPrefix(line, "info depth") {
+			depths++
+		}
+	}
+	if depths != 4 || !strings.HasPrefix(lines[len(lines)-1], "bestmove ") {
+		t.Errorf("got %q", lines)
+	}
+}
+
+func TestServerStop(t *testing.T) {
+	r, w := io.Pipe()
+	server := protocol.NewServer(search.Options{TableSize: 1 << 14})
+	out, outWriter := io.Pipe()
+	go server.Serve(r, outWriter)
+
+	start := time.Now()
+	io.WriteString(w, "position startpos\ngo infinite\n")
+	time.Sleep(50 * time.Millisecond)
+	io.WriteString(w, "stop\n")
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), "bestmove ") {
+			break
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("stopping took %v", elapsed)
+	}
+	io.WriteString(w, "quit\n")
+	w.Close()
+}
+
+func TestServerErrors(t *testing.T) {
+	lines := runServer(t, "hello\nposition nowhere\ngo depth x\nsetoption name Level value godlike\nsetoption name Threads value 2\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %q", lines)
+	}
+	for k, line := range lines {
+		if !strings.HasPrefix(line, "info string ") {
+			t.Errorf("line %d: got '%s'", k, line)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+// Package puzzle finds tactical puzzles in g4 games.
+//
+// A puzzle is a position where the mover has a forced win in a few moves,
+// starting with a single winning move. The shortest win is found by an
+// exhaustive search, and the solver checks that no other first move wins,
+// however slowly. Puzzles are stored as game records, whose main line is the
+// solution: the winning moves, and the replies which resist the longest.
+package puzzle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"g4/record"
+	"g4/solver"
+	"io"
+	"os"
+)
+
+const (
+	// DefaultBudget is the default node budget of the solver, for each alternative first move.
+	DefaultBudget = 100000
+
+	// goalTag is the tag of records holding the length of the win.
+	goalTag = "Puzzle"
+
+	// tiltTag is the tag of records telling whether the solution needs a tilt.
+	tiltTag = "Tilt"
+
+	// sourceTag is the tag of records telling where the puzzle comes from.
+	sourceTag = "Source"
+)
+
+// ErrorNotPuzzle is returned when reading a record which does not describe a puzzle.
+var ErrorNotPuzzle = errors.New("record is not a puzzle")
+
+// Puzzle is a position with a unique winning move.
+type Puzzle struct {
+	Start bitsim.Game
+
+	// Moves is the number of moves of the mover needed to win.
+	Moves int
+
+	// Solution alternates the winning moves and the replies resisting the
+	// longest, until the game is won. It has at most 2*Moves-1 moves.
+	Solution []g4.Move
+
+	// Tilt tells whether the winning moves of the solution include a tilt.
+	Tilt bool
+
+	// Source tells where the position was found.
+	Source string
+}
+
+// Options configures the search for puzzles.
+type Options struct {
+	// MinMoves and MaxMoves bound the number of moves of the win.
+	MinMoves, MaxMoves int
+
+	// TiltsOnly keeps only the puzzles whose solution needs a tilt.
+	TiltsOnly bool
+
+	// Budget is the node budget of the solver for each alternative first
+	// move. It defaults to DefaultBudget.
+	Budget int
+}
+
+// Find returns the puzzle of position g, if it is one.
+//
+// The shortest win of the mover must take between MinMoves and MaxMoves
+// moves, its first move must be the only one to win in that many moves, and
+// the solver must prove that no other first move wins at all. Positions where
+// the solver runs out of budget are not puzzles.
+func Find(ctx context.Context, g bitsim.Game, options Options) (Puzzle, bool) {
+	if options.MinMoves <= 0 {
+		options.MinMoves = 1
+	}
+	if options.Budget <= 0 {
+		options.Budget = DefaultBudget
+	}
+	moves, err := g.Generate()
+	if err != nil {
+		return Puzzle{}, false
+	}
+
+	pr := &prover{ctx: ctx}
+	n := pr.distance(g, options.MaxMoves)
+	if n < options.MinMoves {
+		return Puzzle{}, false
+	}
+	var key []g4.Move
+	for _, move := range moves {
+		if pr.moveWinsIn(g, move, n) {
+			key = append(key, move)
+		}
+	}
+	if pr.err != nil || len(key) != 1 {
+		return Puzzle{}, false
+	}
+	for _, move := range moves {
+		if move == key[0] {
+			continue
+		}
+		child, err := g.Apply(move)
+		if err != nil {
+			continue // Finished games are no wins, or the move would have been found.
+		}
+		result, _ := solver.Solve(ctx, child, options.Budget)
+		if result.Outcome == solver.Unknown || result.Outcome == solver.Loss {
+			return Puzzle{}, false
+		}
+	}
+
+	line := pr.solution(g, key[0], n)
+	if pr.err != nil {
+		return Puzzle{}, false
+	}
+	p := Puzzle{Start: g, Moves: n, Solution: line}
+	for k := 0; k < len(p.Solution); k += 2 {
+		p.Tilt = p.Tilt || p.Solution[k].Type == g4.Tilt
+	}
+	if options.TiltsOnly && !p.Tilt {
+		return Puzzle{}, false
+	}
+	return p, true
+}
+
+// WinsIn returns whether the mover of g can force a win in at most n moves.
+//
+// Like the other exhaustive searches of the package, it gives up with the
+// error of ctx once ctx is done.
+func WinsIn(ctx context.Context, g bitsim.Game, n int) (bool, error) {
+	p := &prover{ctx: ctx}
+	wins := p.winsIn(g, n)
+	return wins, p.err
+}
+
+// MoveWinsIn returns whether move forces a win in at most n moves of the mover of g, including itself.
+func MoveWinsIn(ctx context.Context, g bitsim.Game, move g4.Move, n int) (bool, error) {
+	p := &prover{ctx: ctx}
+	wins := p.moveWinsIn(g, move, n)
+	return wins, p.err
+}
+
+// Distance returns the smallest number of moves in which the mover of g forces
+// a win, or 0 if it takes more than max moves.
+func Distance(ctx context.Context, g bitsim.Game, max int) (int, error) {
+	p := &prover{ctx: ctx}
+	n := p.distance(g, max)
+	return n, p.err
+}
+
+// Defend returns the move of the mover of g delaying the most its loss, when
+// the opponent wins in at most n moves.
+//
+// It also returns the number of moves the opponent then needs, which is 0 when
+// all moves lose at once. It returns the error of the game when it is over.
+func Defend(ctx context.Context, g bitsim.Game, n int) (g4.Move, int, error) {
+	p := &prover{ctx: ctx}
+	move, distance, err := p.defend(g, n)
+	if err == nil {
+		err = p.err
+	}
+	return move, distance, err
+}
+
+// prover runs the exhaustive searches of wins, until its context is done.
+type prover struct {
+	ctx   context.Context
+	nodes int
+	err   error
+}
+
+// stopped counts a node, and tells whether the search has to give up.
+//
+// The context is checked at the first node, then every 1024 nodes.
+func (p *prover) stopped() bool {
+	p.nodes++
+	if p.err == nil && p.nodes&1023 == 1 {
+		p.err = p.ctx.Err()
+	}
+	return p.err != nil
+}
+
+func (p *prover) winsIn(g bitsim.Game, n int) bool {
+	if n <= 0 || p.stopped() {
+		return false
+	}
+	moves, err := g.Generate()
+	if err != nil {
+		return false
+	}
+	for _, move := range moves {
+		if p.moveWinsIn(g, move, n) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *prover) moveWinsIn(g bitsim.Game, move g4.Move, n int) bool {
+	child, err := g.Apply(move)
+	if err != nil {
+		return g4.IsWin(err, g.Mover)
+	}
+	if n <= 1 {
+		return false
+	}
+	replies, _ := child.Generate()
+	for _, reply := range replies {
+		next, err := child.Apply(reply)
+		if err != nil {
+			if g4.IsWin(err, g.Mover) {
+				continue
+			}
+			return false
+		}
+		if !p.winsIn(next, n-1) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *prover) distance(g bitsim.Game, max int) int {
+	for n := 1; n <= max; n++ {
+		if p.winsIn(g, n) {
+			return n
+		}
+	}
+	return 0
+}
+
+func (p *prover) defend(g bitsim.Game, n int) (g4.Move, int, error) {
+	moves, err := g.Generate()
+	if err != nil {
+		return g4.Move{}, 0, err
+	}
+	best, bestDistance := moves[0], -1
+	for _, move := range moves {
+		next, err := g.Apply(move)
+		distance := 0
+		if err == nil {
+			distance = p.distance(next, n)
+		}
+		if distance > bestDistance {
+			best, bestDistance = move, distance
+		}
+	}
+	return best, bestDistance, nil
+}
+
+// solution returns the main line of a win in n moves starting with key.
+//
+// The defender plays the reply which delays the loss the most, and the
+// attacker the first move which still wins in time.
+func (p *prover) solution(g bitsim.Game, key g4.Move, n int) []g4.Move {
+	line := []g4.Move{key}
+	for ; n > 1; n-- {
+		g, _ = g.Apply(key)
+		reply, distance, _ := p.defend(g, n-1)
+		line = append(line, reply)
+		if distance == 0 {
+			return line // The defender loses at once, whatever it plays.
+		}
+		g, _ = g.Apply(reply)
+		moves, _ := g.Generate()
+		for _, move := range moves {
+			if p.moveWinsIn(g, move, n-1) {
+				key = move
+				break
+			}
+		}
+		line = append(line, key)
+	}
+	return line
+}
+
+// Record returns the puzzle as a game record, whose main line is the solution.
+func (p Puzzle) Record() *record.Record {
+	rec := &record.Record{
+		Start: p.Start.Board.String(),
+		Mover: p.Start.Mover,
+		Tags: []record.Tag{
+			{Name: goalTag, Value: fmt.Sprintf("win in %d", p.Moves)},
+			{Name: tiltTag, Value: fmt.Sprint(p.Tilt)},
+		},
+	}
+	if p.Start.Rules != (bitsim.Rules{}) {
+		rec.Variant = p.Start.Rules.String()
+	}
+	if p.Source != "" {
+		rec.Tags = append(rec.Tags, record.Tag{Name: sourceTag, Value: p.Source})
+	}
+	game := p.Start
+	for _, move := range p.Solution {
+		rec.Moves = append(rec.Moves, record.Node{Move: move})
+		game, _ = game.Apply(move)
+	}
+	rec.Result, rec.Reason = record.Conclude(game)
+	return rec
+}
+
+// FromRecord reads a puzzle written by Record.
+func FromRecord(rec *record.Record) (Puzzle, error) {
+	start, err := rec.StartingGame()
+	if err != nil {
+		return Puzzle{}, err
+	}
+	p := Puzzle{Start: start}
+	found := false
+	for _, tag := range rec.Tags {
+		switch tag.Name {
+		case goalTag:
+			_, err := fmt.Sscanf(tag.Value, "win in %d", &p.Moves)
+			found = err == nil
+		case tiltTag:
+			p.Tilt = tag.Value == "true"
+		case sourceTag:
+			p.Source = tag.Value
+		}
+	}
+	if !found || len(rec.Moves) == 0 || len(rec.Moves) > 2*p.Moves-1 {
+		return Puzzle{}, ErrorNotPuzzle
+	}
+	if _, err := rec.Replay(); err != nil {
+		return Puzzle{}, err
+	}
+	for _, node := range rec.Moves {
+		p.Solution = append(p.Solution, node.Move)
+	}
+	return p, nil
+}
+
+// Read reads the puzzles of a record file, skipping records which are not puzzles.
+func Read(r io.Reader) ([]Puzzle, error) {
+	records, err := record.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	var puzzles []Puzzle
+	for _, rec := range records {
+		if p, err := FromRecord(rec); err == nil {
+			puzzles = append(puzzles, p)
+		}
+	}
+	return puzzles, nil
+}
+
+// Load reads the puzzles of a record file.
+func Load(path string) ([]Puzzle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
@@ -0,0 +1,157 @@
+package puzzle_test
+
+import (
+	"bytes"
+	"context"
+	"g4"
+	"g4/bitsim"
+	"g4/internal/g4test"
+	"g4/puzzle"
+	"g4/record"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func line(moves []g4.Move) string {
+	words := make([]string, len(moves))
+	for k, move := range moves {
+		words[k] = move.String()
+	}
+	return strings.Join(words, " ")
+}
+
+func TestDistance(t *testing.T) {
+	examples := []struct {
+		board string
+		mover g4.Color
+		want  int
+	}{
+		{bitsim.StartingPosition, g4.Yellow, 0},
+		{"yyy5|rr6|r7|8|8|8|8|8", g4.Yellow, 1},
+		{"yyrryy2|yyyrr3|ryrr4|rryy4|yr6|y7|8|8", g4.Red, 2},
+		{"8|8|y7|yr6|rrry4|ryr5|yyyrr3|yyrryy2", g4.Yellow, 2},
+	}
+	for k, example := range examples {
+		game := g4test.Game(t, example.board, example.mover)
+		got, err := puzzle.Distance(context.Background(), game, 2)
+		if err != nil {
+			t.Fatalf("example %d: error in Distance: %v", k, err)
+		}
+		if got != example.want {
+			t.Errorf("example %d: got %d but want %d", k, got, example.want)
+		}
+	}
+}
+
+func TestDistanceCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	game := g4test.Game(t, bitsim.StartingPosition, g4.Yellow)
+	if _, err := puzzle.Distance(ctx, game, 3); err != context.Canceled {
+		t.Errorf("got error %v but want %v", err, context.Canceled)
+	}
+}
+
+func TestDefend(t *testing.T) {
+	game := g4test.Game(t, "yyrryy2|yyyrr3|ryrr4|rryy4|yr6|y7|8|8", g4.Red)
+	game, err := game.Apply(g4test.Move(t, "3", g4.Red))
+	if err != nil {
+		t.Fatalf("error in Apply: %v", err)
+	}
+	move, distance, err := puzzle.Defend(context.Background(), game, 1)
+	if err != nil {
+		t.Fatalf("error in Defend: %v", err)
+	}
+	if want := g4test.Move(t, "L", g4.Yellow); move != want || distance != 1 {
+		t.Errorf("got %v losing in %d but want %v losing in 1", move, distance, want)
+	}
+
+	game = g4test.Game(t, "yyyy4|rrr5|8|8|8|8|8|8", g4.Red)
+	if _, _, err := puzzle.Defend(context.Background(), game, 1); err == nil {
+		t.Errorf("got no error defending a finished game")
+	}
+}
+
+func TestFind(t *testing.T) {
+	examples := []struct {
+		board    string
+		mover    g4.Color
+		moves    int
+		solution string
+		tilt     bool
+	}{
+		{"yyrryy2|yyyrr3|ryrr4|rryy4|yr6|y7|8|8", g4.Red, 2, "3 L D", true},
+		{"8|8|y7|yr6|rrry4|ryr5|yyyrr3|yyrryy2", g4.Yellow, 2, "6 D 2", false},
+	}
+	options := puzzle.Options{MinMoves: 1, MaxMoves: 3}
+	for k, example := range examples {
+		game := g4test.Game(t, example.board, example.mover)
+		p, ok := puzzle.Find(context.Background(), game, options)
+		if !ok {
+			t.Errorf("example %d: no puzzle found", k)
+			continue
+		}
+		if p.Moves != example.moves || line(p.Solution) != example.solution || p.Tilt != example.tilt {
+			t.Errorf("example %d: got win in %d (%s, tilt %v) but want win in %d (%s, tilt %v)", k,
+				p.Moves, line(p.Solution), p.Tilt, example.moves, example.solution, example.tilt)
+		}
+	}
+}
+
+func TestFindRejects(t *testing.T) {
+	examples := []struct {
+		board   string
+		mover   g4.Color
+		options puzzle.Options
+	}{
+		// No win.
+		{bitsim.StartingPosition, g4.Yellow, puzzle.Options{MaxMoves: 2}},
+		// Two winning moves.
+		{"yyy5|yyy5|rrr5|rr6|r7|8|8|8", g4.Yellow, puzzle.Options{MaxMoves: 2}},
+		// Too short.
+		{"8|8|y7|yr6|rrry4|ryr5|yyyrr3|yyrryy2", g4.Yellow, puzzle.Options{MinMoves: 3, MaxMoves: 3}},
+		// Too long.
+		{"8|8|y7|yr6|rrry4|ryr5|yyyrr3|yyrryy2", g4.Yellow, puzzle.Options{MaxMoves: 1}},
+		// No tilt.
+		{"8|8|y7|yr6|rrry4|ryr5|yyyrr3|yyrryy2", g4.Yellow, puzzle.Options{MaxMoves: 2, TiltsOnly: true}},
+	}
+	for k, example := range examples {
+		game := g4test.Game(t, example.board, example.mover)
+		if p, ok := puzzle.Find(context.Background(), game, example.options); ok {
+			t.Errorf("example %d: got puzzle %s", k, line(p.Solution))
+		}
+	}
+}
+
+func TestRecord(t *testing.T) {
+	game := g4test.Game(t, "yyrryy2|yyyrr3|ryrr4|rryy4|yr6|y7|8|8", g4.Red)
+	p, ok := puzzle.Find(context.Background(), game, puzzle.Options{MaxMoves: 2})
+	if !ok {
+		t.Fatalf("no puzzle found")
+	}
+	p.Source = "test"
+
+	var b bytes.Buffer
+	w := record.NewWriter(&b)
+	if err := w.Write(&record.Record{Result: record.Draw}); err != nil {
+		t.Fatalf("error in Write: %v", err)
+	}
+	if err := w.Write(p.Record()); err != nil {
+		t.Fatalf("error in Write: %v", err)
+	}
+	puzzles, err := puzzle.Read(&b)
+	if err != nil {
+		t.Fatalf("error in Read: %v", err)
+	}
+	// The record of a game is skipped.
+	if len(puzzles) != 1 {
+		t.Fatalf("got %d puzzles but want 1", len(puzzles))
+	}
+	if !reflect.DeepEqual(puzzles[0], p) {
+		t.Errorf("got %+v but want %+v", puzzles[0], p)
+	}
+	if rec := p.Record(); rec.Result != record.RedWins {
+		t.Errorf("got result %v but want %v", rec.Result, record.RedWins)
+	}
+}
@@ -0,0 +1,98 @@
+package record
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+)
+
+// MarshalBinary encodes the record in a compact binary form.
+//
+// The header fields are written first as length-prefixed strings (yellow, red,
+// date, variant and reason), followed by one byte for the result and by the
+// main line, encoded with bitsim.Line. Comments, variations and extra tags are
+// not part of the binary form.
+func (r *Record) MarshalBinary() ([]byte, error) {
+	var data []byte
+	for _, s := range []string{r.Yellow, r.Red, r.Date, r.Variant, string(r.Reason)} {
+		data = appendString(data, s)
+	}
+	data = append(data, byte(r.Result))
+
+	start, err := r.StartingGame()
+	if err != nil {
+		return nil, err
+	}
+	// NB: the rules are stored with the variant.
+	line := bitsim.Line{Start: bitsim.Game{Board: start.Board, Mover: start.Mover}}
+	for _, node := range r.Moves {
+		line.Moves = append(line.Moves, node.Move)
+	}
+	lineData, err := line.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append(data, lineData...), nil
+}
+
+// UnmarshalBinary decodes a record encoded by MarshalBinary.
+func (r *Record) UnmarshalBinary(data []byte) error {
+	var fields [5]string
+	for k := range fields {
+		s, n, err := readString(data)
+		if err != nil {
+			return err
+		}
+		fields[k] = s
+		data = data[n:]
+	}
+
+	if len(data) == 0 {
+		return errors.New("missing result")
+	}
+	result := Result(data[0])
+	if result > Draw {
+		return fmt.Errorf("invalid result: %d", data[0])
+	}
+
+	var line bitsim.Line
+	if err := line.UnmarshalBinary(data[1:]); err != nil {
+		return err
+	}
+
+	*r = Record{
+		Yellow:  fields[0],
+		Red:     fields[1],
+		Date:    fields[2],
+		Variant: fields[3],
+		Reason:  Reason(fields[4]),
+		Result:  result,
+	}
+	if start := line.Start.Board.String(); start != bitsim.StartingPosition {
+		r.Start = start
+	}
+	if line.Start.Mover == g4.Red {
+		r.Mover = g4.Red
+	}
+	for _, move := range line.Moves {
+		r.Moves = append(r.Moves, Node{Move: move})
+	}
+	return nil
+}
+
+func appendString(data []byte, s string) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], uint64(len(s)))
+	return append(append(data, buf[:n]...), s...)
+}
+
+// readString reads a length-prefixed string and returns it along with the number of bytes read.
+func readString(data []byte) (string, int, error) {
+	length, n := binary.Uvarint(data)
+	if n <= 0 || length > uint64(len(data)-n) {
+		return "", 0, errors.New("invalid string")
+	}
+	return string(data[n : n+int(length)]), n + int(length), nil
+}
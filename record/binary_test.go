@@ -0,0 +1,96 @@
+package record_test
+
+import (
+	"g4"
+	"g4/record"
+	"reflect"
+	"testing"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	examples := []*record.Record{
+		{},
+		{
+			Yellow:  "alice",
+			Red:     "bob",
+			Date:    "2023.07.14",
+			Variant: "standard",
+			Result:  record.YellowWins,
+			Reason:  record.Connect4,
+			Moves: []record.Node{
+				{Move: g4.TokenMove(g4.Yellow, 3)},
+				{Move: g4.TiltMove(g4.Red, g4.LEFT)},
+				{Move: g4.TokenMove(g4.Yellow, 7)},
+			},
+		},
+		{
+			Variant: "no-progress=4,move-limit=100,forbid-noop-tilts",
+			Result:  record.Unfinished,
+			Moves: []record.Node{
+				{Move: g4.TokenMove(g4.Yellow, 0)},
+				{Move: g4.TiltMove(g4.Red, g4.DOWN)},
+			},
+		},
+		{
+			Start:  "yr6|r7|8|8|8|8|8|8",
+			Mover:  g4.Red,
+			Result: record.Draw,
+			Reason: record.Repetition,
+			Moves: []record.Node{
+				{Move: g4.TiltMove(g4.Red, g4.RIGHT)},
+				{Move: g4.TiltMove(g4.Yellow, g4.LEFT)},
+			},
+		},
+	}
+	for k, ex := range examples {
+		data, err := ex.MarshalBinary()
+		if err != nil {
+			t.Errorf("example %d: unexpected error: %v", k, err)
+			continue
+		}
+		var got record.Record
+		if err := got.UnmarshalBinary(data); err != nil || !reflect.DeepEqual(&got, ex) {
+			t.Errorf("example %d: got (%+v, %v) but want (%+v, nil)", k, got, err, ex)
+		}
+	}
+}
+
+func TestBinaryDropsAnnotations(t *testing.T) {
+	rec := &record.Record{
+		Comment: "hello",
+		Tags:    []record.Tag{{Name: "Round", Value: "1"}},
+		Moves: []record.Node{
+			{
+				Move:       g4.TokenMove(g4.Yellow, 3),
+				Comment:    "center",
+				Variations: [][]record.Node{{{Move: g4.TokenMove(g4.Yellow, 2)}}},
+			},
+		},
+	}
+	data, _ := rec.MarshalBinary()
+	var got record.Record
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := record.Record{Moves: []record.Node{{Move: g4.TokenMove(g4.Yellow, 3)}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v but want %+v", got, want)
+	}
+}
+
+func TestUnmarshalBinaryError(t *testing.T) {
+	valid, _ := (&record.Record{}).MarshalBinary()
+	examples := [][]byte{
+		nil,
+		{10, 'a'},
+		valid[:5],
+		append(append([]byte{}, valid[:5]...), 4),
+		valid[:len(valid)-1],
+	}
+	for k, ex := range examples {
+		var rec record.Record
+		if err := rec.UnmarshalBinary(ex); err == nil {
+			t.Errorf("example %d: expected error", k)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package record
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"g4"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var moveNumberRe = regexp.MustCompile(`^\d+\.(\.\.)?$`)
+
+// Reader reads records in text format.
+//
+// A single input can hold several records, one after the other.
+type Reader struct {
+	r    *bufio.Reader
+	line int
+}
+
+// NewReader returns a new Reader that reads from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r), line: 1}
+}
+
+// ReadAll reads all the remaining records.
+func (r *Reader) ReadAll() ([]*Record, error) {
+	var records []*Record
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		records = append(records, rec)
+	}
+}
+
+// Read reads the next record.
+//
+// It returns io.EOF when there is no record left.
+func (r *Reader) Read() (*Record, error) {
+	rec := &Record{}
+	var headerResult string
+	empty := true
+
+	// Header.
+	for {
+		c, err := r.skipSpaces()
+		if err == io.EOF {
+			if empty {
+				return nil, io.EOF
+			}
+			break
+		}
+		if err != nil {
+			return nil, r.errorf("%w", err)
+		}
+		if c != '[' {
+			r.r.UnreadRune()
+			break
+		}
+		empty = false
+		name, value, err := r.readTag()
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "Yellow":
+			rec.Yellow = value
+		case "Red":
+			rec.Red = value
+		case "Date":
+			rec.Date = value
+		case "Variant":
+			rec.Variant = value
+		case "Start":
+			rec.Start = value
+		case "Mover":
+			switch value {
+			case "yellow":
+				rec.Mover = g4.Yellow
+			case "red":
+				rec.Mover = g4.Red
+			default:
+				return nil, r.errorf("invalid mover '%s'", value)
+			}
+		case "Result":
+			if _, ok := parseResult(value); !ok {
+				return nil, r.errorf("invalid result '%s'", value)
+			}
+			headerResult = value
+		case "Reason":
+			rec.Reason = Reason(value)
+		default:
+			rec.Tags = append(rec.Tags, Tag{Name: name, Value: value})
+		}
+	}
+
+	// Move list.
+	moves, comment, result, err := r.readLine(rec.startingMover(), 0)
+	if err != nil {
+		return nil, err
+	}
+	rec.Moves = moves
+	rec.Comment = comment
+	if result == "" {
+		result = headerResult
+	}
+	if headerResult != "" && result != headerResult {
+		return nil, r.errorf("result '%s' does not match header '%s'", result, headerResult)
+	}
+	rec.Result, _ = parseResult(result)
+
+	return rec, nil
+}
+
+// readLine reads moves until the end of the line.
+//
+// A line ends with a closing parenthesis for variations (depth > 0) and with a
+// result or the end of input for the main line. It returns the moves, the comment
+// found before the first move and the result ending the line, if any.
+func (r *Reader) readLine(mover g4.Color, depth int) (line []Node, comment, result string, err error) {
+	for {
+		c, err := r.skipSpaces()
+		if err == io.EOF {
+			if depth > 0 {
+				return nil, "", "", r.errorf("unterminated variation")
+			}
+			return line, comment, "", nil
+		}
+		if err != nil {
+			return nil, "", "", r.errorf("%w", err)
+		}
+
+		switch c {
+
+		case '{':
+			text, err := r.r.ReadString('}')
+			r.line += strings.Count(text, "\n")
+			if err != nil {
+				return nil, "", "", r.errorf("unterminated comment")
+			}
+			text = strings.TrimSpace(strings.TrimSuffix(text, "}"))
+			if len(line) == 0 {
+				comment = joinComments(comment, text)
+			} else {
+				line[len(line)-1].Comment = joinComments(line[len(line)-1].Comment, text)
+			}
+
+		case '(':
+			if len(line) == 0 {
+				return nil, "", "", r.errorf("variation before first move")
+			}
+			last := &line[len(line)-1]
+			variation, _, _, err := r.readLine(last.Move.Color, depth+1)
+			if err != nil {
+				return nil, "", "", err
+			}
+			last.Variations = append(last.Variations, variation)
+
+		case ')':
+			if depth == 0 {
+				return nil, "", "", r.errorf("unexpected ')'")
+			}
+			return line, comment, "", nil
+
+		case '[':
+			return nil, "", "", r.errorf("unexpected tag in move list")
+
+		default:
+			r.r.UnreadRune()
+			word, err := r.readWord()
+			if err != nil {
+				return nil, "", "", r.errorf("%w", err)
+			}
+			if _, ok := parseResult(word); ok {
+				if depth > 0 {
+					return nil, "", "", r.errorf("result inside variation")
+				}
+				return line, comment, word, nil
+			}
+			if moveNumberRe.MatchString(word) {
+				break
+			}
+			move, err := g4.ParseMove(word, mover)
+			if err != nil {
+				return nil, "", "", r.errorf("%w", err)
+			}
+			line = append(line, Node{Move: move})
+			mover = mover.Opponent()
+		}
+	}
+}
+
+// readTag reads a tag pair, after the opening bracket.
+func (r *Reader) readTag() (name, value string, err error) {
+	s, err := r.r.ReadString(']')
+	if err != nil {
+		return "", "", r.errorf("unterminated tag")
+	}
+	// NB: a ']' inside the value ends the tag early, and we must keep reading.
+	for strings.Count(s, `"`)-strings.Count(s, `\"`) == 1 {
+		more, err := r.r.ReadString(']')
+		if err != nil {
+			return "", "", r.errorf("unterminated tag")
+		}
+		s += more
+	}
+	fields := strings.SplitN(strings.TrimSuffix(s, "]"), " ", 2)
+	if len(fields) != 2 {
+		return "", "", r.errorf("invalid tag '%s'", s)
+	}
+	value, err = strconv.Unquote(strings.TrimSpace(fields[1]))
+	if err != nil {
+		return "", "", r.errorf("invalid tag value %s", fields[1])
+	}
+	return fields[0], value, nil
+}
+
+// readWord reads a sequence of characters up to a space or a delimiter.
+func (r *Reader) readWord() (string, error) {
+	var s strings.Builder
+	for {
+		c, _, err := r.r.ReadRune()
+		if err == io.EOF {
+			return s.String(), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if unicode.IsSpace(c) || strings.ContainsRune("{}()[]", c) {
+			r.r.UnreadRune()
+			return s.String(), nil
+		}
+		s.WriteRune(c)
+	}
+}
+
+// skipSpaces consumes spaces and returns the next character.
+func (r *Reader) skipSpaces() (rune, error) {
+	for {
+		c, _, err := r.r.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		if c == '\n' {
+			r.line++
+		}
+		if !unicode.IsSpace(c) {
+			return c, nil
+		}
+	}
+}
+
+func (r *Reader) errorf(format string, a ...interface{}) error {
+	err := fmt.Errorf(format, a...)
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return fmt.Errorf("line %d: %w", r.line, err)
+}
+
+func joinComments(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + " " + b
+}
@@ -0,0 +1,115 @@
+package record_test
+
+import (
+	"g4"
+	"g4/record"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	in := `[Yellow "alice"]
+[Red "bob \"the builder\""]
+[Date "2023.07.14"]
+[Variant "standard"]
+[Result "1-0"]
+[Reason "connect-4"]
+[Event "office ladder [round 2]"]
+
+{a friendly game} 1. 4 5 2. L {tilting early} 4 (2... 3 3. D (3. 1) 3) 3. 4
+R 4. 1 1-0
+
+[Mover "red"]
+
+1... 2 2. D *
+`
+	r := record.NewReader(strings.NewReader(in))
+
+	got, err := r.Read()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := &record.Record{
+		Yellow:  "alice",
+		Red:     `bob "the builder"`,
+		Date:    "2023.07.14",
+		Variant: "standard",
+		Result:  record.YellowWins,
+		Reason:  record.Connect4,
+		Tags:    []record.Tag{{Name: "Event", Value: "office ladder [round 2]"}},
+		Comment: "a friendly game",
+		Moves: []record.Node{
+			{Move: g4.TokenMove(g4.Yellow, 3)},
+			{Move: g4.TokenMove(g4.Red, 4)},
+			{Move: g4.TiltMove(g4.Yellow, g4.LEFT), Comment: "tilting early"},
+			{
+				Move: g4.TokenMove(g4.Red, 3),
+				Variations: [][]record.Node{{
+					{Move: g4.TokenMove(g4.Red, 2)},
+					{
+						Move: g4.TiltMove(g4.Yellow, g4.DOWN),
+						Variations: [][]record.Node{{
+							{Move: g4.TokenMove(g4.Yellow, 0)},
+						}},
+					},
+					{Move: g4.TokenMove(g4.Red, 2)},
+				}},
+			},
+			{Move: g4.TokenMove(g4.Yellow, 3)},
+			{Move: g4.TiltMove(g4.Red, g4.RIGHT)},
+			{Move: g4.TokenMove(g4.Yellow, 0)},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("first record: got %+v but want %+v", got, want)
+	}
+
+	got, err = r.Read()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.Moves) != 2 || got.Moves[0].Move != g4.TokenMove(g4.Red, 1) ||
+		got.Moves[1].Move != g4.TiltMove(g4.Yellow, g4.DOWN) || got.Result != record.Unfinished {
+		t.Errorf("second record: got %+v", got)
+	}
+
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("expected io.EOF but got %v", err)
+	}
+}
+
+func TestReadError(t *testing.T) {
+	examples := []string{
+		`[Yellow "alice"`,
+		`[Yellow alice]`,
+		`[Mover "blue"]`,
+		`[Result "1-0"] 1. 4 0-1`,
+		`1. 4 {unterminated`,
+		`1. 4 (1... 5`,
+		`1. 4 ) 5`,
+		`(1. 4) 5`,
+		`1. 9`,
+		`1. 4 (4 1-0) *`,
+	}
+	for k, ex := range examples {
+		if rec, err := record.NewReader(strings.NewReader(ex)).Read(); err == nil {
+			t.Errorf("example %d: got (%v, %v) but expected error", k, rec, err)
+		}
+	}
+}
+
+func TestReadAll(t *testing.T) {
+	in := "1. 4 5 *\n\n[Yellow \"a\"]\n\n1. L 1/2-1/2\n\n\n"
+	records, err := record.NewReader(strings.NewReader(in)).ReadAll()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records but want 2", len(records))
+	}
+	if records[1].Yellow != "a" || records[1].Result != record.Draw {
+		t.Errorf("wrong second record: %+v", records[1])
+	}
+}
@@ -0,0 +1,213 @@
+// Package record implements a portable text format for g4 game records.
+//
+// The format is inspired by chess PGN. A record starts with a header made of
+// tag pairs, followed by the move list and the result:
+//
+//	[Yellow "alice"]
+//	[Red "bob"]
+//	[Date "2023.07.14"]
+//	[Variant "standard"]
+//	[Result "1-0"]
+//	[Reason "connect-4"]
+//
+//	1. 4 5 2. L {tilting early} 4 (2... 3) 3. 4 R 4. 1 1-0
+//
+// Moves use the notation of g4.Move.String. Comments are written between
+// braces and alternative lines between parentheses, right after the move they
+// replace.
+package record
+
+import (
+	"errors"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"strings"
+)
+
+// Result denotes the final outcome of a recorded game.
+type Result byte
+
+const (
+	Unfinished Result = iota // The game is not over (yet).
+	YellowWins
+	RedWins
+	Draw
+)
+
+// String returns the notation of the result, as used in records.
+func (r Result) String() string {
+	switch r {
+	case YellowWins:
+		return "1-0"
+	case RedWins:
+		return "0-1"
+	case Draw:
+		return "1/2-1/2"
+	default:
+		return "*"
+	}
+}
+
+// parseResult reads a result in record notation.
+func parseResult(s string) (Result, bool) {
+	switch s {
+	case "1-0":
+		return YellowWins, true
+	case "0-1":
+		return RedWins, true
+	case "1/2-1/2":
+		return Draw, true
+	case "*":
+		return Unfinished, true
+	}
+	return Unfinished, false
+}
+
+// Reason explains why a game ended.
+//
+// Any string is valid, but the following constants should be preferred when they apply.
+type Reason string
+
+const (
+	Connect4       Reason = "connect-4"
+	DoubleConnect4 Reason = "double connect-4"
+	FullBoard      Reason = "full board"
+	Repetition     Reason = "3-fold repetition"
+	NoProgress     Reason = "no progress"
+	MoveLimit      Reason = "move limit"
+	Disconnection  Reason = "disconnection"
+)
+
+// Tag is a header entry which does not correspond to any field of Record.
+type Tag struct {
+	Name, Value string
+}
+
+// Node holds a move of the record along with its annotations.
+type Node struct {
+	Move    g4.Move
+	Comment string
+
+	// Variations are alternative lines, starting from the same position as Move.
+	Variations [][]Node
+}
+
+// Record is a complete game record.
+type Record struct {
+	Yellow, Red string
+
+	// Date is written YYYY.MM.DD, unknown parts being replaced by question marks.
+	Date string
+
+	// Variant is the name of the rules, as given by bitsim.Rules.String.
+	// The empty string denotes the standard rules.
+	Variant string
+
+	// Start is the string representation of the initial board.
+	// The empty string denotes bitsim.StartingPosition.
+	Start string
+
+	// Mover denotes the player with the move in the initial position.
+	// Empty color defaults to yellow.
+	Mover g4.Color
+
+	Result Result
+	Reason Reason
+
+	// Tags holds extra header entries, in order of appearance.
+	Tags []Tag
+
+	// Comment is the comment found before the first move.
+	Comment string
+
+	// Moves is the main line of the game.
+	Moves []Node
+}
+
+// StartingGame returns the initial state of the recorded game.
+//
+// It fails if the variant is unknown, or if the start position cannot occur in a game.
+func (r *Record) StartingGame() (bitsim.Game, error) {
+	rules, err := bitsim.ParseRules(r.Variant)
+	if err != nil {
+		return bitsim.Game{}, fmt.Errorf("invalid variant: %w", err)
+	}
+	start := r.Start
+	if start == "" {
+		start = bitsim.StartingPosition
+	}
+	board, err := bitsim.FromString(start)
+	if err != nil {
+		return bitsim.Game{}, fmt.Errorf("invalid start position: %w", err)
+	}
+	if err := board.IsReachable(r.startingMover()); err != nil {
+		return bitsim.Game{}, fmt.Errorf("invalid start position: %w", err)
+	}
+	return bitsim.Game{Board: board, Mover: r.startingMover(), Rules: rules}, nil
+}
+
+// startingMover returns the player with the move in the initial position.
+func (r *Record) startingMover() g4.Color {
+	if r.Mover == g4.Empty {
+		return g4.Yellow
+	}
+	return r.Mover
+}
+
+// Replay plays the main line and returns the successive states of the game.
+//
+// The first element is the initial state, so that the result always has one more
+// element than the main line. It fails if any move is illegal, or if moves are
+// recorded after the end of the game.
+func (r *Record) Replay() ([]bitsim.Game, error) {
+	game, err := r.StartingGame()
+	if err != nil {
+		return nil, err
+	}
+	games := []bitsim.Game{game}
+	for k, node := range r.Moves {
+		if node.Move.Color != game.Mover {
+			return games, fmt.Errorf("move %d (%v): wrong color", k+1, node.Move)
+		}
+		if err := game.Validate(); err != nil {
+			return games, fmt.Errorf("move %d (%v): game is already over: %w", k+1, node.Move, err)
+		}
+		next, err := game.Apply(node.Move)
+		if errors.Is(err, g4.ErrorInvalidMove{}) {
+			return games, fmt.Errorf("move %d (%v): %w", k+1, node.Move, err)
+		}
+		game = next
+		games = append(games, game)
+	}
+	return games, nil
+}
+
+// Conclude returns the result of a game which ended in state `g`.
+//
+// It returns Unfinished if the game is still live.
+func Conclude(g bitsim.Game) (Result, Reason) {
+	switch g.Validate().(type) {
+	case g4.YellowWins:
+		return YellowWins, Connect4
+	case g4.RedWins:
+		return RedWins, Connect4
+	case g4.Draw:
+		if g.Board.Count() < 64 {
+			return Draw, DoubleConnect4
+		}
+		return Draw, FullBoard
+	case g4.DrawByNoProgress:
+		return Draw, NoProgress
+	case g4.DrawByMoveLimit:
+		return Draw, MoveLimit
+	}
+	return Unfinished, ""
+}
+
+// String returns the record in text format.
+func (r *Record) String() string {
+	var s strings.Builder
+	NewWriter(&s).Write(r)
+	return s.String()
+}
@@ -0,0 +1,124 @@
+package record_test
+
+import (
+	"g4"
+	"g4/bitsim"
+	"g4/record"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	rec := &record.Record{
+		Moves: []record.Node{
+			{Move: g4.TokenMove(g4.Yellow, 0)},
+			{Move: g4.TokenMove(g4.Red, 1)},
+			{Move: g4.TokenMove(g4.Yellow, 2)},
+			{Move: g4.TokenMove(g4.Red, 3)},
+			{Move: g4.TiltMove(g4.Yellow, g4.LEFT)},
+		},
+	}
+	games, err := rec.Replay()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(games) != 6 {
+		t.Fatalf("got %d games but want 6", len(games))
+	}
+	if got := games[5].Board.String(); got != "8|8|8|8|8|8|8|yryr4" {
+		t.Errorf("got final board '%s'", got)
+	}
+	if games[5].Mover != g4.Red {
+		t.Errorf("got final mover %v", games[5].Mover)
+	}
+}
+
+func TestReplayError(t *testing.T) {
+	examples := []*record.Record{
+		{Start: "9|8|8|8|8|8|8|8"},
+		{Start: "1y6|8|8|8|8|8|8|8"},
+		{Variant: "blitz"},
+		{Start: "yyyy4|8|8|8|8|8|8|yyyy4", Mover: g4.Red},
+		{Moves: []record.Node{{Move: g4.TokenMove(g4.Red, 0)}}},
+		{
+			Start: "ryryryry|8|8|8|8|8|8|8",
+			Moves: []record.Node{{Move: g4.TokenMove(g4.Yellow, 0)}},
+		},
+		{
+			Start: "yyyy4|8|8|8|8|8|8|8",
+			Mover: g4.Red,
+			Moves: []record.Node{{Move: g4.TokenMove(g4.Red, 1)}},
+		},
+	}
+	for k, ex := range examples {
+		if _, err := ex.Replay(); err == nil {
+			t.Errorf("example %d: expected error", k)
+		}
+	}
+}
+
+func TestReplayVariant(t *testing.T) {
+	rec := &record.Record{
+		Variant: "no-progress=2",
+		Moves: []record.Node{
+			{Move: g4.TiltMove(g4.Yellow, g4.LEFT)},
+			{Move: g4.TiltMove(g4.Red, g4.LEFT)},
+			{Move: g4.TiltMove(g4.Yellow, g4.LEFT)},
+		},
+	}
+	if _, err := rec.Replay(); err == nil {
+		t.Errorf("expected error for move after the end of the game")
+	}
+	rec.Moves = rec.Moves[:2]
+	games, err := rec.Replay()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	result, reason := record.Conclude(games[2])
+	if result != record.Draw || reason != record.NoProgress {
+		t.Errorf("got (%v, %v) but want (%v, %v)", result, reason, record.Draw, record.NoProgress)
+	}
+}
+
+func TestConclude(t *testing.T) {
+	examples := []struct {
+		in     string
+		result record.Result
+		reason record.Reason
+	}{
+		{
+			in:     "8|8|8|8|8|8|8|8",
+			result: record.Unfinished,
+			reason: "",
+		},
+		{
+			in:     "yyyy4|8|8|8|8|8|8|8",
+			result: record.YellowWins,
+			reason: record.Connect4,
+		},
+		{
+			in:     "rrrr4|yryr4|8|8|8|8|8|8",
+			result: record.RedWins,
+			reason: record.Connect4,
+		},
+		{
+			in:     "yyyy4|rrrr4|8|8|8|8|8|8",
+			result: record.Draw,
+			reason: record.DoubleConnect4,
+		},
+		{
+			in:     "ryryryry|ryryryry|ryryryry|yryryryr|yryryryr|yryryryr|ryryryry|ryryryry",
+			result: record.Draw,
+			reason: record.FullBoard,
+		},
+	}
+	for k, ex := range examples {
+		board, err := bitsim.FromString(ex.in)
+		if err != nil {
+			t.Errorf("example %d: error in FromString: %v", k, err)
+		}
+		result, reason := record.Conclude(bitsim.Game{Board: board, Mover: g4.Yellow})
+		if result != ex.result || reason != ex.reason {
+			t.Errorf("example %d: got (%v, %v) but want (%v, %v)", k, result, reason, ex.result, ex.reason)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package record
+
+import (
+	"bufio"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// lineWidth is the width at which the move list gets wrapped.
+const lineWidth = 80
+
+// Writer writes records in text format.
+type Writer struct {
+	w *bufio.Writer
+}
+
+// NewWriter returns a new Writer that writes to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Write writes a single record, followed by an empty line.
+func (w *Writer) Write(r *Record) error {
+	for _, tag := range []Tag{
+		{"Yellow", r.Yellow},
+		{"Red", r.Red},
+		{"Date", r.Date},
+		{"Variant", r.Variant},
+	} {
+		if tag.Value != "" {
+			writeTag(w.w, tag.Name, tag.Value)
+		}
+	}
+	if r.Start != "" && r.Start != bitsim.StartingPosition {
+		writeTag(w.w, "Start", r.Start)
+	}
+	if r.startingMover() != g4.Yellow {
+		writeTag(w.w, "Mover", "red")
+	}
+	writeTag(w.w, "Result", r.Result.String())
+	if r.Reason != "" {
+		writeTag(w.w, "Reason", string(r.Reason))
+	}
+	for _, tag := range r.Tags {
+		writeTag(w.w, tag.Name, tag.Value)
+	}
+	w.w.WriteString("\n")
+
+	// Build the list of words, then wrap them.
+	var words []string
+	if r.Comment != "" {
+		words = append(words, formatComment(r.Comment))
+	}
+	words = appendLine(words, r.Moves, 0, r.startingMover())
+	words = append(words, r.Result.String())
+
+	width := 0
+	for _, word := range words {
+		if width > 0 && width+1+len(word) > lineWidth {
+			w.w.WriteString("\n")
+			width = 0
+		}
+		if width > 0 {
+			w.w.WriteString(" ")
+			width++
+		}
+		w.w.WriteString(word)
+		width += len(word)
+	}
+	w.w.WriteString("\n\n")
+
+	return w.w.Flush()
+}
+
+func writeTag(w *bufio.Writer, name, value string) {
+	fmt.Fprintf(w, "[%s %s]\n", name, strconv.Quote(value))
+}
+
+func formatComment(comment string) string {
+	return "{" + strings.ReplaceAll(comment, "}", ")") + "}"
+}
+
+// appendLine appends the words of a line of moves.
+//
+// `ply` is the number of moves played before the line and `mover` the player
+// making its first move. Move numbers are counted by pairs of moves, as if
+// yellow had played the first move of the game.
+func appendLine(words []string, line []Node, ply int, mover g4.Color) []string {
+	if mover == g4.Red {
+		ply |= 1
+	}
+	needNumber := true
+	for _, node := range line {
+		if ply%2 == 0 {
+			words = append(words, strconv.Itoa(ply/2+1)+".")
+		} else if needNumber {
+			words = append(words, strconv.Itoa(ply/2+1)+"...")
+		}
+		needNumber = false
+		words = append(words, node.Move.String())
+		if node.Comment != "" {
+			words = append(words, formatComment(node.Comment))
+			needNumber = true
+		}
+		for _, variation := range node.Variations {
+			if len(variation) == 0 {
+				continue
+			}
+			start := len(words)
+			words = appendLine(words, variation, ply, node.Move.Color)
+			words[start] = "(" + words[start]
+			words[len(words)-1] += ")"
+			needNumber = true
+		}
+		ply++
+	}
+	return words
+}
@@ -0,0 +1,108 @@
+package record_test
+
+import (
+	"g4"
+	"g4/record"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	rec := &record.Record{
+		Yellow:  "alice",
+		Red:     "bob",
+		Variant: "standard",
+		Result:  record.RedWins,
+		Reason:  record.Connect4,
+		Moves: []record.Node{
+			{Move: g4.TokenMove(g4.Yellow, 3)},
+			{Move: g4.TokenMove(g4.Red, 4), Comment: "solid"},
+			{
+				Move: g4.TiltMove(g4.Yellow, g4.LEFT),
+				Variations: [][]record.Node{{
+					{Move: g4.TokenMove(g4.Yellow, 2)},
+					{Move: g4.TokenMove(g4.Red, 2)},
+				}},
+			},
+			{Move: g4.TokenMove(g4.Red, 7)},
+		},
+	}
+	want := `[Yellow "alice"]
+[Red "bob"]
+[Variant "standard"]
+[Result "0-1"]
+[Reason "connect-4"]
+
+1. 4 5 {solid} 2. L (2. 3 3) 2... 8 0-1
+
+`
+	if got := rec.String(); got != want {
+		t.Errorf("got:\n%s\nbut want:\n%s", got, want)
+	}
+}
+
+func TestWriteStartPosition(t *testing.T) {
+	rec := &record.Record{
+		Start: "y7|8|8|8|8|8|8|8",
+		Mover: g4.Red,
+		Moves: []record.Node{
+			{Move: g4.TokenMove(g4.Red, 0)},
+			{Move: g4.TokenMove(g4.Yellow, 0)},
+		},
+	}
+	want := `[Start "y7|8|8|8|8|8|8|8"]
+[Mover "red"]
+[Result "*"]
+
+1... 1 2. 1 *
+
+`
+	if got := rec.String(); got != want {
+		t.Errorf("got:\n%s\nbut want:\n%s", got, want)
+	}
+}
+
+func TestWriteWraps(t *testing.T) {
+	rec := &record.Record{}
+	color := g4.Yellow
+	for k := 0; k < 100; k++ {
+		rec.Moves = append(rec.Moves, record.Node{Move: g4.TiltMove(color, g4.DOWN)})
+		if color == g4.Yellow {
+			color = g4.Red
+		} else {
+			color = g4.Yellow
+		}
+	}
+	for _, line := range strings.Split(rec.String(), "\n") {
+		if len(line) > 80 {
+			t.Errorf("line too long (%d): %s", len(line), line)
+		}
+	}
+}
+
+// Tests that reading back a written record gives the original record.
+func TestRoundTrip(t *testing.T) {
+	in := `[Yellow "alice"]
+[Date "2023.??.??"]
+[Start "yr6|r7|8|8|8|8|8|8"]
+[Result "1/2-1/2"]
+[Reason "3-fold repetition"]
+[Round "3"]
+
+{starts from a puzzle} 1. R {why not} 1... L (1... 2 2. D) (1... 4) 2. R L 3. R
+L 1/2-1/2
+
+`
+	rec, err := record.NewReader(strings.NewReader(in)).Read()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := rec.String(); got != in {
+		t.Errorf("got:\n%s\nbut want:\n%s", got, in)
+	}
+	again, err := record.NewReader(strings.NewReader(rec.String())).Read()
+	if err != nil || !reflect.DeepEqual(rec, again) {
+		t.Errorf("got (%+v, %v) but want (%+v, nil)", again, err, rec)
+	}
+}
@@ -0,0 +1,303 @@
+package search
+
+import (
+	"context"
+	"g4"
+	"g4/bitsim"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultMovesToGo is the number of moves assumed left when the clock has no time control.
+	defaultMovesToGo = 30
+
+	// moveOverhead is kept on the clock to send the move.
+	moveOverhead = 50 * time.Millisecond
+
+	// minThinkTime is the least time given to a search under a clock.
+	minThinkTime = 10 * time.Millisecond
+
+	// stableIterations is the number of iterations with the same best move
+	// after which the search may stop before the soft limit.
+	stableIterations = 4
+
+	// subscriberBuffer is the capacity of the progress channels.
+	subscriberBuffer = 16
+)
+
+// Clock describes the time the engine may spend on a move.
+type Clock struct {
+	// MoveTime is a fixed thinking time. When set, the other time fields are ignored.
+	MoveTime time.Duration
+
+	// Remaining is the time left on the clock of the engine. Zero means no clock.
+	Remaining time.Duration
+
+	// Increment is added to the clock after each move.
+	Increment time.Duration
+
+	// MovesToGo is the number of moves before the next time control.
+	// Zero means that the remaining time is for the rest of the game.
+	MovesToGo int
+
+	// Depth also limits the depth of the search, on top of the level. Zero means no limit.
+	Depth int
+}
+
+// Limits returns the soft and hard limits of the thinking time.
+//
+// No iteration starts after the soft limit, and the search is interrupted at
+// the hard limit. Both are zero when the time is not limited.
+func (c Clock) Limits() (soft, hard time.Duration) {
+	if c.MoveTime > 0 {
+		return c.MoveTime, c.MoveTime
+	}
+	if c.Remaining <= 0 {
+		return 0, 0
+	}
+
+	available := c.Remaining - moveOverhead
+	if available < minThinkTime {
+		available = minThinkTime
+	}
+	movesToGo := c.MovesToGo
+	if movesToGo <= 0 {
+		movesToGo = defaultMovesToGo
+	}
+
+	soft = available/time.Duration(movesToGo) + c.Increment*3/4
+	hard = 4 * soft
+	// Keep time for the next moves, unless this is the last one before the time control.
+	limit := available
+	if movesToGo > 1 {
+		limit = available / 2
+	}
+	if hard > limit {
+		hard = limit
+	}
+	if soft > hard {
+		soft = hard
+	}
+	return soft, hard
+}
+
+// Info reports the progress of a search.
+type Info struct {
+	Result
+
+	// Elapsed is the time since the start of the search.
+	Elapsed time.Duration
+
+	// Pondering tells whether the search runs on the opponent's time.
+	// The first move of the principal variation is then the expected move of the opponent.
+	Pondering bool
+}
+
+// Controller drives a searcher under time constraints.
+//
+// It decides when to stop thinking, ponders on the opponent's time and
+// publishes the progress of its searches to subscribers. Think, Ponder and
+// StopPondering must be called from a single goroutine.
+type Controller struct {
+	searcher *Searcher
+
+	mu          sync.Mutex
+	level       Level
+	r           *rand.Rand
+	subscribers []chan Info
+	stopPonder  context.CancelFunc
+	ponderDone  chan struct{}
+}
+
+// NewController creates a controller using given searcher, playing at MaxLevel.
+//
+// The searcher must not be used elsewhere while the controller is in use.
+func NewController(searcher *Searcher) *Controller {
+	c := &Controller{searcher: searcher}
+	c.SetLevel(MaxLevel)
+	return c
+}
+
+// SetLevel changes the strength of the engine, and resets its random choices to the seed of the level.
+//
+// It may be called while thinking, and then applies to the next move.
+func (c *Controller) SetLevel(level Level) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.level = level
+	c.r = rand.New(rand.NewSource(level.Seed))
+}
+
+// Level returns the strength of the engine.
+func (c *Controller) Level() Level {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.level
+}
+
+// Subscribe returns a channel receiving the progress of the searches.
+//
+// Updates are dropped when the channel is full, so that slow subscribers do
+// not slow down the search. The channel is closed by Close.
+func (c *Controller) Subscribe() <-chan Info {
+	ch := make(chan Info, subscriberBuffer)
+	c.mu.Lock()
+	c.subscribers = append(c.subscribers, ch)
+	c.mu.Unlock()
+	return ch
+}
+
+func (c *Controller) publish(info Info) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ch := range c.subscribers {
+		select {
+		case ch <- info:
+		default:
+		}
+	}
+}
+
+// Think searches the best move of the mover within the time of the clock.
+//
+// It stops pondering first. Iterative deepening stops at the soft limit of the
+// clock, or earlier if the best move stays the same for a few iterations, and
+// the search is interrupted at the hard limit or when ctx is done.
+//
+// Positions of the opening book, if any, are not searched: a move of the
+// book is chosen at random instead, so that the engine varies its openings.
+//
+// Below MaxLevel, the search is also limited by the level. With a single
+// search thread, the moves of a level are reproducible from its seed.
+func (c *Controller) Think(ctx context.Context, g bitsim.Game, clock Clock) (Result, error) {
+	c.StopPondering()
+
+	soft, hard := clock.Limits()
+	if hard > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, hard)
+		defer cancel()
+	}
+
+	c.mu.Lock()
+	level, r := c.level, c.r
+	c.mu.Unlock()
+
+	depth := c.searcher.levelDepth(level)
+	if clock.Depth > 0 && clock.Depth < depth {
+		depth = clock.Depth
+	}
+
+	start := time.Now()
+	if move, ok := c.bookMove(g, r); ok {
+		result := Result{Move: move, PV: []g4.Move{move}, Book: true}
+		c.publish(Info{Result: result, Elapsed: time.Since(start)})
+		return result, nil
+	}
+	if level.isNoisy() {
+		result, err := c.searcher.chooseMove(ctx, g, level, depth, r)
+		if err == nil {
+			c.publish(Info{Result: result, Elapsed: time.Since(start)})
+		}
+		return result, err
+	}
+
+	var bestMove g4.Move
+	stable := 0
+	return c.searcher.iterate(ctx, g, depth, func(result Result) bool {
+		elapsed := time.Since(start)
+		c.publish(Info{Result: result, Elapsed: elapsed})
+
+		if result.Move == bestMove {
+			stable++
+		} else {
+			bestMove, stable = result.Move, 1
+		}
+		if soft == 0 {
+			return true
+		}
+		if stable >= stableIterations && elapsed >= soft/3 {
+			return false
+		}
+		return elapsed < soft
+	})
+}
+
+// bookMove picks a move of the opening book, if the position is in it.
+//
+// Moves of the book which are not legal, which may happen on collisions of
+// position keys, are ignored.
+func (c *Controller) bookMove(g bitsim.Game, r *rand.Rand) (g4.Move, bool) {
+	if c.searcher.options.Book == nil {
+		return g4.Move{}, false
+	}
+	move, ok := c.searcher.options.Book.Choose(g, r)
+	if !ok {
+		return g4.Move{}, false
+	}
+	moves, err := g.Generate()
+	if err != nil {
+		return g4.Move{}, false
+	}
+	for _, legal := range moves {
+		if legal == move {
+			return move, true
+		}
+	}
+	return g4.Move{}, false
+}
+
+// Ponder starts searching g in the background, on the opponent's time.
+//
+// g is the position after the move of the engine, with the opponent to move.
+// Pondering fills the transposition table so that the next call to Think
+// benefits from it. It runs until StopPondering or Think is called, or until
+// ctx is done.
+//
+// Levels below MaxLevel do not ponder, so that their moves stay reproducible.
+func (c *Controller) Ponder(ctx context.Context, g bitsim.Game) {
+	c.StopPondering()
+	if err := g.Validate(); err != nil || c.Level().isLimited() {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	c.mu.Lock()
+	c.stopPonder, c.ponderDone = cancel, done
+	c.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		start := time.Now()
+		_, _ = c.searcher.Iterate(ctx, g, func(result Result) bool {
+			c.publish(Info{Result: result, Elapsed: time.Since(start), Pondering: true})
+			return true
+		})
+	}()
+}
+
+// StopPondering stops pondering, if any, and waits for the search to return.
+func (c *Controller) StopPondering() {
+	c.mu.Lock()
+	cancel, done := c.stopPonder, c.ponderDone
+	c.stopPonder, c.ponderDone = nil, nil
+	c.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// Close stops pondering and closes the channels of the subscribers.
+func (c *Controller) Close() {
+	c.StopPondering()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ch := range c.subscribers {
+		close(ch)
+	}
+	c.subscribers = nil
+}
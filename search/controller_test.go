@@ -0,0 +1,187 @@
+package search_test
+
+import (
+	"context"
+	"g4"
+	"g4/bitsim"
+	"g4/book"
+	"g4/internal/g4test"
+	"g4/search"
+	"testing"
+	"time"
+)
+
+func TestClockLimits(t *testing.T) {
+	examples := []struct {
+		in   search.Clock
+		soft time.Duration
+		hard time.Duration
+	}{
+		{
+			in:   search.Clock{},
+			soft: 0,
+			hard: 0,
+		},
+		{
+			in:   search.Clock{MoveTime: time.Second, Remaining: time.Minute},
+			soft: time.Second,
+			hard: time.Second,
+		},
+		{
+			in:   search.Clock{Remaining: 30*time.Second + 50*time.Millisecond},
+			soft: time.Second,
+			hard: 4 * time.Second,
+		},
+		{
+			in:   search.Clock{Remaining: 10*time.Second + 50*time.Millisecond, Increment: 4 * time.Second, MovesToGo: 10},
+			soft: 4 * time.Second,
+			hard: 5 * time.Second,
+		},
+		{
+			in:   search.Clock{Remaining: 2*time.Second + 50*time.Millisecond, MovesToGo: 1},
+			soft: 2 * time.Second,
+			hard: 2 * time.Second,
+		},
+		{
+			in:   search.Clock{Remaining: time.Millisecond},
+			soft: 10 * time.Millisecond / 30,
+			hard: 4 * (10 * time.Millisecond / 30),
+		},
+	}
+	for k, ex := range examples {
+		soft, hard := ex.in.Limits()
+		if soft != ex.soft || hard != ex.hard {
+			t.Errorf("example %d: got (%v, %v) but want (%v, %v)", k, soft, hard, ex.soft, ex.hard)
+		}
+	}
+}
+
+func TestControllerThink(t *testing.T) {
+	game := g4test.Game(t, bitsim.StartingPosition, g4.Yellow)
+	c := search.NewController(search.New(search.Options{Threads: 2, TableSize: 1 << 14}))
+	infos := c.Subscribe()
+	received := make(chan []search.Info)
+	go func() {
+		var all []search.Info
+		for info := range infos {
+			all = append(all, info)
+		}
+		received <- all
+	}()
+
+	start := time.Now()
+	result, err := c.Think(context.Background(), game, search.Clock{MoveTime: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("search took %v", elapsed)
+	}
+
+	c.Close()
+	var last search.Info
+	count := 0
+	for _, info := range <-received {
+		if info.Pondering {
+			t.Errorf("got pondering info while thinking")
+		}
+		if info.Depth <= last.Depth {
+			t.Errorf("got depth %d after %d", info.Depth, last.Depth)
+		}
+		last = info
+		count++
+	}
+	if count == 0 {
+		t.Fatalf("got no info")
+	}
+	if last.Move != result.Move || last.Depth != result.Depth {
+		t.Errorf("last info %+v does not match result %+v", last, result)
+	}
+}
+
+func TestControllerStopsEarly(t *testing.T) {
+	// The win is found at once, long before the soft limit.
+	game := g4test.Game(t, "yyy5|rr6|r7|8|8|8|8|8", g4.Yellow)
+	c := search.NewController(search.New(search.Options{TableSize: 1 << 14}))
+	start := time.Now()
+	result, err := c.Think(context.Background(), game, search.Clock{Remaining: time.Hour})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("search took %v", elapsed)
+	}
+	if result.Move != g4.TokenMove(g4.Yellow, 0) {
+		t.Errorf("got move %v", result.Move)
+	}
+}
+
+func TestControllerPonder(t *testing.T) {
+	game := g4test.Game(t, bitsim.StartingPosition, g4.Yellow)
+	c := search.NewController(search.New(search.Options{TableSize: 1 << 14}))
+	infos := c.Subscribe()
+
+	c.Ponder(context.Background(), game)
+	info := <-infos
+	if !info.Pondering {
+		t.Errorf("got info %+v while pondering", info)
+	}
+
+	// Thinking stops pondering.
+	next, _ := game.Apply(g4.TokenMove(g4.Yellow, 3))
+	result, err := c.Think(context.Background(), next, search.Clock{MoveTime: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := next.Apply(result.Move); err != nil {
+		t.Errorf("got illegal move %v: %v", result.Move, err)
+	}
+	c.Close()
+}
+
+func TestControllerBook(t *testing.T) {
+	start := g4test.Game(t, bitsim.StartingPosition, g4.Yellow)
+	b := book.New()
+	b.Add(start, g4.TokenMove(g4.Yellow, 0), nil)
+	b.Add(start, g4.TokenMove(g4.Yellow, 7), nil)
+	c := search.NewController(search.New(search.Options{TableSize: 1 << 14, Book: b}))
+	defer c.Close()
+
+	seen := make(map[g4.Move]bool)
+	for k := 0; k < 20; k++ {
+		result, err := c.Think(context.Background(), start, search.Clock{MoveTime: time.Second})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !result.Book || result.Nodes != 0 {
+			t.Fatalf("got %+v but want a book move", result)
+		}
+		seen[result.Move] = true
+	}
+	if len(seen) != 2 || !seen[g4.TokenMove(g4.Yellow, 0)] || !seen[g4.TokenMove(g4.Yellow, 7)] {
+		t.Errorf("got moves %v but want both book moves", seen)
+	}
+
+	// Out of the book, the controller searches.
+	game := g4test.Game(t, "yyy5|rr6|r7|8|8|8|8|8", g4.Yellow)
+	result, err := c.Think(context.Background(), game, search.Clock{MoveTime: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Book || result.Move != g4.TokenMove(g4.Yellow, 0) {
+		t.Errorf("got %+v but want the winning move from a search", result)
+	}
+}
+
+func TestControllerDepth(t *testing.T) {
+	game := g4test.Game(t, bitsim.StartingPosition, g4.Yellow)
+	c := search.NewController(search.New(search.Options{TableSize: 1 << 14}))
+	defer c.Close()
+	result, err := c.Think(context.Background(), game, search.Clock{Depth: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Depth != 3 {
+		t.Errorf("got depth %d but want 3", result.Depth)
+	}
+}
@@ -0,0 +1,153 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"math/rand"
+	"strings"
+)
+
+// Level weakens the engine to a given strength.
+type Level struct {
+	// Name identifies the level.
+	Name string
+
+	// Depth limits the depth of the search. Zero means no limit.
+	Depth int
+
+	// Noise is the amplitude of the random noise added to the scores of the moves.
+	// Forced wins and losses are never blurred.
+	Noise int
+
+	// MissRate is the probability, on each move, to overlook the replies of the
+	// opponent, and thus to miss its threats.
+	MissRate float64
+
+	// Seed initializes the random choices of the level, so that games can be reproduced.
+	Seed int64
+}
+
+// Levels lists the strength levels, from the weakest to the strongest.
+var Levels = []Level{
+	{Name: "beginner", Depth: 2, Noise: 60, MissRate: 0.3, Seed: 1},
+	{Name: "casual", Depth: 3, Noise: 30, MissRate: 0.15, Seed: 2},
+	{Name: "intermediate", Depth: 4, Noise: 15, MissRate: 0.05, Seed: 3},
+	{Name: "advanced", Depth: 6, Noise: 5, Seed: 4},
+	{Name: "expert", Depth: 10, Seed: 5},
+	{Name: "max", Seed: 6},
+}
+
+// MaxLevel is the level of the engine at full strength.
+var MaxLevel = Levels[len(Levels)-1]
+
+// ParseLevel returns the level of given name.
+func ParseLevel(name string) (Level, error) {
+	for _, level := range Levels {
+		if level.Name == name {
+			return level, nil
+		}
+	}
+	names := make([]string, len(Levels))
+	for k, level := range Levels {
+		names[k] = level.Name
+	}
+	return Level{}, fmt.Errorf("unknown level '%s' (expected one of %s)", name, strings.Join(names, ", "))
+}
+
+// String returns the name of the level.
+func (l Level) String() string {
+	return l.Name
+}
+
+// isLimited returns whether the level is weaker than the full engine.
+func (l Level) isLimited() bool {
+	return l.Depth > 0 || l.isNoisy()
+}
+
+// isNoisy returns whether the level alters the choice of the moves.
+func (l Level) isNoisy() bool {
+	return l.Noise > 0 || l.MissRate > 0
+}
+
+// levelDepth returns the depth limit of a search at given level.
+func (s *Searcher) levelDepth(level Level) int {
+	if level.Depth <= 0 || level.Depth > s.options.Depth {
+		return s.options.Depth
+	}
+	return level.Depth
+}
+
+// MoveScore is the score of a move for the player who plays it.
+type MoveScore struct {
+	Move  g4.Move
+	Score int
+}
+
+// ScoreMoves scores every legal move of g, in the order of g.Generate.
+//
+// Each move is searched on its own, one ply shallower than depth, or only
+// statically evaluated when depth is at most 1. Moves ending the game get the
+// score of their outcome. It also returns the number of positions visited.
+func (s *Searcher) ScoreMoves(ctx context.Context, g bitsim.Game, depth int) ([]MoveScore, int64, error) {
+	moves, err := g.Generate()
+	if err != nil {
+		return nil, 0, err
+	}
+	scores := make([]MoveScore, len(moves))
+	var nodes int64
+	for k, move := range moves {
+		var score int
+		child, err := g.Apply(move)
+		switch {
+		case err != nil:
+			score = outcomeScore(err, g.Mover, 1)
+		case depth <= 1:
+			score = -clampEval(s.options.Evaluator.Evaluate(child))
+			nodes++
+		default:
+			result, _ := s.iterate(ctx, child, depth-1, nil)
+			score = -result.Score
+			nodes += result.Nodes
+			// Forced results are one ply further from g than from child.
+			if score > maxEval {
+				score--
+			} else if score < -maxEval {
+				score++
+			}
+		}
+		scores[k] = MoveScore{Move: move, Score: score}
+	}
+	return scores, nodes, nil
+}
+
+// chooseMove scores every move of g at the level and picks the best noisy score.
+//
+// Moves are scored by ScoreMoves at given depth, unless the level
+// misses threats on this move, in which case they are only statically evaluated.
+func (s *Searcher) chooseMove(ctx context.Context, g bitsim.Game, level Level, depth int, r *rand.Rand) (Result, error) {
+	if _, err := g.Generate(); err != nil {
+		return Result{}, err
+	}
+	if r.Float64() < level.MissRate {
+		depth = 1
+	}
+
+	scores, nodes, err := s.ScoreMoves(ctx, g, depth)
+	if err != nil {
+		return Result{}, err
+	}
+	best := Result{Score: -Mate - 1, Depth: depth, Nodes: nodes}
+	for _, ms := range scores {
+		score := ms.Score
+		if !IsMate(score) && level.Noise > 0 {
+			score += r.Intn(2*level.Noise+1) - level.Noise
+		}
+		if score > best.Score {
+			best.Move, best.Score = ms.Move, score
+		}
+	}
+	best.PV = []g4.Move{best.Move}
+	return best, nil
+}
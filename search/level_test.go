@@ -0,0 +1,125 @@
+package search_test
+
+import (
+	"context"
+	"g4"
+	"g4/bitsim"
+	"g4/internal/g4test"
+	"g4/search"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	for _, level := range search.Levels {
+		got, err := search.ParseLevel(level.Name)
+		if err != nil || got != level {
+			t.Errorf("got (%v, %v) but want (%v, nil)", got, err, level)
+		}
+	}
+	if _, err := search.ParseLevel("grandmaster"); err == nil {
+		t.Errorf("expected error")
+	}
+	if search.MaxLevel.Name != "max" || search.MaxLevel.Depth != 0 || search.MaxLevel.Noise != 0 {
+		t.Errorf("max level is limited: %+v", search.MaxLevel)
+	}
+}
+
+// Tests that levels get stronger in every respect.
+func TestLevelsOrder(t *testing.T) {
+	seeds := make(map[int64]bool)
+	for k, level := range search.Levels {
+		if seeds[level.Seed] {
+			t.Errorf("level %s: seed %d already used", level, level.Seed)
+		}
+		seeds[level.Seed] = true
+		if k == 0 {
+			continue
+		}
+		previous := search.Levels[k-1]
+		if level.Depth != 0 && level.Depth < previous.Depth {
+			t.Errorf("level %s is shallower than %s", level, previous)
+		}
+		if level.Noise > previous.Noise || level.MissRate > previous.MissRate {
+			t.Errorf("level %s is noisier than %s", level, previous)
+		}
+	}
+}
+
+// playLine lets the controller play both sides for a few moves.
+func playLine(t *testing.T, c *search.Controller, plies int) []g4.Move {
+	game := g4test.Game(t, bitsim.StartingPosition, g4.Yellow)
+	var moves []g4.Move
+	for k := 0; k < plies; k++ {
+		result, err := c.Think(context.Background(), game, search.Clock{})
+		if err != nil {
+			break
+		}
+		moves = append(moves, result.Move)
+		if game, err = game.Apply(result.Move); err != nil {
+			break
+		}
+	}
+	return moves
+}
+
+func TestLevelReproducible(t *testing.T) {
+	level, _ := search.ParseLevel("beginner")
+	var lines [2][]g4.Move
+	for k := range lines {
+		c := search.NewController(search.New(search.Options{TableSize: 1 << 14}))
+		c.SetLevel(level)
+		lines[k] = playLine(t, c, 8)
+	}
+	if len(lines[0]) != 8 {
+		t.Fatalf("got %d moves but want 8", len(lines[0]))
+	}
+	for k := range lines[0] {
+		if lines[0][k] != lines[1][k] {
+			t.Fatalf("got different lines: %v and %v", lines[0], lines[1])
+		}
+	}
+}
+
+func TestLevelTakesWins(t *testing.T) {
+	game := g4test.Game(t, "yyy5|rr6|r7|8|8|8|8|8", g4.Yellow)
+	for _, level := range search.Levels {
+		c := search.NewController(search.New(search.Options{TableSize: 1 << 14}))
+		c.SetLevel(level)
+		result, err := c.Think(context.Background(), game, search.Clock{})
+		if err != nil {
+			t.Fatalf("level %s: unexpected error: %v", level, err)
+		}
+		if result.Move != g4.TokenMove(g4.Yellow, 0) {
+			t.Errorf("level %s: got move %v", level, result.Move)
+		}
+	}
+}
+
+func TestLevelMissesThreats(t *testing.T) {
+	game := g4test.Game(t, "rrr5|yy6|y7|8|8|8|8|8", g4.Yellow)
+	c := search.NewController(search.New(search.Options{TableSize: 1 << 14}))
+	c.SetLevel(search.Level{Name: "blind", Depth: 4, MissRate: 1})
+	result, err := c.Think(context.Background(), game, search.Clock{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Depth != 1 {
+		t.Errorf("got depth %d but want 1", result.Depth)
+	}
+	if got := c.Level().Name; got != "blind" {
+		t.Errorf("got level %s", got)
+	}
+}
+
+func TestLevelClockDepth(t *testing.T) {
+	game := g4test.Game(t, "yr6|ry6|y7|8|r7|8|8|8", g4.Yellow)
+	c := search.NewController(search.New(search.Options{TableSize: 1 << 14}))
+	c.SetLevel(search.Level{Name: "noisy", Depth: 4, Noise: 10})
+	result, err := c.Think(context.Background(), game, search.Clock{Depth: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Depth != 2 {
+		t.Errorf("got depth %d but want 2", result.Depth)
+	}
+}
@@ -0,0 +1,69 @@
+package search
+
+import (
+	"g4"
+)
+
+// columnOrder lists token moves from the centre to the edges.
+var columnOrder = [8]int{3, 4, 2, 5, 1, 6, 0, 7}
+
+// moveCode returns a code between 1 and 11 identifying the move, regardless of its color.
+//
+// Token moves are coded by their column (1 to 8) and tilts by 9 (left), 10 (down) and 11 (right).
+func moveCode(move g4.Move) int {
+	if move.Type == g4.Tilt {
+		return 9 + int(move.Direction-g4.LEFT)
+	}
+	return 1 + move.Column
+}
+
+// orderMoves sorts moves in place for the search.
+//
+// The hinted move (from the transposition table) comes first, then token moves
+// from the centre to the edges, then tilts. A non-zero shift rotates the moves
+// which follow the hint, so that helper threads explore the tree in different orders.
+func orderMoves(moves []g4.Move, hint int, shift int) {
+	var ordered [11]g4.Move
+	n := 0
+	for _, column := range columnOrder {
+		for _, move := range moves {
+			if move.Type == g4.Token && move.Column == column {
+				ordered[n] = move
+				n++
+			}
+		}
+	}
+	for _, move := range moves {
+		if move.Type == g4.Tilt {
+			ordered[n] = move
+			n++
+		}
+	}
+
+	first := 0
+	for k := 0; k < n; k++ {
+		if moveCode(ordered[k]) == hint {
+			hinted := ordered[k]
+			copy(ordered[1:k+1], ordered[:k])
+			ordered[0] = hinted
+			first = 1
+			break
+		}
+	}
+	if rest := n - first; shift != 0 && rest > 1 {
+		var tail [11]g4.Move
+		copy(tail[:], ordered[first:n])
+		for k := 0; k < rest; k++ {
+			ordered[first+k] = tail[(k+shift)%rest]
+		}
+	}
+	copy(moves, ordered[:n])
+}
+
+// codeMove returns the move of given code for a player.
+func codeMove(code int, color g4.Color) g4.Move {
+	if code >= 9 {
+		return g4.TiltMove(color, g4.LEFT+g4.Direction(code-9))
+	}
+	return g4.TokenMove(color, code-1)
+}
@@ -0,0 +1,214 @@
+// Package search implements an alpha-beta engine for g4.
+//
+// The search is a negamax with iterative deepening and a transposition table.
+// It can run on several goroutines sharing the table (lazy SMP): every thread
+// searches the same root, and the helpers fill the table with results the main
+// thread reuses. With a single thread, the search is deterministic.
+package search
+
+import (
+	"context"
+	"g4"
+	"g4/bitsim"
+	"g4/book"
+	"g4/eval"
+	"g4/tablebase"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// Mate is the score of a won game. A win in n plies scores Mate-n.
+	Mate = 1000000
+
+	// MaxDepth is the greatest search depth.
+	MaxDepth = 64
+
+	// maxEval bounds static evaluations, so that they never look like mates.
+	maxEval = Mate / 2
+
+	// DefaultTableSize is the default number of slots of the transposition table.
+	DefaultTableSize = 1 << 20
+)
+
+// IsMate returns whether a score is a forced win or loss.
+func IsMate(score int) bool {
+	return score > maxEval || score < -maxEval
+}
+
+// Options configures a searcher.
+type Options struct {
+	// Threads is the number of search goroutines. It defaults to 1.
+	Threads int
+
+	// Depth limits the depth of the search. It defaults to MaxDepth.
+	Depth int
+
+	// TableSize is the number of slots of the transposition table.
+	// It defaults to DefaultTableSize.
+	TableSize int
+
+	// Evaluator scores the leaves of the search. It defaults to eval.New(nil).
+	Evaluator eval.Evaluator
+
+	// Tablebase gives the exact value of the positions it holds, if not nil.
+	Tablebase *tablebase.Table
+
+	// Book gives the moves of the controller in the positions it holds, if
+	// not nil. Searches ignore it.
+	Book *book.Book
+}
+
+// Result is the outcome of a search.
+type Result struct {
+	// Move is the best move found.
+	Move g4.Move
+
+	// Score is the score of the best move for the mover.
+	Score int
+
+	// Depth is the depth of the last completed iteration.
+	Depth int
+
+	// Nodes is the number of positions visited by all threads.
+	Nodes int64
+
+	// PV is the principal variation, starting with Move.
+	PV []g4.Move
+
+	// Book tells whether the move was taken from the opening book, without searching.
+	Book bool
+}
+
+// Searcher looks for the best move in a position.
+//
+// A searcher keeps its transposition table from one search to the next. It
+// must not run two searches at the same time.
+type Searcher struct {
+	options Options
+	table   *table
+}
+
+// New creates a searcher.
+func New(options Options) *Searcher {
+	if options.Threads <= 0 {
+		options.Threads = 1
+	}
+	if options.Depth <= 0 || options.Depth > MaxDepth {
+		options.Depth = MaxDepth
+	}
+	if options.TableSize <= 0 {
+		options.TableSize = DefaultTableSize
+	}
+	if options.Evaluator == nil {
+		options.Evaluator = eval.New(nil)
+	}
+	return &Searcher{options: options, table: newTable(options.TableSize)}
+}
+
+// DefaultThreads returns a number of threads suited to the machine.
+func DefaultThreads() int {
+	return runtime.NumCPU()
+}
+
+// Clear empties the transposition table.
+func (s *Searcher) Clear() {
+	s.table.clear()
+}
+
+// Search looks for the best move of the mover.
+//
+// It deepens the search until the depth limit, or until a forced result is
+// found, or until ctx is done. In the latter case it returns the result of
+// the last completed iteration. The first iteration always completes, so
+// that a move is returned even if ctx is already done.
+//
+// It fails if the game is over.
+func (s *Searcher) Search(ctx context.Context, g bitsim.Game) (Result, error) {
+	return s.Iterate(ctx, g, nil)
+}
+
+// Iterate works like Search, but calls onIteration after each completed iteration.
+//
+// The search stops if onIteration returns false. Results given to onIteration
+// count the nodes visited so far by all threads.
+func (s *Searcher) Iterate(ctx context.Context, g bitsim.Game, onIteration func(Result) bool) (Result, error) {
+	return s.iterate(ctx, g, s.options.Depth, onIteration)
+}
+
+// iterate works like Iterate with given depth limit.
+func (s *Searcher) iterate(ctx context.Context, g bitsim.Game, depth int, onIteration func(Result) bool) (Result, error) {
+	if err := g.Validate(); err != nil {
+		return Result{}, err
+	}
+
+	var stop int32
+	var nodes int64
+	workers := make([]*worker, s.options.Threads)
+	for id := range workers {
+		workers[id] = &worker{
+			id:          id,
+			table:       s.table,
+			evaluator:   s.options.Evaluator,
+			tablebase:   s.options.Tablebase,
+			stop:        &stop,
+			sharedNodes: &nodes,
+		}
+	}
+
+	// Stop all threads when ctx is done or when the main thread is finished.
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			atomic.StoreInt32(&stop, 1)
+		case <-done:
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for _, w := range workers[1:] {
+		wg.Add(1)
+		go func(w *worker) {
+			defer wg.Done()
+			w.iterate(g, depth, nil)
+		}(w)
+	}
+	var report func(Result) bool
+	if onIteration != nil {
+		report = func(result Result) bool {
+			result.Nodes = atomic.LoadInt64(&nodes)
+			result.PV = s.principalVariation(g, result.Move, result.Depth)
+			return onIteration(result)
+		}
+	}
+	result := workers[0].iterate(g, depth, report)
+	atomic.StoreInt32(&stop, 1)
+	close(done)
+	wg.Wait()
+
+	result.Nodes = nodes
+	result.PV = s.principalVariation(g, result.Move, result.Depth)
+	return result, nil
+}
+
+// principalVariation follows the best moves stored in the transposition table.
+func (s *Searcher) principalVariation(g bitsim.Game, first g4.Move, depth int) []g4.Move {
+	pv := []g4.Move{first}
+	seen := map[uint64]bool{g.Hash(): true}
+	g, err := g.Apply(first)
+	for err == nil && len(pv) < depth {
+		e, ok := s.table.probe(g.Hash())
+		if !ok || e.move == 0 || seen[g.Hash()] {
+			break
+		}
+		seen[g.Hash()] = true
+		move := codeMove(e.move, g.Mover)
+		if g, err = g.Apply(move); err != nil && !isOutcome(err) {
+			break
+		}
+		pv = append(pv, move)
+	}
+	return pv
+}
@@ -0,0 +1,183 @@
+package search_test
+
+import (
+	"context"
+	"g4"
+	"g4/bitsim"
+	"g4/internal/g4test"
+	"g4/search"
+	"g4/solver"
+	"g4/tablebase"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestSearchWins(t *testing.T) {
+	examples := []struct {
+		in    string
+		mover g4.Color
+		move  g4.Move
+		score int
+	}{
+		{
+			in:    "yyy5|rr6|r7|8|8|8|8|8",
+			mover: g4.Yellow,
+			move:  g4.TokenMove(g4.Yellow, 0),
+			score: search.Mate - 1,
+		},
+		{
+			in:    "yyy5|rrr5|8|8|8|8|8|8",
+			mover: g4.Red,
+			move:  g4.TokenMove(g4.Red, 1),
+			score: search.Mate - 1,
+		},
+	}
+	for k, ex := range examples {
+		for _, threads := range []int{1, 4} {
+			s := search.New(search.Options{Threads: threads, Depth: 4, TableSize: 1 << 12})
+			result, err := s.Search(context.Background(), g4test.Game(t, ex.in, ex.mover))
+			if err != nil {
+				t.Fatalf("example %d: unexpected error: %v", k, err)
+			}
+			if result.Move != ex.move || result.Score != ex.score {
+				t.Errorf("example %d (%d threads): got (%v, %d) but want (%v, %d)",
+					k, threads, result.Move, result.Score, ex.move, ex.score)
+			}
+			if len(result.PV) == 0 || result.PV[0] != result.Move {
+				t.Errorf("example %d: PV %v does not start with %v", k, result.PV, result.Move)
+			}
+		}
+	}
+}
+
+func TestIterateReportsForcedResult(t *testing.T) {
+	game := g4test.Game(t, "yyy5|rr6|r7|8|8|8|8|8", g4.Yellow)
+	s := search.New(search.Options{Depth: 6, TableSize: 1 << 12})
+	var results []search.Result
+	result, err := s.Iterate(context.Background(), game, func(r search.Result) bool {
+		results = append(results, r)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) == 0 {
+		t.Fatalf("got no iteration reported")
+	}
+	last := results[len(results)-1]
+	if last.Move != result.Move || last.Score != result.Score || last.Depth != result.Depth {
+		t.Errorf("got last iteration %+v but want the result %+v", last, result)
+	}
+	if result.Score != search.Mate-1 || result.Depth != 1 {
+		t.Errorf("got score %d at depth %d but want %d at depth 1", result.Score, result.Depth, search.Mate-1)
+	}
+}
+
+// Tests that the mover avoids an immediate loss.
+func TestSearchDefends(t *testing.T) {
+	game := g4test.Game(t, "rrr5|yy6|y7|8|8|8|8|8", g4.Yellow)
+	s := search.New(search.Options{Depth: 3, TableSize: 1 << 12})
+	result, err := s.Search(context.Background(), game)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if search.IsMate(result.Score) {
+		t.Errorf("got mate score %d after %v", result.Score, result.Move)
+	}
+}
+
+func TestSearchDeterministic(t *testing.T) {
+	game := g4test.Game(t, "yr6|ry6|y7|8|r7|8|8|8", g4.Yellow)
+	var results []search.Result
+	for k := 0; k < 2; k++ {
+		s := search.New(search.Options{Depth: 4, TableSize: 1 << 14})
+		result, err := s.Search(context.Background(), game)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		results = append(results, result)
+	}
+	a, b := results[0], results[1]
+	if a.Move != b.Move || a.Score != b.Score || a.Nodes != b.Nodes || a.Depth != 4 {
+		t.Errorf("got different results: %+v and %+v", a, b)
+	}
+}
+
+func TestSearchCancel(t *testing.T) {
+	game := g4test.Game(t, bitsim.StartingPosition, g4.Yellow)
+	s := search.New(search.Options{Threads: 2, TableSize: 1 << 14})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := s.Search(ctx, game)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Depth < 1 {
+		t.Errorf("got depth %d but want at least 1", result.Depth)
+	}
+	if _, err := game.Apply(result.Move); err != nil {
+		t.Errorf("got illegal move %v: %v", result.Move, err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := s.Search(ctx, game); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("search took %v after cancellation", elapsed)
+	}
+}
+
+func TestSearchGameOver(t *testing.T) {
+	s := search.New(search.Options{TableSize: 1 << 10})
+	if _, err := s.Search(context.Background(), g4test.Game(t, "yyyy4|8|8|8|8|8|8|8", g4.Red)); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func BenchmarkSearch(b *testing.B) {
+	game := g4test.Game(b, bitsim.StartingPosition, g4.Yellow)
+	for k := 0; k < b.N; k++ {
+		s := search.New(search.Options{Depth: 5, TableSize: 1 << 16})
+		_, _ = s.Search(context.Background(), game)
+	}
+}
+
+func TestSearchTablebase(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var seeds []bitsim.Game
+	for len(seeds) < 10 {
+		g, ok := tablebase.RandomSeed(r, 6)
+		if !ok {
+			t.Fatalf("no seed found")
+		}
+		seeds = append(seeds, g)
+	}
+	table, err := tablebase.Generate(seeds, 6, 100000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for k, g := range seeds {
+		value, _ := table.Probe(g)
+		s := search.New(search.Options{Depth: 2, TableSize: 1 << 12, Tablebase: table})
+		result, err := s.Search(context.Background(), g)
+		if err != nil {
+			t.Fatalf("seed %d: unexpected error: %v", k, err)
+		}
+		var want int
+		switch value.Outcome {
+		case solver.Win:
+			want = search.Mate - value.Plies
+		case solver.Loss:
+			want = -search.Mate + value.Plies
+		}
+		if result.Score != want {
+			t.Errorf("seed %d: got score %d but the table says %v", k, result.Score, value)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package search
+
+import (
+	"sync/atomic"
+)
+
+// Bounds of the scores stored in the transposition table.
+const (
+	exact = iota + 1
+	lower
+	upper
+)
+
+// table is a transposition table shared by the search threads.
+//
+// It is lock-free: each slot is made of two 64-bit words, the data and the key
+// xored with the data. Both words are written and read atomically but not
+// together, so a slot torn by concurrent writes fails the key check and reads
+// as a miss instead of returning corrupted data.
+type table struct {
+	slots []slot
+	mask  uint64
+}
+
+type slot struct {
+	check uint64 // key ^ data
+	data  uint64
+}
+
+// entry is the content of a slot.
+type entry struct {
+	score int
+	depth int
+	bound int
+	move  int // move code, 0 when unknown
+}
+
+// newTable creates a table with the greatest power of two of slots not above size.
+func newTable(size int) *table {
+	n := 1
+	for n*2 <= size {
+		n *= 2
+	}
+	return &table{slots: make([]slot, n), mask: uint64(n - 1)}
+}
+
+// probe returns the entry stored for given key, if any.
+func (t *table) probe(key uint64) (entry, bool) {
+	s := &t.slots[key&t.mask]
+	data := atomic.LoadUint64(&s.data)
+	check := atomic.LoadUint64(&s.check)
+	if check^data != key || data == 0 {
+		return entry{}, false
+	}
+	return unpack(data), true
+}
+
+// store saves an entry for given key.
+//
+// Entries of another position are always replaced, entries of the same
+// position only by searches at least as deep.
+func (t *table) store(key uint64, e entry) {
+	s := &t.slots[key&t.mask]
+	old := atomic.LoadUint64(&s.data)
+	if atomic.LoadUint64(&s.check)^old == key && unpack(old).depth > e.depth {
+		return
+	}
+	data := pack(e)
+	atomic.StoreUint64(&s.data, data)
+	atomic.StoreUint64(&s.check, key^data)
+}
+
+// clear empties the table.
+//
+// It must not be called during a search.
+func (t *table) clear() {
+	for k := range t.slots {
+		t.slots[k] = slot{}
+	}
+}
+
+// pack encodes an entry on 64 bits.
+//
+// The score takes the lower 32 bits, followed by 8 bits of depth, 2 bits of
+// bound and 4 bits of move code. Bounds are never 0, so no packed entry is 0.
+func pack(e entry) uint64 {
+	return uint64(uint32(int32(e.score))) |
+		uint64(e.depth&0xff)<<32 |
+		uint64(e.bound&0x3)<<40 |
+		uint64(e.move&0xf)<<42
+}
+
+func unpack(data uint64) entry {
+	return entry{
+		score: int(int32(uint32(data))),
+		depth: int(data >> 32 & 0xff),
+		bound: int(data >> 40 & 0x3),
+		move:  int(data >> 42 & 0xf),
+	}
+}
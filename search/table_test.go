@@ -0,0 +1,73 @@
+package search
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestPack(t *testing.T) {
+	examples := []entry{
+		{score: 0, depth: 0, bound: exact, move: 0},
+		{score: -Mate, depth: 1, bound: lower, move: 11},
+		{score: Mate - 3, depth: 255, bound: upper, move: 5},
+		{score: -12, depth: 7, bound: exact, move: 9},
+	}
+	for k, ex := range examples {
+		if got := unpack(pack(ex)); got != ex {
+			t.Errorf("example %d: got %+v but want %+v", k, got, ex)
+		}
+	}
+}
+
+func TestTable(t *testing.T) {
+	tt := newTable(1000)
+	if len(tt.slots) != 512 {
+		t.Errorf("got %d slots but want 512", len(tt.slots))
+	}
+
+	if _, ok := tt.probe(42); ok {
+		t.Errorf("empty table should miss")
+	}
+	tt.store(42, entry{score: 10, depth: 3, bound: exact, move: 2})
+	if e, ok := tt.probe(42); !ok || e.score != 10 {
+		t.Errorf("got (%+v, %v)", e, ok)
+	}
+	if _, ok := tt.probe(42 + 512); ok {
+		t.Errorf("another key in the same slot should miss")
+	}
+
+	// Shallower entries do not replace deeper ones of the same position.
+	tt.store(42, entry{score: 20, depth: 2, bound: exact})
+	if e, _ := tt.probe(42); e.score != 10 {
+		t.Errorf("got score %d but want 10", e.score)
+	}
+	tt.store(42+512, entry{score: 30, depth: 1, bound: lower})
+	if e, ok := tt.probe(42 + 512); !ok || e.score != 30 {
+		t.Errorf("got (%+v, %v)", e, ok)
+	}
+
+	tt.clear()
+	if _, ok := tt.probe(42 + 512); ok {
+		t.Errorf("cleared table should miss")
+	}
+}
+
+// Tests that concurrent accesses never return an entry stored for another key.
+func TestTableConcurrent(t *testing.T) {
+	tt := newTable(16)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for k := 0; k < 10000; k++ {
+				key := uint64(k%64 + 1)
+				tt.store(key, entry{score: int(key), depth: w, bound: exact})
+				if e, ok := tt.probe(key); ok && e.score != int(key) {
+					t.Errorf("got score %d for key %d", e.score, key)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+}
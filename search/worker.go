@@ -0,0 +1,234 @@
+package search
+
+import (
+	"errors"
+	"g4"
+	"g4/bitsim"
+	"g4/eval"
+	"g4/solver"
+	"g4/tablebase"
+	"sync/atomic"
+)
+
+// worker is a search thread.
+type worker struct {
+	id        int
+	table     *table
+	evaluator eval.Evaluator
+	tablebase *tablebase.Table
+	stop      *int32
+
+	// nodes counts the positions visited by this worker, and sharedNodes by
+	// all workers. Nodes are added to sharedNodes by batches.
+	nodes         int64
+	sharedNodes   *int64
+	interruptible bool
+	aborted       bool
+
+	// path holds the hashes of the positions between the root and the current node.
+	path []uint64
+
+	rootMove g4.Move
+}
+
+// iterate runs iterative deepening up to maxDepth and returns the last completed iteration.
+//
+// After each iteration, including the one proving a forced result, it calls
+// onIteration if not nil, and stops if it returns false.
+//
+// Helper threads (id > 0) search odd iterations one ply deeper and in a
+// different move order, so that they do not all duplicate the main thread.
+func (w *worker) iterate(g bitsim.Game, maxDepth int, onIteration func(Result) bool) Result {
+	var result Result
+	for depth := 1; depth <= maxDepth; depth++ {
+		searchDepth := depth
+		if w.id > 0 && depth%2 == 1 && depth < maxDepth {
+			searchDepth++
+		}
+		score := w.negamax(g, searchDepth, 0, -Mate-1, Mate+1)
+		if w.aborted {
+			break
+		}
+		w.interruptible = true
+		result = Result{Move: w.rootMove, Score: score, Depth: searchDepth}
+
+		if onIteration != nil && !onIteration(result) {
+			break
+		}
+		// No need to search deeper once the result is forced.
+		if IsMate(score) && Mate-abs(score) <= searchDepth {
+			break
+		}
+	}
+	atomic.AddInt64(w.sharedNodes, w.nodes&1023)
+	return result
+}
+
+// negamax returns the score of g for its mover, searching depth plies deep.
+//
+// When the search is stopped, it sets w.aborted and returns a meaningless score.
+func (w *worker) negamax(g bitsim.Game, depth, ply, alpha, beta int) int {
+	w.nodes++
+	if w.nodes&1023 == 0 {
+		atomic.AddInt64(w.sharedNodes, 1024)
+		if w.interruptible && atomic.LoadInt32(w.stop) != 0 {
+			w.aborted = true
+		}
+	}
+	if w.aborted {
+		return 0
+	}
+
+	// Positions repeated along the path are scored as draws.
+	hash := g.Hash()
+	for _, previous := range w.path {
+		if previous == hash {
+			return 0
+		}
+	}
+
+	if w.tablebase != nil && ply > 0 {
+		if value, ok := w.tablebase.Probe(g); ok {
+			return tablebaseScore(value, ply)
+		}
+	}
+
+	if depth <= 0 {
+		return clampEval(w.evaluator.Evaluate(g))
+	}
+
+	originalAlpha := alpha
+	hint := 0
+	if e, ok := w.table.probe(hash); ok {
+		hint = e.move
+		if ply > 0 && e.depth >= depth {
+			score := fromTable(e.score, ply)
+			switch {
+			case e.bound == exact,
+				e.bound == lower && score >= beta,
+				e.bound == upper && score <= alpha:
+				return score
+			}
+		}
+	}
+
+	moves, _ := g.Generate()
+	orderMoves(moves, hint, w.id)
+
+	w.path = append(w.path, hash)
+	best, bestMove := -Mate-1, moves[0]
+	for _, move := range moves {
+		var score int
+		child, err := g.Apply(move)
+		if err == nil {
+			score = -w.negamax(child, depth-1, ply+1, -beta, -alpha)
+		} else {
+			score = outcomeScore(err, g.Mover, ply+1)
+		}
+		if w.aborted {
+			break
+		}
+		if score > best {
+			best, bestMove = score, move
+		}
+		if score > alpha {
+			alpha = score
+		}
+		if alpha >= beta {
+			break
+		}
+	}
+	w.path = w.path[:len(w.path)-1]
+	if w.aborted {
+		return 0
+	}
+
+	bound := exact
+	if best <= originalAlpha {
+		bound = upper
+	} else if best >= beta {
+		bound = lower
+	}
+	w.table.store(hash, entry{
+		score: toTable(best, ply),
+		depth: depth,
+		bound: bound,
+		move:  moveCode(bestMove),
+	})
+	if ply == 0 {
+		w.rootMove = bestMove
+	}
+	return best
+}
+
+// outcomeScore returns the score of a finished game for the player who made the last move.
+func outcomeScore(err error, mover g4.Color, ply int) int {
+	switch err.(type) {
+	case g4.YellowWins:
+		if mover == g4.Yellow {
+			return Mate - ply
+		}
+		return -Mate + ply
+	case g4.RedWins:
+		if mover == g4.Red {
+			return Mate - ply
+		}
+		return -Mate + ply
+	}
+	return 0
+}
+
+// tablebaseScore converts the value of a position at given ply into a score.
+func tablebaseScore(value tablebase.Value, ply int) int {
+	switch value.Outcome {
+	case solver.Win:
+		return Mate - ply - value.Plies
+	case solver.Loss:
+		return -Mate + ply + value.Plies
+	}
+	return 0
+}
+
+// isOutcome returns whether an error returned by Apply denotes the end of the game.
+func isOutcome(err error) bool {
+	return err != nil && !errors.Is(err, g4.ErrorInvalidMove{})
+}
+
+// toTable converts a mate score relative to the root into one relative to the node.
+func toTable(score, ply int) int {
+	switch {
+	case score > maxEval:
+		return score + ply
+	case score < -maxEval:
+		return score - ply
+	}
+	return score
+}
+
+// fromTable converts a mate score relative to the node into one relative to the root.
+func fromTable(score, ply int) int {
+	switch {
+	case score > maxEval:
+		return score - ply
+	case score < -maxEval:
+		return score + ply
+	}
+	return score
+}
+
+func clampEval(score int) int {
+	switch {
+	case score > maxEval:
+		return maxEval
+	case score < -maxEval:
+		return -maxEval
+	}
+	return score
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
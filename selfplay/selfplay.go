@@ -0,0 +1,288 @@
+// Package selfplay generates training data from games of the engine against itself.
+//
+// Every position of a game after its random opening becomes a sample: the
+// position, a probability distribution over its moves derived from the scores
+// of the engine, and the final outcome of the game. Games are played in
+// parallel, and the data is reproducible from a seed.
+package selfplay
+
+import (
+	"context"
+	"errors"
+	"g4"
+	"g4/bitsim"
+	"g4/eval"
+	"g4/search"
+	"math"
+	"math/rand"
+	"runtime"
+	"sync"
+)
+
+const (
+	// DefaultDepth is the default search depth used to score moves.
+	DefaultDepth = 4
+
+	// DefaultTemperature is the default temperature of move distributions.
+	DefaultTemperature = 20
+
+	// DefaultMoveLimit is the default number of moves after which games are drawn.
+	DefaultMoveLimit = 200
+
+	// PolicySize is the number of possible moves: 8 tokens and 3 tilts.
+	PolicySize = 11
+)
+
+// Sample is a position of a self-play game.
+type Sample struct {
+	// Game is the position. Its counters are those of the self-play game.
+	Game bitsim.Game
+
+	// Policy gives the probability of each move, indexed by PolicyIndex. Illegal moves have probability 0.
+	Policy [PolicySize]float32
+
+	// Outcome is the final result of the game for the mover: 1 for a win, 0 for a draw and -1 for a loss.
+	Outcome int
+
+	// GameIndex is the number of the game, starting at 0, and Ply the number of moves played before the position.
+	GameIndex, Ply int
+}
+
+// PolicyIndex returns the index of a move in policies: 0 to 7 for token moves
+// in columns 1 to 8, then 8, 9 and 10 for tilts left, down and right.
+func PolicyIndex(move g4.Move) int {
+	if move.Type == g4.Tilt {
+		return 8 + int(move.Direction-g4.LEFT)
+	}
+	return move.Column
+}
+
+// PolicyMove returns the move of given color at an index of policies.
+func PolicyMove(index int, color g4.Color) g4.Move {
+	if index >= 8 {
+		return g4.TiltMove(color, g4.LEFT+g4.Direction(index-8))
+	}
+	return g4.TokenMove(color, index)
+}
+
+// Options configures the generator.
+type Options struct {
+	// Games is the number of games to play.
+	Games int
+
+	// Workers is the number of games played at the same time. It defaults to runtime.NumCPU().
+	Workers int
+
+	// Seed makes the data reproducible: game k uses the seed Seed+k.
+	Seed int64
+
+	// Depth is the search depth used to score moves. It defaults to DefaultDepth.
+	Depth int
+
+	// Temperature, in evaluation units, flattens the move distributions:
+	// the probability of a move is proportional to exp(score/Temperature).
+	// It defaults to DefaultTemperature.
+	Temperature float64
+
+	// RandomPlies is the number of uniformly random moves opening each game.
+	// They do not produce samples.
+	RandomPlies int
+
+	// MoveLimit is the number of moves after which games are drawn. It defaults to DefaultMoveLimit.
+	MoveLimit int
+
+	// Evaluator scores the leaves of the searches. It defaults to eval.New(nil).
+	Evaluator eval.Evaluator
+}
+
+func (o Options) withDefaults() Options {
+	if o.Workers <= 0 {
+		o.Workers = runtime.NumCPU()
+	}
+	if o.Depth <= 0 {
+		o.Depth = DefaultDepth
+	}
+	if o.Temperature <= 0 {
+		o.Temperature = DefaultTemperature
+	}
+	if o.MoveLimit <= 0 {
+		o.MoveLimit = DefaultMoveLimit
+	}
+	return o
+}
+
+// Generate plays the games and passes their samples to emit, one game at a time, in the order of the games.
+//
+// It stops at the first error of emit, or when ctx is done.
+func Generate(ctx context.Context, options Options, emit func([]Sample) error) error {
+	options = options.withDefaults()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type played struct {
+		index   int
+		samples []Sample
+		err     error
+	}
+	indices := make(chan int)
+	results := make(chan played)
+	var wg sync.WaitGroup
+	for w := 0; w < options.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Searches are single-threaded so that they are deterministic.
+			searcher := search.New(search.Options{Threads: 1, Depth: options.Depth, Evaluator: options.Evaluator})
+			for index := range indices {
+				samples, err := play(ctx, searcher, options, index)
+				select {
+				case results <- played{index, samples, err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(indices)
+		for k := 0; k < options.Games; k++ {
+			select {
+			case indices <- k:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Games finish out of order: keep them until their turn comes.
+	pending := make(map[int][]Sample)
+	next := 0
+	for result := range results {
+		if result.err != nil {
+			return result.err
+		}
+		pending[result.index] = result.samples
+		for samples, ok := pending[next]; ok; samples, ok = pending[next] {
+			delete(pending, next)
+			next++
+			if err := emit(samples); err != nil {
+				return err
+			}
+		}
+	}
+	if next < options.Games {
+		return ctx.Err()
+	}
+	return nil
+}
+
+// play plays game number index and returns its samples.
+//
+// Games end by the rules, or by 3-fold repetition as in g4.
+func play(ctx context.Context, searcher *search.Searcher, options Options, index int) ([]Sample, error) {
+	r := rand.New(rand.NewSource(options.Seed + int64(index)))
+	searcher.Clear()
+
+	game := bitsim.Game{Mover: g4.Yellow, Rules: bitsim.Rules{MoveLimit: options.MoveLimit}}
+	history := make(map[bitsim.Game]int)
+	var samples []Sample
+	for ply := 0; ; ply++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		var move g4.Move
+		if ply < options.RandomPlies {
+			moves, err := game.Generate()
+			if err != nil {
+				return nil, err
+			}
+			move = moves[r.Intn(len(moves))]
+		} else {
+			scores, _, err := searcher.ScoreMoves(ctx, game, options.Depth)
+			if err != nil {
+				return nil, err
+			}
+			sample := Sample{Game: game, Policy: policy(scores, options.Temperature), GameIndex: index, Ply: ply}
+			samples = append(samples, sample)
+			move = choose(sample.Policy, game.Mover, r)
+		}
+
+		var err error
+		game, err = game.Apply(move)
+		if errors.Is(err, g4.ErrorInvalidMove{}) {
+			return nil, err
+		}
+		if err != nil {
+			for k := range samples {
+				samples[k].Outcome = outcome(err, samples[k].Game.Mover)
+			}
+			return samples, nil
+		}
+
+		// NB: positions are compared without the move counters.
+		position := bitsim.Game{Board: game.Board, Mover: game.Mover}
+		history[position]++
+		if history[position] == 3 {
+			// The game is drawn by 3-fold repetition: samples keep their outcome 0.
+			return samples, nil
+		}
+	}
+}
+
+// policy turns move scores into a probability distribution, with a softmax at given temperature.
+func policy(scores []search.MoveScore, temperature float64) (p [PolicySize]float32) {
+	best := math.Inf(-1)
+	for _, ms := range scores {
+		best = math.Max(best, float64(ms.Score))
+	}
+	var weights [PolicySize]float64
+	total := 0.
+	for _, ms := range scores {
+		w := math.Exp((float64(ms.Score) - best) / temperature)
+		weights[PolicyIndex(ms.Move)] = w
+		total += w
+	}
+	for k, w := range weights {
+		p[k] = float32(w / total)
+	}
+	return
+}
+
+// choose picks a move at random according to a policy.
+func choose(p [PolicySize]float32, color g4.Color, r *rand.Rand) g4.Move {
+	x := r.Float32()
+	last := 0
+	for k, q := range p {
+		if q == 0 {
+			continue
+		}
+		if x < q {
+			return PolicyMove(k, color)
+		}
+		x -= q
+		last = k
+	}
+	// Rounding errors may leave x above the sum of the probabilities.
+	return PolicyMove(last, color)
+}
+
+// outcome returns the result of a finished game for given player.
+func outcome(err error, color g4.Color) int {
+	switch err.(type) {
+	case g4.YellowWins:
+		if color == g4.Yellow {
+			return 1
+		}
+		return -1
+	case g4.RedWins:
+		if color == g4.Red {
+			return 1
+		}
+		return -1
+	}
+	return 0
+}
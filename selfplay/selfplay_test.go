@@ -0,0 +1,158 @@
+package selfplay_test
+
+import (
+	"context"
+	"errors"
+	"g4"
+	"g4/bitsim"
+	"g4/selfplay"
+	"math"
+	"testing"
+)
+
+func generate(t *testing.T, options selfplay.Options) [][]selfplay.Sample {
+	t.Helper()
+	var games [][]selfplay.Sample
+	err := selfplay.Generate(context.Background(), options, func(samples []selfplay.Sample) error {
+		games = append(games, samples)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return games
+}
+
+func TestGenerate(t *testing.T) {
+	options := selfplay.Options{Games: 6, Workers: 3, Seed: 7, Depth: 2, RandomPlies: 2, MoveLimit: 60}
+	games := generate(t, options)
+	if len(games) != 6 {
+		t.Fatalf("got %d games but want 6", len(games))
+	}
+	for k, samples := range games {
+		if len(samples) == 0 {
+			t.Errorf("game %d: no samples", k)
+			continue
+		}
+		for i, sample := range samples {
+			if sample.GameIndex != k || sample.Ply != i+2 {
+				t.Errorf("game %d: sample %d: got game %d and ply %d", k, i, sample.GameIndex, sample.Ply)
+			}
+			total := 0.
+			for _, p := range sample.Policy {
+				total += float64(p)
+			}
+			if math.Abs(total-1) > 1e-4 {
+				t.Errorf("game %d: sample %d: policy sums to %v", k, i, total)
+			}
+			moves, err := sample.Game.Generate()
+			if err != nil {
+				t.Errorf("game %d: sample %d: %v", k, i, err)
+			}
+			if len(moves) < len(nonZero(sample.Policy)) {
+				t.Errorf("game %d: sample %d: probability on illegal moves", k, i)
+			}
+		}
+		// Outcomes alternate with the mover, unless the game is drawn.
+		last := samples[len(samples)-1]
+		for _, sample := range samples {
+			want := last.Outcome
+			if sample.Game.Mover != last.Game.Mover {
+				want = -want
+			}
+			if sample.Outcome != want {
+				t.Errorf("game %d: got outcome %d but want %d", k, sample.Outcome, want)
+				break
+			}
+		}
+	}
+}
+
+func nonZero(policy [selfplay.PolicySize]float32) []int {
+	var indices []int
+	for k, p := range policy {
+		if p > 0 {
+			indices = append(indices, k)
+		}
+	}
+	return indices
+}
+
+// tokenAverse scores positions with tokens as won for the mover.
+type tokenAverse struct{}
+
+func (tokenAverse) Evaluate(g bitsim.Game) int {
+	if g.Board.Count() > 0 {
+		return 1000
+	}
+	return 0
+}
+
+func TestGenerateRepetition(t *testing.T) {
+	// Token moves get a probability of 0, which leaves only no-op tilts of the empty board.
+	options := selfplay.Options{Games: 1, Depth: 1, Temperature: 1, Evaluator: tokenAverse{}}
+	samples := generate(t, options)[0]
+	if len(samples) != 5 {
+		t.Fatalf("got %d samples but want 5", len(samples))
+	}
+	for i, sample := range samples {
+		if sample.Game.Board != (bitsim.Board{}) || sample.Outcome != 0 {
+			t.Errorf("sample %d: got board %v and outcome %d but want the empty board and a draw", i, sample.Game.Board, sample.Outcome)
+		}
+	}
+}
+
+func TestGenerateReproducible(t *testing.T) {
+	options := selfplay.Options{Games: 4, Workers: 4, Seed: 3, Depth: 2, RandomPlies: 4, MoveLimit: 40}
+	first := generate(t, options)
+	options.Workers = 1
+	second := generate(t, options)
+	for k := range first {
+		if len(first[k]) != len(second[k]) {
+			t.Fatalf("game %d: got %d and %d samples", k, len(first[k]), len(second[k]))
+		}
+		for i := range first[k] {
+			if first[k][i] != second[k][i] {
+				t.Errorf("game %d: sample %d differs", k, i)
+			}
+		}
+	}
+
+	options.Seed = 4
+	third := generate(t, options)
+	same := true
+	for k := range first {
+		same = same && len(first[k]) == len(third[k]) && first[k][0] == third[k][0]
+	}
+	if same {
+		t.Errorf("got the same games with another seed")
+	}
+}
+
+func TestGenerateError(t *testing.T) {
+	errStop := errors.New("stop")
+	calls := 0
+	err := selfplay.Generate(context.Background(), selfplay.Options{Games: 10, Depth: 1, MoveLimit: 20}, func([]selfplay.Sample) error {
+		calls++
+		return errStop
+	})
+	if err != errStop || calls != 1 {
+		t.Errorf("got error %v after %d calls", err, calls)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = selfplay.Generate(ctx, selfplay.Options{Games: 10, Depth: 1}, func([]selfplay.Sample) error { return nil })
+	if err == nil {
+		t.Errorf("expected error for a cancelled context")
+	}
+}
+
+func TestPolicyIndex(t *testing.T) {
+	for k := 0; k < selfplay.PolicySize; k++ {
+		move := selfplay.PolicyMove(k, g4.Red)
+		if got := selfplay.PolicyIndex(move); got != k {
+			t.Errorf("example %d: got index %d for move %v", k, got, move)
+		}
+	}
+}
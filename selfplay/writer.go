@@ -0,0 +1,168 @@
+package selfplay
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"io"
+	"math"
+)
+
+// Writer writes samples to a dataset.
+type Writer interface {
+	Write(samples []Sample) error
+	// Flush writes buffered data to the underlying writer.
+	Flush() error
+}
+
+// jsonSample is the JSON form of a sample.
+type jsonSample struct {
+	Game    int                `json:"game"`
+	Ply     int                `json:"ply"`
+	Board   string             `json:"board"`
+	Mover   string             `json:"mover"`
+	Policy  map[string]float32 `json:"policy"`
+	Outcome int                `json:"outcome"`
+}
+
+// JSONWriter writes samples as JSON lines.
+//
+// Each line holds the game number and ply, the board string, the mover
+// ("yellow" or "red"), the policy as a map from move notation to probability
+// (illegal moves are left out), and the outcome for the mover.
+type JSONWriter struct {
+	w *bufio.Writer
+}
+
+// NewJSONWriter creates a JSON lines writer.
+func NewJSONWriter(w io.Writer) *JSONWriter {
+	return &JSONWriter{w: bufio.NewWriter(w)}
+}
+
+// Write writes one line per sample.
+func (w *JSONWriter) Write(samples []Sample) error {
+	for _, sample := range samples {
+		js := jsonSample{
+			Game:    sample.GameIndex,
+			Ply:     sample.Ply,
+			Board:   sample.Game.Board.String(),
+			Mover:   "yellow",
+			Policy:  make(map[string]float32),
+			Outcome: sample.Outcome,
+		}
+		if sample.Game.Mover == g4.Red {
+			js.Mover = "red"
+		}
+		for k, p := range sample.Policy {
+			if p > 0 {
+				js.Policy[PolicyMove(k, sample.Game.Mover).String()] = p
+			}
+		}
+		data, err := json.Marshal(js)
+		if err != nil {
+			return err
+		}
+		w.w.Write(data)
+		if err := w.w.WriteByte('\n'); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush writes buffered lines to the underlying writer.
+func (w *JSONWriter) Flush() error {
+	return w.w.Flush()
+}
+
+const (
+	// binaryMagic starts binary datasets.
+	binaryMagic = "G4SP\x01"
+
+	// binarySampleSize is the size of a sample in binary datasets.
+	binarySampleSize = 17 + 4*PolicySize + 1
+)
+
+// BinaryWriter writes samples in a compact binary form.
+//
+// The dataset starts with the magic string "G4SP\x01". Samples follow with a
+// fixed size of 62 bytes: the position as encoded by bitsim.Game.MarshalBinary
+// (17 bytes), the policy as 11 little-endian float32, and the outcome as a
+// signed byte. Game numbers and plies are not stored.
+type BinaryWriter struct {
+	w       *bufio.Writer
+	started bool
+}
+
+// NewBinaryWriter creates a binary writer.
+func NewBinaryWriter(w io.Writer) *BinaryWriter {
+	return &BinaryWriter{w: bufio.NewWriter(w)}
+}
+
+// Write writes the samples, after the magic string for the first call.
+func (w *BinaryWriter) Write(samples []Sample) error {
+	if !w.started {
+		w.w.WriteString(binaryMagic)
+		w.started = true
+	}
+	for _, sample := range samples {
+		// NB: the rules and the counters are not stored.
+		game, _ := bitsim.Game{Board: sample.Game.Board, Mover: sample.Game.Mover}.MarshalBinary()
+		data := make([]byte, binarySampleSize)
+		copy(data, game)
+		for i, p := range sample.Policy {
+			binary.LittleEndian.PutUint32(data[17+4*i:], math.Float32bits(p))
+		}
+		data[binarySampleSize-1] = byte(int8(sample.Outcome))
+		if _, err := w.w.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush writes buffered samples to the underlying writer.
+//
+// An empty dataset still gets its magic string.
+func (w *BinaryWriter) Flush() error {
+	if !w.started {
+		w.w.WriteString(binaryMagic)
+		w.started = true
+	}
+	return w.w.Flush()
+}
+
+// ReadBinary reads a dataset written by BinaryWriter.
+//
+// Samples have no game number nor ply, and their games have no rules nor counters.
+func ReadBinary(r io.Reader) ([]Sample, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(binaryMagic))
+	if _, err := io.ReadFull(br, header); err != nil || string(header) != binaryMagic {
+		return nil, errors.New("not a self-play dataset")
+	}
+	var samples []Sample
+	data := make([]byte, binarySampleSize)
+	for k := 0; ; k++ {
+		_, err := io.ReadFull(br, data)
+		if err == io.EOF {
+			return samples, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("sample %d: %w", k, err)
+		}
+		var sample Sample
+		if err := sample.Game.UnmarshalBinary(data[:17]); err != nil {
+			return nil, fmt.Errorf("sample %d: %w", k, err)
+		}
+		for i := range sample.Policy {
+			sample.Policy[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[17+4*i:]))
+		}
+		sample.Outcome = int(int8(data[binarySampleSize-1]))
+		samples = append(samples, sample)
+	}
+}
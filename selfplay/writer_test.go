@@ -0,0 +1,95 @@
+package selfplay_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"g4"
+	"g4/bitsim"
+	"g4/selfplay"
+	"testing"
+)
+
+func testSamples(t *testing.T) []selfplay.Sample {
+	board, err := bitsim.FromString("y7|r7|8|8|8|8|8|8")
+	if err != nil {
+		t.Fatalf("error in FromString: %v", err)
+	}
+	var policy [selfplay.PolicySize]float32
+	policy[3], policy[9] = 0.75, 0.25
+	return []selfplay.Sample{
+		{Game: bitsim.Game{Mover: g4.Yellow}, Policy: policy, Outcome: 1, GameIndex: 2, Ply: 0},
+		{Game: bitsim.Game{Board: board, Mover: g4.Red}, Policy: policy, Outcome: -1, GameIndex: 2, Ply: 2},
+	}
+}
+
+func TestBinary(t *testing.T) {
+	samples := testSamples(t)
+	var buf bytes.Buffer
+	w := selfplay.NewBinaryWriter(&buf)
+	if err := w.Write(samples[:1]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Write(samples[1:]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := buf.Len(), 5+2*62; got != want {
+		t.Errorf("got %d bytes but want %d", got, want)
+	}
+
+	read, err := selfplay.ReadBinary(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(read) != len(samples) {
+		t.Fatalf("got %d samples but want %d", len(read), len(samples))
+	}
+	for k, sample := range samples {
+		sample.GameIndex, sample.Ply = 0, 0
+		if read[k] != sample {
+			t.Errorf("sample %d: got %+v but want %+v", k, read[k], sample)
+		}
+	}
+
+	// Truncated samples and foreign files are rejected.
+	var truncated bytes.Buffer
+	w = selfplay.NewBinaryWriter(&truncated)
+	w.Write(samples)
+	w.Flush()
+	examples := [][]byte{nil, []byte("G4BK\x01"), truncated.Bytes()[:truncated.Len()-1]}
+	for k, ex := range examples {
+		if _, err := selfplay.ReadBinary(bytes.NewReader(ex)); err == nil {
+			t.Errorf("example %d: expected error", k)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	w := selfplay.NewJSONWriter(&buf)
+	if err := w.Write(testSamples(t)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w.Flush()
+
+	want := []string{
+		`{"game":2,"ply":0,"board":"8|8|8|8|8|8|8|8","mover":"yellow","policy":{"4":0.75,"D":0.25},"outcome":1}`,
+		`{"game":2,"ply":2,"board":"y7|r7|8|8|8|8|8|8","mover":"red","policy":{"4":0.75,"D":0.25},"outcome":-1}`,
+	}
+	scanner := bufio.NewScanner(&buf)
+	k := 0
+	for ; scanner.Scan(); k++ {
+		if k < len(want) && scanner.Text() != want[k] {
+			t.Errorf("line %d: got %s but want %s", k, scanner.Text(), want[k])
+		}
+		if !json.Valid(scanner.Bytes()) {
+			t.Errorf("line %d: invalid JSON", k)
+		}
+	}
+	if k != len(want) {
+		t.Errorf("got %d lines but want %d", k, len(want))
+	}
+}
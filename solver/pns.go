@@ -0,0 +1,150 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"g4"
+	"g4/bitsim"
+)
+
+// infinity is the proof or disproof number of a settled node.
+const infinity = 1 << 30
+
+// node is a node of the proof-number search tree.
+//
+// Positions are not stored: they are replayed from the root while descending.
+type node struct {
+	move     g4.Move
+	parent   *node
+	children []*node
+
+	// pn is the least number of leaves to prove that the attacker wins,
+	// dn the least number of leaves to disprove it.
+	pn, dn int
+
+	// or tells whether the attacker is to move.
+	or bool
+}
+
+// search is a single proof-number search, for the question "can attacker force a win?".
+type search struct {
+	ctx      context.Context
+	attacker g4.Color
+	budget   int
+
+	nodes int
+	err   error
+}
+
+// run grows the tree from g until the root is settled, the budget is exhausted or ctx is done.
+func (s *search) run(g bitsim.Game) *node {
+	root := &node{pn: 1, dn: 1, or: g.Mover == s.attacker}
+	s.nodes = 1
+	path := make([]uint64, 0, 64)
+	for expansions := 1; root.pn != 0 && root.dn != 0; expansions++ {
+		if s.nodes >= s.budget {
+			s.err = ErrorBudgetExhausted
+			break
+		}
+		if expansions&1023 == 0 {
+			if err := s.ctx.Err(); err != nil {
+				s.err = err
+				break
+			}
+		}
+
+		// Descend to the most proving node.
+		n, game := root, g
+		path = append(path[:0], g.Hash())
+		for n.children != nil {
+			n = n.mostProving()
+			game, _ = game.Apply(n.move)
+			path = append(path, game.Hash())
+		}
+
+		s.expand(n, game, path)
+		for ; n != nil; n = n.parent {
+			n.update()
+		}
+	}
+	return root
+}
+
+// expand creates the children of a leaf, settling those which end the game.
+func (s *search) expand(n *node, g bitsim.Game, path []uint64) {
+	moves, _ := g.Generate()
+	n.children = make([]*node, 0, len(moves))
+	for _, move := range moves {
+		child := &node{move: move, parent: n, pn: 1, dn: 1, or: !n.or}
+		next, err := g.Apply(move)
+		switch {
+		case errors.Is(err, g4.ErrorInvalidMove{}):
+			continue
+		case err != nil:
+			if g4.IsWin(err, s.attacker) {
+				child.pn, child.dn = 0, infinity
+			} else {
+				child.pn, child.dn = infinity, 0
+			}
+		case isRepeated(next.Hash(), path):
+			child.pn, child.dn = infinity, 0
+		}
+		n.children = append(n.children, child)
+		s.nodes++
+	}
+}
+
+// update computes the proof and disproof numbers of an inner node from its children.
+func (n *node) update() {
+	if n.children == nil {
+		return
+	}
+	if n.or {
+		n.pn, n.dn = infinity, 0
+		for _, child := range n.children {
+			n.pn = min(n.pn, child.pn)
+			n.dn = add(n.dn, child.dn)
+		}
+	} else {
+		n.pn, n.dn = 0, infinity
+		for _, child := range n.children {
+			n.pn = add(n.pn, child.pn)
+			n.dn = min(n.dn, child.dn)
+		}
+	}
+}
+
+// mostProving returns the child to descend into.
+func (n *node) mostProving() *node {
+	best := n.children[0]
+	for _, child := range n.children[1:] {
+		if n.or && child.pn < best.pn || !n.or && child.dn < best.dn {
+			best = child
+		}
+	}
+	return best
+}
+
+func isRepeated(hash uint64, path []uint64) bool {
+	for _, previous := range path {
+		if previous == hash {
+			return true
+		}
+	}
+	return false
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// add sums proof numbers, saturating at infinity.
+func add(a, b int) int {
+	if a+b > infinity {
+		return infinity
+	}
+	return a + b
+}
@@ -0,0 +1,105 @@
+// Package solver proves the outcome of g4 positions with proof-number search.
+//
+// Proof-number search grows the game tree towards the moves that are the
+// easiest to prove or disprove, which suits sharp positions where a few
+// forcing lines decide the game. Each search answers a yes-or-no question,
+// "can this player force a win?", and the solver asks it for both players to
+// tell wins, losses and draws apart.
+//
+// Repeated positions along a line are scored as draws.
+package solver
+
+import (
+	"context"
+	"errors"
+	"g4"
+	"g4/bitsim"
+)
+
+// Outcome is the game-theoretic value of a position for the player with the move.
+type Outcome int
+
+const (
+	// Unknown means that the node budget was exhausted before a proof was found.
+	Unknown Outcome = iota
+	Win
+	Loss
+	Draw
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Win:
+		return "win"
+	case Loss:
+		return "loss"
+	case Draw:
+		return "draw"
+	}
+	return "unknown"
+}
+
+// Result is the answer of the solver.
+type Result struct {
+	// Outcome is the value of the position for the player with the move.
+	Outcome Outcome
+
+	// Move is a winning move for a win, or a drawing move for a draw.
+	Move g4.Move
+
+	// Proof is the proof tree of the outcome, nil if unknown.
+	//
+	// For a win, it holds one winning move at each turn of the mover and every
+	// reply of the opponent. For a loss, it holds the winning strategy of the
+	// opponent. For a draw, it holds a strategy of the mover that never loses.
+	Proof *Tree
+
+	// Nodes is the number of positions created by the search.
+	Nodes int
+}
+
+// Solve computes the outcome of g for its mover, creating at most budget positions.
+//
+// The search can be interrupted through ctx, in which case the outcome is Unknown.
+// It fails if the game is already over.
+func Solve(ctx context.Context, g bitsim.Game, budget int) (Result, error) {
+	if err := g.Validate(); err != nil {
+		return Result{}, err
+	}
+	opponent := g4.Red
+	if g.Mover == g4.Red {
+		opponent = g4.Yellow
+	}
+
+	// Can the mover force a win?
+	s := &search{ctx: ctx, attacker: g.Mover, budget: budget}
+	root := s.run(g)
+	result := Result{Nodes: s.nodes}
+	switch {
+	case root.pn == 0:
+		result.Outcome = Win
+		result.Proof = proofTree(root, true)
+		result.Move = result.Proof.Children[0].Move
+		return result, nil
+	case root.dn != 0:
+		return result, s.err
+	}
+
+	// The mover cannot win: can the opponent?
+	s = &search{ctx: ctx, attacker: opponent, budget: budget - result.Nodes}
+	root = s.run(g)
+	result.Nodes += s.nodes
+	switch {
+	case root.pn == 0:
+		result.Outcome = Loss
+		result.Proof = proofTree(root, true)
+	case root.dn == 0:
+		result.Outcome = Draw
+		result.Proof = proofTree(root, false)
+		result.Move = result.Proof.Children[0].Move
+	}
+	return result, s.err
+}
+
+// ErrorBudgetExhausted is returned along with an Unknown outcome when the node budget runs out.
+var ErrorBudgetExhausted = errors.New("node budget exhausted")
@@ -0,0 +1,181 @@
+package solver_test
+
+import (
+	"context"
+	"errors"
+	"g4"
+	"g4/bitsim"
+	"g4/internal/g4test"
+	"g4/solver"
+	"testing"
+)
+
+func TestSolve(t *testing.T) {
+	examples := []struct {
+		in      string
+		mover   g4.Color
+		outcome solver.Outcome
+		move    g4.Move
+		depth   int
+	}{
+		{
+			in:      "yyy5|rr6|r7|8|8|8|8|8",
+			mover:   g4.Yellow,
+			outcome: solver.Win,
+			move:    g4.TokenMove(g4.Yellow, 0),
+			depth:   1,
+		},
+		{
+			in:      "yrrryry1|rryy4|yyr5|ry6|r7|y7|8|8",
+			mover:   g4.Red,
+			outcome: solver.Win,
+			move:    g4.TiltMove(g4.Red, g4.RIGHT),
+			depth:   1,
+		},
+		{
+			in:      "8|8|r7|r7|rr6|y7|yry5|yryryy2",
+			mover:   g4.Yellow,
+			outcome: solver.Loss,
+			depth:   2,
+		},
+		{
+			in:      "yyrrr3|rry5|y7|r7|r7|8|8|8",
+			mover:   g4.Yellow,
+			outcome: solver.Loss,
+			depth:   8,
+		},
+		{
+			in:      "ryyrrr2|ryr5|rr6|yyry4|y7|yr6|ry6|yy6",
+			mover:   g4.Yellow,
+			outcome: solver.Draw,
+			move:    g4.TiltMove(g4.Yellow, g4.DOWN),
+			depth:   3,
+		},
+	}
+	for k, ex := range examples {
+		game := g4test.Game(t, ex.in, ex.mover)
+		result, err := solver.Solve(context.Background(), game, 100000)
+		if err != nil {
+			t.Errorf("example %d: unexpected error: %v", k, err)
+			continue
+		}
+		if result.Outcome != ex.outcome || result.Move != ex.move {
+			t.Errorf("example %d: got (%v, %v) but want (%v, %v)", k, result.Outcome, result.Move, ex.outcome, ex.move)
+		}
+		if depth := result.Proof.Depth(); depth != ex.depth {
+			t.Errorf("example %d: got proof depth %d but want %d", k, depth, ex.depth)
+		}
+		checkProof(t, k, game, result.Proof, result.Outcome)
+	}
+}
+
+// checkProof replays a proof tree and checks that it proves the outcome.
+//
+// The player who must show a strategy (the prover) plays one move per turn,
+// and every move of the other player must be answered.
+func checkProof(t *testing.T, k int, g bitsim.Game, tree *solver.Tree, outcome solver.Outcome) {
+	t.Helper()
+	prover := g.Mover
+	if outcome == solver.Loss {
+		prover = g.Mover.Opponent()
+	}
+	path := map[uint64]bool{}
+	var check func(g bitsim.Game, tree *solver.Tree) bool
+	check = func(g bitsim.Game, tree *solver.Tree) bool {
+		if g.Mover == prover && len(tree.Children) != 1 {
+			return false
+		}
+		if g.Mover != prover {
+			moves, _ := g.Generate()
+			if len(tree.Children) != len(moves) {
+				return false
+			}
+		}
+		path[g.Hash()] = true
+		defer delete(path, g.Hash())
+		for _, child := range tree.Children {
+			next, err := g.Apply(child.Move)
+			switch {
+			case errors.Is(err, g4.ErrorInvalidMove{}):
+				return false
+			case err != nil:
+				if !leafAgrees(err, prover, outcome) {
+					return false
+				}
+			case path[next.Hash()]:
+				// Repetitions are draws.
+				if outcome != solver.Draw {
+					return false
+				}
+			case !check(next, child):
+				return false
+			}
+		}
+		return true
+	}
+	if !check(g, tree) {
+		t.Errorf("example %d: invalid proof tree:\n%s", k, tree)
+	}
+}
+
+func leafAgrees(err error, prover g4.Color, outcome solver.Outcome) bool {
+	var winner g4.Color
+	switch err.(type) {
+	case g4.YellowWins:
+		winner = g4.Yellow
+	case g4.RedWins:
+		winner = g4.Red
+	}
+	if outcome == solver.Draw {
+		return winner != prover.Opponent()
+	}
+	return winner == prover
+}
+
+func TestSolveUnknown(t *testing.T) {
+	game := g4test.Game(t, bitsim.StartingPosition, g4.Yellow)
+	result, err := solver.Solve(context.Background(), game, 1000)
+	if !errors.Is(err, solver.ErrorBudgetExhausted) || result.Outcome != solver.Unknown {
+		t.Errorf("got (%v, %v) but want (%v, %v)", result.Outcome, err, solver.Unknown, solver.ErrorBudgetExhausted)
+	}
+	if result.Nodes < 1000 || result.Nodes > 1100 {
+		t.Errorf("got %d nodes for a budget of 1000", result.Nodes)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err = solver.Solve(ctx, game, 1000000)
+	if !errors.Is(err, context.Canceled) || result.Outcome != solver.Unknown {
+		t.Errorf("got (%v, %v) but want (%v, %v)", result.Outcome, err, solver.Unknown, context.Canceled)
+	}
+}
+
+func TestSolveGameOver(t *testing.T) {
+	if _, err := solver.Solve(context.Background(), g4test.Game(t, "yyyy4|8|8|8|8|8|8|8", g4.Red), 1000); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestTree(t *testing.T) {
+	tree := &solver.Tree{Children: []*solver.Tree{
+		{
+			Move: g4.TokenMove(g4.Yellow, 0),
+			Children: []*solver.Tree{
+				{Move: g4.TokenMove(g4.Red, 1), Children: []*solver.Tree{{Move: g4.TiltMove(g4.Yellow, g4.LEFT)}}},
+				{Move: g4.TiltMove(g4.Red, g4.DOWN)},
+			},
+		},
+	}}
+	if got := tree.Size(); got != 4 {
+		t.Errorf("got size %d but want 4", got)
+	}
+	if got := tree.Depth(); got != 3 {
+		t.Errorf("got depth %d but want 3", got)
+	}
+	if got := tree.Line(); len(got) != 3 || got[2] != g4.TiltMove(g4.Yellow, g4.LEFT) {
+		t.Errorf("got line %v", got)
+	}
+	if got, want := tree.String(), "1\n  2\n    L\n  D\n"; got != want {
+		t.Errorf("got %q but want %q", got, want)
+	}
+}
@@ -0,0 +1,84 @@
+package solver
+
+import (
+	"g4"
+	"strings"
+)
+
+// Tree is a proof tree: each node is a move, and its children are the moves considered after it.
+//
+// The root holds no move.
+type Tree struct {
+	Move     g4.Move
+	Children []*Tree
+}
+
+// proofTree extracts the proof (or disproof) tree of a settled search tree.
+//
+// A proof keeps one proven child at the turns of the attacker and every child
+// at the turns of the defender. A disproof is the other way around.
+func proofTree(n *node, proof bool) *Tree {
+	tree := &Tree{Move: n.move}
+	settled := func(child *node) bool {
+		if proof {
+			return child.pn == 0
+		}
+		return child.dn == 0
+	}
+	keepOne := n.or == proof
+	for _, child := range n.children {
+		if !settled(child) {
+			continue
+		}
+		tree.Children = append(tree.Children, proofTree(child, proof))
+		if keepOne {
+			break
+		}
+	}
+	return tree
+}
+
+// Size returns the number of moves in the tree.
+func (t *Tree) Size() int {
+	size := 0
+	for _, child := range t.Children {
+		size += 1 + child.Size()
+	}
+	return size
+}
+
+// Depth returns the number of moves of the longest line of the tree.
+func (t *Tree) Depth() int {
+	depth := 0
+	for _, child := range t.Children {
+		if d := 1 + child.Depth(); d > depth {
+			depth = d
+		}
+	}
+	return depth
+}
+
+// Line returns the main line of the tree, following the first child at each move.
+func (t *Tree) Line() []g4.Move {
+	var line []g4.Move
+	for n := t; len(n.Children) > 0; n = n.Children[0] {
+		line = append(line, n.Children[0].Move)
+	}
+	return line
+}
+
+// String renders the tree one move per line, indented by depth.
+func (t *Tree) String() string {
+	var sb strings.Builder
+	var write func(t *Tree, depth int)
+	write = func(t *Tree, depth int) {
+		for _, child := range t.Children {
+			sb.WriteString(strings.Repeat("  ", depth))
+			sb.WriteString(child.Move.String())
+			sb.WriteString("\n")
+			write(child, depth+1)
+		}
+	}
+	write(t, 0)
+	return sb.String()
+}
@@ -0,0 +1,164 @@
+package tablebase
+
+import (
+	"errors"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"g4/solver"
+)
+
+// ErrorTooManyPositions is returned when a generation exceeds its position limit.
+var ErrorTooManyPositions = errors.New("too many positions")
+
+// graph is the game graph explored by the generator.
+type graph struct {
+	keys  []Key
+	games []bitsim.Game
+	index map[Key]int
+
+	// children lists the positions reached by the moves which do not end the game.
+	children [][]int
+
+	// immediateWin and immediateDraw tell whether the mover can end the game
+	// at once with a win or a draw.
+	immediateWin  []bool
+	immediateDraw []bool
+}
+
+// Generate solves every position reachable from seeds with at most maxEmpty empty squares.
+//
+// Seeds with more empty squares, finished seeds and seeds played under other
+// rules than the standard ones are ignored. Since moves never add empty
+// squares, the whole future of each seed is solved, by retrograde analysis:
+// starting from the positions where the game ends, values are propagated back
+// to the positions leading to them. Positions left unsolved can avoid defeat
+// forever, by repetition, and are draws.
+//
+// It fails if more than limit positions are reached.
+func Generate(seeds []bitsim.Game, maxEmpty, limit int) (*Table, error) {
+	g := &graph{index: make(map[Key]int)}
+	for _, seed := range seeds {
+		if seed.Rules != (bitsim.Rules{}) || 64-seed.Board.Count() > maxEmpty || seed.Validate() != nil {
+			continue
+		}
+		if err := g.explore(seed, limit); err != nil {
+			return nil, err
+		}
+	}
+	values := g.solve()
+
+	t := &Table{MaxEmpty: maxEmpty}
+	for i, key := range g.keys {
+		t.keys = append(t.keys, key)
+		t.values = append(t.values, values[i].encode())
+	}
+	t.sort()
+	return t, nil
+}
+
+// explore adds to the graph all the positions reachable from seed.
+func (g *graph) explore(seed bitsim.Game, limit int) error {
+	if _, ok := g.index[Canonical(seed)]; ok {
+		return nil
+	}
+	g.add(seed)
+	// NB: positions are expanded in order of discovery, so the graph doubles as the queue.
+	for i := len(g.keys) - 1; i < len(g.keys); i++ {
+		game := g.games[i]
+		moves, _ := game.Generate()
+		for _, move := range moves {
+			next, err := game.Apply(move)
+			switch err.(type) {
+			case nil:
+			case g4.YellowWins:
+				g.immediateWin[i] = g.immediateWin[i] || game.Mover == g4.Yellow
+				continue
+			case g4.RedWins:
+				g.immediateWin[i] = g.immediateWin[i] || game.Mover == g4.Red
+				continue
+			default:
+				g.immediateDraw[i] = true
+				continue
+			}
+			key := Canonical(next)
+			j, ok := g.index[key]
+			if !ok {
+				if len(g.keys) >= limit {
+					return fmt.Errorf("%w: more than %d", ErrorTooManyPositions, limit)
+				}
+				j = g.add(next)
+			}
+			g.children[i] = append(g.children[i], j)
+		}
+	}
+	return nil
+}
+
+func (g *graph) add(game bitsim.Game) int {
+	i := len(g.keys)
+	key := Canonical(game)
+	g.index[key] = i
+	g.keys = append(g.keys, key)
+	g.games = append(g.games, game)
+	g.children = append(g.children, nil)
+	g.immediateWin = append(g.immediateWin, false)
+	g.immediateDraw = append(g.immediateDraw, false)
+	return i
+}
+
+// solve computes the values of all the positions of the graph by retrograde analysis.
+func (g *graph) solve() []Value {
+	n := len(g.keys)
+	values := make([]Value, n)
+	known := make([]bool, n)
+	parents := make([][]int, n)
+	remaining := make([]int, n)
+	var queue []int
+
+	set := func(i int, value Value) {
+		values[i], known[i] = value, true
+		queue = append(queue, i)
+	}
+	for i := 0; i < n; i++ {
+		for _, j := range g.children[i] {
+			parents[j] = append(parents[j], i)
+		}
+		remaining[i] = len(g.children[i])
+		switch {
+		case g.immediateWin[i]:
+			set(i, Value{Outcome: solver.Win, Plies: 1})
+		case remaining[i] == 0 && !g.immediateDraw[i]:
+			// Every move gives the opponent a connect-4.
+			set(i, Value{Outcome: solver.Loss, Plies: 1})
+		}
+	}
+
+	// Positions are settled by increasing distance to the end, so that wins
+	// are as short as possible, and losses as long as possible.
+	for k := 0; k < len(queue); k++ {
+		child := queue[k]
+		value := values[child]
+		for _, i := range parents[child] {
+			if known[i] {
+				continue
+			}
+			switch value.Outcome {
+			case solver.Loss:
+				set(i, Value{Outcome: solver.Win, Plies: value.Plies + 1})
+			case solver.Win:
+				remaining[i]--
+				if remaining[i] == 0 && !g.immediateDraw[i] {
+					set(i, Value{Outcome: solver.Loss, Plies: value.Plies + 1})
+				}
+			}
+		}
+	}
+
+	for i := range values {
+		if !known[i] {
+			values[i] = Value{Outcome: solver.Draw}
+		}
+	}
+	return values
+}
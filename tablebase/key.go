@@ -0,0 +1,38 @@
+package tablebase
+
+import (
+	"g4"
+	"g4/bitsim"
+)
+
+// Key identifies a position up to symmetry.
+//
+// Positions are normalized with yellow to move, by swapping colors if red is
+// to move, then the smallest of the board and its mirror image is kept.
+type Key struct {
+	Yellow, Red uint64
+}
+
+// Canonical returns the key of a position.
+func Canonical(g bitsim.Game) Key {
+	board := g.Board
+	if g.Mover == g4.Red {
+		board = board.SwapColors()
+	}
+	key, mirrored := keyOf(board), keyOf(board.Mirror())
+	if mirrored.less(key) {
+		return mirrored
+	}
+	return key
+}
+
+func keyOf(b bitsim.Board) Key {
+	return Key{Yellow: b.Tokens(g4.Yellow), Red: b.Tokens(g4.Red)}
+}
+
+func (k Key) less(other Key) bool {
+	if k.Yellow != other.Yellow {
+		return k.Yellow < other.Yellow
+	}
+	return k.Red < other.Red
+}
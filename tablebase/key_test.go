@@ -0,0 +1,27 @@
+package tablebase_test
+
+import (
+	"g4"
+	"g4/bitsim"
+	"g4/tablebase"
+	"testing"
+)
+
+func TestCanonical(t *testing.T) {
+	board, _ := bitsim.FromString("yr6|r7|8|yyr5|8|8|8|8")
+	game := bitsim.Game{Board: board, Mover: g4.Yellow}
+	key := tablebase.Canonical(game)
+	symmetric := []bitsim.Game{
+		{Board: board.Mirror(), Mover: g4.Yellow},
+		{Board: board.SwapColors(), Mover: g4.Red},
+		{Board: board.SwapColors().Mirror(), Mover: g4.Red},
+	}
+	for k, g := range symmetric {
+		if got := tablebase.Canonical(g); got != key {
+			t.Errorf("example %d: got key %v but want %v", k, got, key)
+		}
+	}
+	if tablebase.Canonical(bitsim.Game{Board: board, Mover: g4.Red}) == key {
+		t.Errorf("mover is not part of the key")
+	}
+}
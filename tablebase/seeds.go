@@ -0,0 +1,49 @@
+package tablebase
+
+import (
+	"g4"
+	"g4/bitsim"
+	"math/rand"
+)
+
+// maxSeedTries bounds the number of attempts of RandomSeed.
+const maxSeedTries = 1000
+
+// RandomSeed returns a random live position with maxEmpty empty squares, to seed Generate.
+//
+// Tokens are dropped in random columns with random colors, avoiding
+// connect-4s, and the position must pass bitsim.Board.IsReachable.
+func RandomSeed(r *rand.Rand, maxEmpty int) (bitsim.Game, bool) {
+	for tries := 0; tries < maxSeedTries; tries++ {
+		if g, ok := randomFill(r, maxEmpty); ok {
+			return g, true
+		}
+	}
+	return bitsim.Game{}, false
+}
+
+func randomFill(r *rand.Rand, maxEmpty int) (bitsim.Game, bool) {
+	colors := [2]g4.Color{g4.Yellow, g4.Red}
+	board, _ := bitsim.FromString(bitsim.StartingPosition)
+	for board.Count() < 64-maxEmpty {
+		column := r.Intn(8)
+		if board.Heights()[column] == 8 {
+			continue
+		}
+		first := r.Intn(2)
+		next := board.AddToken(column, colors[first])
+		if isOver(next) {
+			next = board.AddToken(column, colors[1-first])
+			if isOver(next) {
+				return bitsim.Game{}, false
+			}
+		}
+		board = next
+	}
+	g := bitsim.Game{Board: board, Mover: colors[r.Intn(2)]}
+	return g, board.IsReachable(g.Mover) == nil
+}
+
+func isOver(b bitsim.Board) bool {
+	return bitsim.Game{Board: b, Mover: g4.Yellow}.Validate() != nil
+}
@@ -0,0 +1,214 @@
+// Package tablebase solves nearly-full g4 boards and stores their exact values.
+//
+// Tables are built by retrograde analysis (see Generate) and stored on disk
+// with one entry per canonical position (see Key). They only apply to games
+// played under the standard rules.
+package tablebase
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"g4/solver"
+	"io"
+	"os"
+	"sort"
+)
+
+// magic starts every table file.
+const magic = "G4TB\x01"
+
+// Table maps positions to their exact values.
+type Table struct {
+	// MaxEmpty is the greatest number of empty squares of the positions of the table.
+	MaxEmpty int
+
+	// keys are sorted, and values[i] is the encoded value of keys[i].
+	keys   []Key
+	values []byte
+}
+
+// Len returns the number of positions in the table.
+func (t *Table) Len() int {
+	return len(t.keys)
+}
+
+// Probe returns the value of g for its mover, if the table holds it.
+func (t *Table) Probe(g bitsim.Game) (Value, bool) {
+	if g.Rules != (bitsim.Rules{}) || 64-g.Board.Count() > t.MaxEmpty {
+		return Value{}, false
+	}
+	key := Canonical(g)
+	i := sort.Search(len(t.keys), func(i int) bool {
+		return !t.keys[i].less(key)
+	})
+	if i == len(t.keys) || t.keys[i] != key {
+		return Value{}, false
+	}
+	value, err := decode(t.values[i])
+	return value, err == nil
+}
+
+// BestMove returns the best move of the mover according to the table, along with the value of g.
+//
+// It fails if the table does not hold g or one of its successors.
+func (t *Table) BestMove(g bitsim.Game) (g4.Move, Value, bool) {
+	moves, err := g.Generate()
+	if err != nil {
+		return g4.Move{}, Value{}, false
+	}
+	var bestMove g4.Move
+	var best Value
+	for k, move := range moves {
+		var value Value
+		next, err := g.Apply(move)
+		switch err.(type) {
+		case nil:
+			childValue, ok := t.Probe(next)
+			if !ok {
+				return g4.Move{}, Value{}, false
+			}
+			value = childValue.parent()
+		case g4.YellowWins, g4.RedWins:
+			value = Value{Outcome: solver.Loss, Plies: 1}
+			if g4.IsWin(err, g.Mover) {
+				value.Outcome = solver.Win
+			}
+		default:
+			value = Value{Outcome: solver.Draw}
+		}
+		if k == 0 || value.better(best) {
+			bestMove, best = move, value
+		}
+	}
+	return bestMove, best, true
+}
+
+// Merge returns a table holding the positions of all given tables.
+func Merge(tables ...*Table) *Table {
+	merged := &Table{}
+	seen := make(map[Key]bool)
+	for _, t := range tables {
+		if t.MaxEmpty > merged.MaxEmpty {
+			merged.MaxEmpty = t.MaxEmpty
+		}
+		for i, key := range t.keys {
+			if !seen[key] {
+				seen[key] = true
+				merged.keys = append(merged.keys, key)
+				merged.values = append(merged.values, t.values[i])
+			}
+		}
+	}
+	merged.sort()
+	return merged
+}
+
+// sort sorts the entries of the table by key.
+func (t *Table) sort() {
+	order := make([]int, len(t.keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return t.keys[order[a]].less(t.keys[order[b]])
+	})
+	keys := make([]Key, len(t.keys))
+	values := make([]byte, len(t.values))
+	for i, j := range order {
+		keys[i], values[i] = t.keys[j], t.values[j]
+	}
+	t.keys, t.values = keys, values
+}
+
+// Write writes the table in binary form.
+//
+// The file starts with a magic string, the maximum number of empty squares
+// and the number of entries (as an unsigned varint). Entries follow by
+// increasing key, each made of the yellow and red bitboards of the key
+// (8 bytes each, little-endian) and of one byte of value.
+func (t *Table) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	header := append([]byte(magic), byte(t.MaxEmpty))
+	var buf [binary.MaxVarintLen64]byte
+	header = append(header, buf[:binary.PutUvarint(buf[:], uint64(len(t.keys)))]...)
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+	var entry [17]byte
+	for i, key := range t.keys {
+		binary.LittleEndian.PutUint64(entry[:], key.Yellow)
+		binary.LittleEndian.PutUint64(entry[8:], key.Red)
+		entry[16] = t.values[i]
+		if _, err := bw.Write(entry[:]); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Read reads a table written by Write.
+func Read(r io.Reader) (*Table, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(magic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("error reading header: %w", err)
+	}
+	if string(header[:len(magic)]) != magic {
+		return nil, errors.New("not a tablebase file")
+	}
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("error reading header: %w", err)
+	}
+
+	t := &Table{MaxEmpty: int(header[len(magic)])}
+	var entry [17]byte
+	for i := uint64(0); i < count; i++ {
+		if _, err := io.ReadFull(br, entry[:]); err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i, err)
+		}
+		key := Key{
+			Yellow: binary.LittleEndian.Uint64(entry[:]),
+			Red:    binary.LittleEndian.Uint64(entry[8:]),
+		}
+		if _, err := decode(entry[16]); err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i, err)
+		}
+		if i > 0 && !t.keys[i-1].less(key) {
+			return nil, fmt.Errorf("entry %d: keys are not sorted", i)
+		}
+		t.keys = append(t.keys, key)
+		t.values = append(t.values, entry[16])
+	}
+	if _, err := br.ReadByte(); err != io.EOF {
+		return nil, errors.New("trailing data after the entries")
+	}
+	return t, nil
+}
+
+// Load reads a table from a file.
+func Load(path string) (*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Save writes the table to a file.
+func (t *Table) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := t.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
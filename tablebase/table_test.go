@@ -0,0 +1,142 @@
+package tablebase_test
+
+import (
+	"bytes"
+	"context"
+	"g4"
+	"g4/bitsim"
+	"g4/solver"
+	"g4/tablebase"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+func seeds(t *testing.T, maxEmpty, n int) []bitsim.Game {
+	t.Helper()
+	r := rand.New(rand.NewSource(1))
+	var games []bitsim.Game
+	for len(games) < n {
+		g, ok := tablebase.RandomSeed(r, maxEmpty)
+		if !ok {
+			t.Fatalf("no seed found")
+		}
+		if got := 64 - g.Board.Count(); got != maxEmpty {
+			t.Fatalf("got seed with %d empty squares", got)
+		}
+		games = append(games, g)
+	}
+	return games
+}
+
+// Tests the tables against the solver, and checks that best moves are consistent with values.
+func TestGenerate(t *testing.T) {
+	games := seeds(t, 6, 10)
+	table, err := tablebase.Generate(games, 6, 100000)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	outcomes := make(map[solver.Outcome]int)
+	for k, g := range games {
+		value, ok := table.Probe(g)
+		if !ok {
+			t.Fatalf("seed %d is not in the table", k)
+		}
+		outcomes[value.Outcome]++
+
+		result, err := solver.Solve(context.Background(), g, 100000)
+		if err != nil {
+			t.Fatalf("seed %d: solver error: %v", k, err)
+		}
+		if result.Outcome != value.Outcome {
+			t.Errorf("seed %d (%s): got %v but the solver says %v", k, g.Board, value, result.Outcome)
+		}
+
+		move, best, ok := table.BestMove(g)
+		if !ok || best != value {
+			t.Errorf("seed %d: best move %v has value %v but position has %v", k, move, best, value)
+		}
+	}
+	if len(outcomes) < 2 {
+		t.Errorf("seeds are not diverse enough: %v", outcomes)
+	}
+}
+
+func TestGenerateLimit(t *testing.T) {
+	games := seeds(t, 10, 10)
+	if _, err := tablebase.Generate(games, 10, 10); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestProbe(t *testing.T) {
+	games := seeds(t, 4, 1)
+	table, _ := tablebase.Generate(games, 4, 100000)
+
+	g := games[0]
+	if _, ok := table.Probe(g); !ok {
+		t.Errorf("seed is not in the table")
+	}
+	g.Rules = bitsim.Rules{NoProgressLimit: 10}
+	if _, ok := table.Probe(g); ok {
+		t.Errorf("positions under other rules should not be found")
+	}
+	board, _ := bitsim.FromString("y7|8|8|8|8|8|8|8")
+	if _, ok := table.Probe(bitsim.Game{Board: board, Mover: g4.Red}); ok {
+		t.Errorf("positions with many empty squares should not be found")
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	table, _ := tablebase.Generate(seeds(t, 6, 5), 6, 100000)
+	var buf bytes.Buffer
+	if err := table.Write(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data := buf.Bytes()
+
+	got, err := tablebase.Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Len() != table.Len() || got.MaxEmpty != 6 {
+		t.Errorf("got %d entries (%d empty) but want %d (6 empty)", got.Len(), got.MaxEmpty, table.Len())
+	}
+
+	examples := [][]byte{
+		nil,
+		[]byte("G4XX\x01\x06\x00"),
+		data[:len(data)-1],
+		append(append([]byte{}, data...), 0),
+	}
+	for k, ex := range examples {
+		if _, err := tablebase.Read(bytes.NewReader(ex)); err == nil {
+			t.Errorf("example %d: expected error", k)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "table.g4tb")
+	if err := table.Save(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, err := tablebase.Load(path); err != nil || got.Len() != table.Len() {
+		t.Errorf("got (%v, %v)", got, err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	games := seeds(t, 6, 4)
+	a, _ := tablebase.Generate(games[:3], 6, 100000)
+	b, _ := tablebase.Generate(games[1:], 6, 100000)
+	all, _ := tablebase.Generate(games, 6, 100000)
+	merged := tablebase.Merge(a, b)
+	if merged.Len() != all.Len() || merged.MaxEmpty != 6 {
+		t.Errorf("got %d entries (%d empty) but want %d (6 empty)", merged.Len(), merged.MaxEmpty, all.Len())
+	}
+	for k, g := range games {
+		want, _ := all.Probe(g)
+		if got, ok := merged.Probe(g); !ok || got != want {
+			t.Errorf("seed %d: got (%v, %v) but want %v", k, got, ok, want)
+		}
+	}
+}
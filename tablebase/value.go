@@ -0,0 +1,80 @@
+package tablebase
+
+import (
+	"fmt"
+	"g4/solver"
+)
+
+// maxPlies is the greatest distance stored in a table. Longer distances are stored as maxPlies.
+const maxPlies = 127
+
+// Value is the exact value of a position for the player with the move.
+type Value struct {
+	// Outcome is Win, Loss or Draw.
+	Outcome solver.Outcome
+
+	// Plies is the number of moves before the end of the game with best play,
+	// for wins and losses: the winner hurries, the loser delays.
+	Plies int
+}
+
+func (v Value) String() string {
+	if v.Outcome == solver.Draw {
+		return "draw"
+	}
+	return fmt.Sprintf("%v in %d", v.Outcome, v.Plies)
+}
+
+// better returns whether v is preferable to other for the player with the move.
+func (v Value) better(other Value) bool {
+	rank := func(v Value) int {
+		switch v.Outcome {
+		case solver.Win:
+			return 2*maxPlies + 2 - v.Plies
+		case solver.Draw:
+			return maxPlies + 1
+		}
+		return v.Plies
+	}
+	return rank(v) > rank(other)
+}
+
+// parent returns the value of a position for the player who moved into a position of value v.
+func (v Value) parent() Value {
+	switch v.Outcome {
+	case solver.Win:
+		return Value{Outcome: solver.Loss, Plies: v.Plies + 1}
+	case solver.Loss:
+		return Value{Outcome: solver.Win, Plies: v.Plies + 1}
+	}
+	return v
+}
+
+// encode stores a value on one byte.
+//
+// A draw is 0, a win in n plies is n and a loss in n plies is 128+n.
+func (v Value) encode() byte {
+	plies := v.Plies
+	if plies > maxPlies {
+		plies = maxPlies
+	}
+	switch v.Outcome {
+	case solver.Win:
+		return byte(plies)
+	case solver.Loss:
+		return byte(128 + plies)
+	}
+	return 0
+}
+
+func decode(b byte) (Value, error) {
+	switch {
+	case b == 0:
+		return Value{Outcome: solver.Draw}, nil
+	case b < 128:
+		return Value{Outcome: solver.Win, Plies: int(b)}, nil
+	case b > 128:
+		return Value{Outcome: solver.Loss, Plies: int(b) - 128}, nil
+	}
+	return Value{}, fmt.Errorf("invalid value: %d", b)
+}
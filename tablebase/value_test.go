@@ -0,0 +1,52 @@
+package tablebase
+
+import (
+	"g4/solver"
+	"testing"
+)
+
+func TestValueEncoding(t *testing.T) {
+	examples := []struct {
+		in  Value
+		out byte
+	}{
+		{in: Value{Outcome: solver.Draw}, out: 0},
+		{in: Value{Outcome: solver.Win, Plies: 1}, out: 1},
+		{in: Value{Outcome: solver.Win, Plies: 127}, out: 127},
+		{in: Value{Outcome: solver.Loss, Plies: 1}, out: 129},
+		{in: Value{Outcome: solver.Loss, Plies: 12}, out: 140},
+	}
+	for k, ex := range examples {
+		if got := ex.in.encode(); got != ex.out {
+			t.Errorf("example %d: got %d but want %d", k, got, ex.out)
+		}
+		if got, err := decode(ex.out); err != nil || got != ex.in {
+			t.Errorf("example %d: got (%v, %v) but want (%v, nil)", k, got, err, ex.in)
+		}
+	}
+	if got := (Value{Outcome: solver.Win, Plies: 300}).encode(); got != 127 {
+		t.Errorf("got %d for a long win", got)
+	}
+	if _, err := decode(128); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestValueOrder(t *testing.T) {
+	// From the worst to the best.
+	values := []Value{
+		{Outcome: solver.Loss, Plies: 1},
+		{Outcome: solver.Loss, Plies: 5},
+		{Outcome: solver.Draw},
+		{Outcome: solver.Win, Plies: 9},
+		{Outcome: solver.Win, Plies: 1},
+	}
+	for k := 1; k < len(values); k++ {
+		if !values[k].better(values[k-1]) || values[k-1].better(values[k]) {
+			t.Errorf("%v should be better than %v", values[k], values[k-1])
+		}
+	}
+	if got := (Value{Outcome: solver.Loss, Plies: 4}).parent(); got != (Value{Outcome: solver.Win, Plies: 5}) {
+		t.Errorf("got parent %v", got)
+	}
+}
@@ -0,0 +1,56 @@
+package tournament
+
+import (
+	"g4"
+	"g4/bitsim"
+	"g4/protocol"
+	"g4/record"
+	"math/rand"
+)
+
+// RandomOpenings returns n openings of given number of random moves, played
+// from the starting position under given rules.
+//
+// Openings ending the game are drawn again.
+func RandomOpenings(r *rand.Rand, n, plies int, rules bitsim.Rules) []protocol.Position {
+	openings := make([]protocol.Position, 0, n)
+	for len(openings) < n {
+		p := protocol.Position{Start: bitsim.Game{Mover: g4.Yellow, Rules: rules}}
+		game := p.Start
+		for k := 0; k < plies; k++ {
+			moves, err := game.Generate()
+			if err != nil {
+				break
+			}
+			move := moves[r.Intn(len(moves))]
+			p.Moves = append(p.Moves, move)
+			if game, err = game.Apply(move); err != nil {
+				break
+			}
+		}
+		if game.Validate() == nil {
+			openings = append(openings, p)
+		}
+	}
+	return openings
+}
+
+// RecordOpenings returns the first plies moves of recorded games, skipping
+// invalid records and openings where the game is already over.
+func RecordOpenings(records []*record.Record, plies int) []protocol.Position {
+	var openings []protocol.Position
+	for _, rec := range records {
+		start, err := rec.StartingGame()
+		if err != nil {
+			continue
+		}
+		p := protocol.Position{Start: start}
+		for k := 0; k < plies && k < len(rec.Moves); k++ {
+			p.Moves = append(p.Moves, rec.Moves[k].Move)
+		}
+		if game, err := p.Game(); err == nil && game.Validate() == nil {
+			openings = append(openings, p)
+		}
+	}
+	return openings
+}
@@ -0,0 +1,108 @@
+package tournament
+
+import (
+	"context"
+	"errors"
+	"g4"
+	"g4/protocol"
+	"g4/search"
+	"strings"
+	"time"
+)
+
+// Player plays the moves of an engine, in one game at a time.
+type Player interface {
+	// NewGame tells the player that a new game starts.
+	NewGame() error
+
+	// Move returns the move of the player in a position.
+	Move(ctx context.Context, p protocol.Position) (g4.Move, error)
+
+	// Close releases the resources of the player.
+	Close() error
+}
+
+// Engine is a participant of a tournament.
+//
+// Games running at the same time use distinct players, all started by Start.
+type Engine struct {
+	Name  string
+	Start func() (Player, error)
+}
+
+// Builtin returns an engine using the search package, thinking within clock on each move.
+func Builtin(name string, options search.Options, level search.Level, clock search.Clock) Engine {
+	return Engine{
+		Name: name,
+		Start: func() (Player, error) {
+			controller := search.NewController(search.New(options))
+			controller.SetLevel(level)
+			return &builtinPlayer{controller: controller, clock: clock}, nil
+		},
+	}
+}
+
+type builtinPlayer struct {
+	controller *search.Controller
+	clock      search.Clock
+}
+
+func (p *builtinPlayer) NewGame() error {
+	return nil
+}
+
+func (p *builtinPlayer) Move(ctx context.Context, position protocol.Position) (g4.Move, error) {
+	game, err := position.Game()
+	if err != nil {
+		return g4.Move{}, err
+	}
+	result, err := p.controller.Think(ctx, game, p.clock)
+	return result.Move, err
+}
+
+func (p *builtinPlayer) Close() error {
+	p.controller.Close()
+	return nil
+}
+
+// External returns an engine run by a command line, split on spaces, and
+// speaking the protocol of package protocol. Each move is searched within limits.
+//
+// Engines overrunning a fixed move time by more than a second are stopped.
+func External(name, commandLine string, limits protocol.Go) Engine {
+	words := strings.Fields(commandLine)
+	return Engine{
+		Name: name,
+		Start: func() (Player, error) {
+			if len(words) == 0 {
+				return nil, errors.New("empty engine command")
+			}
+			client, err := protocol.Start(words[0], words[1:], protocol.DefaultHandshakeTimeout)
+			if err != nil {
+				return nil, err
+			}
+			return &externalPlayer{client: client, limits: limits}, nil
+		},
+	}
+}
+
+type externalPlayer struct {
+	client *protocol.Client
+	limits protocol.Go
+}
+
+func (p *externalPlayer) NewGame() error {
+	return p.client.NewGame()
+}
+
+func (p *externalPlayer) Move(ctx context.Context, position protocol.Position) (g4.Move, error) {
+	var timeout time.Duration
+	if p.limits.MoveTime > 0 {
+		timeout = p.limits.MoveTime + protocol.DefaultTimeoutMargin
+	}
+	return p.client.Think(ctx, position, p.limits, timeout, nil)
+}
+
+func (p *externalPlayer) Close() error {
+	return p.client.Close()
+}
@@ -0,0 +1,237 @@
+package tournament
+
+import (
+	"fmt"
+	"g4/record"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+)
+
+// Score counts the results of an engine.
+type Score struct {
+	Wins, Draws, Losses int
+}
+
+// Games returns the number of games played.
+func (s Score) Games() int {
+	return s.Wins + s.Draws + s.Losses
+}
+
+// Points returns the number of points scored, a draw being worth half a point.
+func (s Score) Points() float64 {
+	return float64(s.Wins) + float64(s.Draws)/2
+}
+
+// Ratio returns the average points per game, or 0.5 if no game was played.
+func (s Score) Ratio() float64 {
+	if s.Games() == 0 {
+		return 0.5
+	}
+	return s.Points() / float64(s.Games())
+}
+
+// moments returns the mean and the variance of the points of a game.
+//
+// Missing results count as regularize games, so that the variance is not zero
+// when all games were won, for instance.
+func (s Score) moments(regularize float64) (mean, variance float64) {
+	counts := [3]float64{float64(s.Losses), float64(s.Draws), float64(s.Wins)}
+	var n float64
+	for k := range counts {
+		if counts[k] == 0 {
+			counts[k] = regularize
+		}
+		n += counts[k]
+	}
+	if n == 0 {
+		return 0.5, 0
+	}
+	for k, count := range counts {
+		mean += count * float64(k) / 2
+	}
+	mean /= n
+	for k, count := range counts {
+		d := float64(k)/2 - mean
+		variance += count * d * d
+	}
+	return mean, variance / n
+}
+
+// Elo returns the Elo difference matching the score, along with the half-width
+// of its 95% confidence interval.
+//
+// The difference is infinite when all games were won or lost.
+func (s Score) Elo() (elo, margin float64) {
+	r := s.Ratio()
+	elo = eloDifference(r)
+	if s.Games() == 0 || math.IsInf(elo, 0) {
+		return elo, math.Inf(1)
+	}
+	_, variance := s.moments(0)
+	delta := 1.959964 * math.Sqrt(variance/float64(s.Games()))
+	return elo, (eloDifference(r+delta) - eloDifference(r-delta)) / 2
+}
+
+// eloDifference returns the Elo difference of players scoring ratio r against each other.
+func eloDifference(r float64) float64 {
+	switch {
+	case r <= 0:
+		return math.Inf(-1)
+	case r >= 1:
+		return math.Inf(1)
+	}
+	return -400 * math.Log10(1/r-1)
+}
+
+// expectedRatio returns the expected score ratio of a player stronger by elo.
+func expectedRatio(elo float64) float64 {
+	return 1 / (1 + math.Pow(10, -elo/400))
+}
+
+// Decision is the outcome of a sequential probability ratio test.
+type Decision int
+
+const (
+	Continue Decision = iota // More games are needed.
+	AcceptH0                 // The Elo difference is rather Elo0.
+	AcceptH1                 // The Elo difference is rather Elo1.
+)
+
+// String returns a description of the decision.
+func (d Decision) String() string {
+	switch d {
+	case AcceptH0:
+		return "H0 accepted"
+	case AcceptH1:
+		return "H1 accepted"
+	}
+	return "inconclusive"
+}
+
+// SPRT is a sequential probability ratio test on the Elo difference between two engines.
+//
+// It tests H0: elo = Elo0 against H1: elo = Elo1, with false positive rate
+// Alpha and false negative rate Beta.
+type SPRT struct {
+	Elo0, Elo1  float64
+	Alpha, Beta float64
+}
+
+// Bounds returns the log-likelihood ratios below which H0 is accepted, and above which H1 is.
+func (t SPRT) Bounds() (lower, upper float64) {
+	return math.Log(t.Beta / (1 - t.Alpha)), math.Log((1 - t.Beta) / t.Alpha)
+}
+
+// LLR returns the log-likelihood ratio of H1 against H0 given a score.
+//
+// It uses the normal approximation of the score distribution, which is good
+// enough for the number of games a test usually takes. Results not seen yet
+// count as half a game, so that a few games of a kind do not conclude.
+func (t SPRT) LLR(s Score) float64 {
+	if s.Games() == 0 {
+		return 0
+	}
+	mean, variance := s.moments(0.5)
+	s0, s1 := expectedRatio(t.Elo0), expectedRatio(t.Elo1)
+	return float64(s.Games()) * (s1 - s0) * (2*mean - s0 - s1) / (2 * variance)
+}
+
+// Decide returns the decision of the test given a score.
+func (t SPRT) Decide(s Score) Decision {
+	llr := t.LLR(s)
+	lower, upper := t.Bounds()
+	switch {
+	case llr <= lower:
+		return AcceptH0
+	case llr >= upper:
+		return AcceptH1
+	}
+	return Continue
+}
+
+// Score returns the score of engine i against engine j, or against all
+// engines if j is negative.
+func (r *Results) Score(i, j int) Score {
+	var s Score
+	for _, game := range r.Games {
+		var color record.Result
+		switch {
+		case game.Yellow == i && (j < 0 || game.Red == j):
+			color = record.YellowWins
+		case game.Red == i && (j < 0 || game.Yellow == j):
+			color = record.RedWins
+		default:
+			continue
+		}
+		switch game.Record.Result {
+		case color:
+			s.Wins++
+		case record.Draw:
+			s.Draws++
+		case record.YellowWins, record.RedWins:
+			s.Losses++
+		}
+	}
+	return s
+}
+
+// WriteCrosstable writes the standings, from the best engine to the worst.
+//
+// Elo differences are relative to the opponents met. Each engine gets a
+// column with the points scored against it.
+func (r *Results) WriteCrosstable(w io.Writer) error {
+	ranking := make([]int, len(r.Names))
+	for k := range ranking {
+		ranking[k] = k
+	}
+	sort.SliceStable(ranking, func(a, b int) bool {
+		return r.Score(ranking[a], -1).Points() > r.Score(ranking[b], -1).Points()
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "#\tEngine\tGames\tPoints\tScore\tElo\t\tW/D/L\t")
+	for rank := range ranking {
+		fmt.Fprintf(tw, "%d\t", rank+1)
+	}
+	fmt.Fprintln(tw)
+	for rank, i := range ranking {
+		s := r.Score(i, -1)
+		elo, margin := s.Elo()
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%.1f\t%.1f%%\t%s\t±%s\t%d/%d/%d\t",
+			rank+1, r.Names[i], s.Games(), s.Points(), 100*s.Ratio(),
+			formatElo(elo), formatElo(margin), s.Wins, s.Draws, s.Losses)
+		for _, j := range ranking {
+			if h := r.Score(i, j); i != j && h.Games() > 0 {
+				fmt.Fprintf(tw, "%.1f/%d\t", h.Points(), h.Games())
+			} else {
+				fmt.Fprint(tw, "-\t")
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if r.SPRT != nil {
+		s := r.Score(0, 1)
+		lower, upper := r.SPRT.Bounds()
+		_, err := fmt.Fprintf(w, "\nSPRT: elo0 %g, elo1 %g, llr %.2f (%.2f, %.2f), %s\n",
+			r.SPRT.Elo0, r.SPRT.Elo1, r.SPRT.LLR(s), lower, upper, r.Decision)
+		return err
+	}
+	return nil
+}
+
+// formatElo writes an Elo difference, which may be infinite.
+func formatElo(elo float64) string {
+	switch {
+	case math.IsInf(elo, 1):
+		return "inf"
+	case math.IsInf(elo, -1):
+		return "-inf"
+	}
+	return fmt.Sprintf("%.0f", elo)
+}
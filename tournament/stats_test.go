@@ -0,0 +1,101 @@
+package tournament_test
+
+import (
+	"bytes"
+	"g4/record"
+	"g4/tournament"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestElo(t *testing.T) {
+	examples := []struct {
+		score       tournament.Score
+		elo, margin float64
+	}{
+		{tournament.Score{Wins: 10, Losses: 10}, 0, 163.32},
+		{tournament.Score{Wins: 30, Draws: 10, Losses: 10}, 147.19, 95.14},
+		{tournament.Score{Wins: 20, Draws: 60, Losses: 20}, 0, 43.29},
+		{tournament.Score{Wins: 30, Draws: 50, Losses: 20}, 34.86, 48.47},
+	}
+	for k, example := range examples {
+		elo, margin := example.score.Elo()
+		if math.Abs(elo-example.elo) > 0.01 || math.Abs(margin-example.margin) > 0.01 {
+			t.Errorf("example %d: got %.2f ± %.2f but want %.2f ± %.2f", k, elo, margin, example.elo, example.margin)
+		}
+	}
+}
+
+func TestEloInfinite(t *testing.T) {
+	elo, margin := tournament.Score{Wins: 3}.Elo()
+	if !math.IsInf(elo, 1) || !math.IsInf(margin, 1) {
+		t.Errorf("got %v ± %v but want +Inf ± +Inf", elo, margin)
+	}
+	if elo, _ := (tournament.Score{Losses: 3}).Elo(); !math.IsInf(elo, -1) {
+		t.Errorf("got %v but want -Inf", elo)
+	}
+}
+
+func TestSPRT(t *testing.T) {
+	sprt := tournament.SPRT{Elo0: 0, Elo1: 10, Alpha: 0.05, Beta: 0.05}
+	examples := []struct {
+		score    tournament.Score
+		llr      float64
+		decision tournament.Decision
+	}{
+		{tournament.Score{}, 0, tournament.Continue},
+		{tournament.Score{Wins: 30, Draws: 50, Losses: 20}, 0.50, tournament.Continue},
+		{tournament.Score{Wins: 300, Draws: 500, Losses: 200}, 5.03, tournament.AcceptH1},
+		{tournament.Score{Wins: 200, Draws: 500, Losses: 300}, -6.72, tournament.AcceptH0},
+	}
+	for k, example := range examples {
+		if llr := sprt.LLR(example.score); math.Abs(llr-example.llr) > 0.01 {
+			t.Errorf("example %d: got llr %.2f but want %.2f", k, llr, example.llr)
+		}
+		if decision := sprt.Decide(example.score); decision != example.decision {
+			t.Errorf("example %d: got %v but want %v", k, decision, example.decision)
+		}
+	}
+	// All games won still gives a decision.
+	if decision := sprt.Decide(tournament.Score{Wins: 20}); decision != tournament.AcceptH1 {
+		t.Errorf("got %v after 20 wins but want %v", decision, tournament.AcceptH1)
+	}
+}
+
+func TestCrosstable(t *testing.T) {
+	results := &tournament.Results{
+		Names: []string{"weak", "strong", "medium"},
+		Games: []tournament.Game{
+			{Yellow: 0, Red: 1, Record: &record.Record{Result: record.RedWins}},
+			{Yellow: 1, Red: 0, Record: &record.Record{Result: record.YellowWins}},
+			{Yellow: 1, Red: 2, Record: &record.Record{Result: record.Draw}},
+			{Yellow: 2, Red: 1, Record: &record.Record{Result: record.RedWins}},
+			{Yellow: 0, Red: 2, Record: &record.Record{Result: record.YellowWins}},
+			{Yellow: 2, Red: 0, Record: &record.Record{Result: record.YellowWins}},
+		},
+	}
+	if s := results.Score(1, -1); s != (tournament.Score{Wins: 3, Draws: 1}) {
+		t.Errorf("got %+v for strong", s)
+	}
+	if s := results.Score(0, 2); s != (tournament.Score{Wins: 1, Losses: 1}) {
+		t.Errorf("got %+v for weak against medium", s)
+	}
+
+	var b bytes.Buffer
+	if err := results.WriteCrosstable(&b); err != nil {
+		t.Fatalf("error in WriteCrosstable: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines but want 4:\n%s", len(lines), b.String())
+	}
+	for k, name := range []string{"strong", "medium", "weak"} {
+		if fields := strings.Fields(lines[k+1]); len(fields) < 2 || fields[1] != name {
+			t.Errorf("rank %d: got %q but want %s", k+1, lines[k+1], name)
+		}
+	}
+	if !strings.HasSuffix(strings.TrimSpace(lines[1]), "-  1.5/2  2.0/2") {
+		t.Errorf("got head-to-head %q", lines[1])
+	}
+}
@@ -0,0 +1,346 @@
+// Package tournament runs matches between engines and estimates their strength.
+//
+// Engines are the built-in one, in any configuration, and external engines
+// speaking the protocol of package protocol. Every opening is played twice by
+// each pair of engines, colours swapped, so that unbalanced openings favour
+// nobody. Games run concurrently, and are adjudicated by rule.
+package tournament
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"g4"
+	"g4/bitsim"
+	"g4/protocol"
+	"g4/record"
+	"g4/solver"
+	"g4/tablebase"
+	"strconv"
+	"sync"
+)
+
+const (
+	// DefaultMoveLimit is the default number of moves after the opening after which games are drawn.
+	DefaultMoveLimit = 300
+
+	// TablebaseAdjudication is the reason of games adjudicated by the tablebase.
+	TablebaseAdjudication record.Reason = "tablebase"
+
+	// Forfeit is the reason of games lost by an engine failing to move.
+	Forfeit record.Reason = "forfeit"
+)
+
+// Format selects the pairings of a tournament.
+type Format int
+
+const (
+	RoundRobin Format = iota // Every engine plays every other.
+	Gauntlet                 // The first engine plays every other.
+)
+
+// ParseFormat returns the format of given name: "round-robin" or "gauntlet".
+func ParseFormat(name string) (Format, error) {
+	switch name {
+	case "round-robin":
+		return RoundRobin, nil
+	case "gauntlet":
+		return Gauntlet, nil
+	}
+	return 0, fmt.Errorf("unknown format '%s' (expected round-robin or gauntlet)", name)
+}
+
+// Pairings returns the pairs of engines playing each other, among n engines.
+func (f Format) Pairings(n int) [][2]int {
+	var pairs [][2]int
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if f == Gauntlet && i > 0 {
+				break
+			}
+			pairs = append(pairs, [2]int{i, j})
+		}
+	}
+	return pairs
+}
+
+// Options configures a tournament.
+type Options struct {
+	Engines []Engine
+	Format  Format
+
+	// Openings are played twice by each pair of engines, colours swapped.
+	Openings []protocol.Position
+
+	// Concurrency is the number of games played at the same time. It defaults to 1.
+	Concurrency int
+
+	// MoveLimit is the number of moves after the opening after which games
+	// are drawn. It defaults to DefaultMoveLimit.
+	MoveLimit int
+
+	// Tablebase, if not nil, ends the games as soon as their result is known.
+	Tablebase *tablebase.Table
+
+	// SPRT, if not nil, stops the tournament early once the first engine is
+	// found stronger or not than the second. It needs exactly two engines.
+	SPRT *SPRT
+}
+
+// Game is a finished game of a tournament.
+type Game struct {
+	// Yellow and Red are the indices of the engines.
+	Yellow, Red int
+
+	// Opening is the index of the opening.
+	Opening int
+
+	Record *record.Record
+}
+
+// Results holds the games of a tournament.
+type Results struct {
+	Names []string
+	Games []Game
+
+	// SPRT is the test of the tournament, if any, and Decision its outcome.
+	SPRT     *SPRT
+	Decision Decision
+}
+
+// task is a game to play.
+type task struct {
+	yellow, red, opening int
+}
+
+// Run plays a tournament, and calls onGame, if not nil, after each game.
+//
+// Games are scheduled opening after opening. An engine failing to move loses
+// the game. When ctx is done, running games are abandoned, and the results of
+// the finished ones are returned along with the error.
+func Run(ctx context.Context, options Options, onGame func(Game)) (*Results, error) {
+	if len(options.Engines) < 2 {
+		return nil, errors.New("a tournament needs at least two engines")
+	}
+	if options.SPRT != nil && len(options.Engines) != 2 {
+		return nil, errors.New("SPRT needs exactly two engines")
+	}
+	if len(options.Openings) == 0 {
+		options.Openings = []protocol.Position{{Start: bitsim.Game{Mover: g4.Yellow}}}
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = 1
+	}
+	if options.MoveLimit <= 0 {
+		options.MoveLimit = DefaultMoveLimit
+	}
+
+	results := &Results{SPRT: options.SPRT}
+	for _, engine := range options.Engines {
+		results.Names = append(results.Names, engine.Name)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	tasks := make(chan task)
+	stopScheduling := make(chan struct{})
+	go func() {
+		defer close(tasks)
+		pairs := options.Format.Pairings(len(options.Engines))
+		for opening := range options.Openings {
+			for _, pair := range pairs {
+				for _, t := range []task{{pair[0], pair[1], opening}, {pair[1], pair[0], opening}} {
+					select {
+					case tasks <- t:
+					case <-stopScheduling:
+						return
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}
+	}()
+
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	for w := 0; w < options.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			players := make(map[int]Player)
+			defer func() {
+				for _, player := range players {
+					player.Close()
+				}
+			}()
+			for t := range tasks {
+				game, err := playTask(ctx, options, players, t)
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					cancel()
+					return
+				}
+				results.Games = append(results.Games, game)
+				if onGame != nil {
+					onGame(game)
+				}
+				if results.SPRT != nil && results.Decision == Continue {
+					results.Decision = results.SPRT.Decide(results.Score(0, 1))
+					if results.Decision != Continue {
+						close(stopScheduling)
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if firstErr == nil {
+		firstErr = ctx.Err()
+	}
+	return results, firstErr
+}
+
+// playTask plays a game with the players of the worker, starting them if needed.
+func playTask(ctx context.Context, options Options, players map[int]Player, t task) (Game, error) {
+	var both [2]Player
+	for k, index := range []int{t.yellow, t.red} {
+		player, ok := players[index]
+		if !ok {
+			var err error
+			if player, err = options.Engines[index].Start(); err != nil {
+				return Game{}, fmt.Errorf("%s: %w", options.Engines[index].Name, err)
+			}
+			players[index] = player
+		}
+		if err := player.NewGame(); err != nil {
+			return Game{}, fmt.Errorf("%s: %w", options.Engines[index].Name, err)
+		}
+		both[k] = player
+	}
+
+	rec, err := play(ctx, both[0], both[1], options.Openings[t.opening], options)
+	if err != nil {
+		return Game{}, err
+	}
+	rec.Yellow, rec.Red = options.Engines[t.yellow].Name, options.Engines[t.red].Name
+	return Game{Yellow: t.yellow, Red: t.red, Opening: t.opening, Record: rec}, nil
+}
+
+// play plays a game from an opening, and returns its record.
+//
+// Games end by the rules, by 3-fold repetition, by the move limit of the
+// options, when the tablebase knows the result, or when a player fails to
+// move, in which case it loses.
+func play(ctx context.Context, yellow, red Player, opening protocol.Position, options Options) (*record.Record, error) {
+	game, err := opening.Game()
+	if err != nil {
+		return nil, fmt.Errorf("invalid opening: %w", err)
+	}
+	rec := &record.Record{
+		Start: opening.Start.Board.String(),
+		Mover: opening.Start.Mover,
+		Tags:  []record.Tag{{Name: "Opening", Value: strconv.Itoa(len(opening.Moves))}},
+	}
+	if opening.Start.Rules != (bitsim.Rules{}) {
+		rec.Variant = opening.Start.Rules.String()
+	}
+	position := protocol.Position{Start: opening.Start, Moves: append([]g4.Move{}, opening.Moves...)}
+	for _, move := range opening.Moves {
+		rec.Moves = append(rec.Moves, record.Node{Move: move})
+	}
+
+	history := make(map[bitsim.Game]int)
+	for ply := 0; ; ply++ {
+		if ply >= options.MoveLimit {
+			rec.Result, rec.Reason = record.Draw, record.MoveLimit
+			return rec, nil
+		}
+		if options.Tablebase != nil {
+			if value, ok := options.Tablebase.Probe(game); ok {
+				rec.Result, rec.Reason = tablebaseResult(value, game.Mover), TablebaseAdjudication
+				return rec, nil
+			}
+		}
+
+		player := yellow
+		if game.Mover == g4.Red {
+			player = red
+		}
+		move, err := player.Move(ctx, position)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if err == nil && !isLegal(game, move) {
+			err = fmt.Errorf("illegal move %v", move)
+		}
+		var next bitsim.Game
+		if err == nil {
+			next, err = game.Apply(move)
+		}
+		if err != nil && !isOutcome(err) {
+			rec.Result, rec.Reason = winner(game.Mover.Opponent()), Forfeit
+			rec.Comment = err.Error()
+			return rec, nil
+		}
+		position.Moves = append(position.Moves, move)
+		rec.Moves = append(rec.Moves, record.Node{Move: move})
+		if err != nil {
+			rec.Result, rec.Reason = record.Conclude(next)
+			return rec, nil
+		}
+
+		// NB: positions are compared without the move counters.
+		history[bitsim.Game{Board: next.Board, Mover: next.Mover}]++
+		if history[bitsim.Game{Board: next.Board, Mover: next.Mover}] == 3 {
+			rec.Result, rec.Reason = record.Draw, record.Repetition
+			return rec, nil
+		}
+		game = next
+	}
+}
+
+// isLegal returns whether move is a legal move of g.
+func isLegal(g bitsim.Game, move g4.Move) bool {
+	moves, _ := g.Generate()
+	for _, legal := range moves {
+		if legal == move {
+			return true
+		}
+	}
+	return false
+}
+
+// isOutcome returns whether err ends the game.
+func isOutcome(err error) bool {
+	switch err.(type) {
+	case g4.YellowWins, g4.RedWins, g4.Draw, g4.DrawByNoProgress, g4.DrawByMoveLimit:
+		return true
+	}
+	return false
+}
+
+// tablebaseResult returns the result of a game known by the tablebase.
+func tablebaseResult(value tablebase.Value, mover g4.Color) record.Result {
+	switch value.Outcome {
+	case solver.Win:
+		return winner(mover)
+	case solver.Loss:
+		return winner(mover.Opponent())
+	}
+	return record.Draw
+}
+
+func winner(color g4.Color) record.Result {
+	if color == g4.Yellow {
+		return record.YellowWins
+	}
+	return record.RedWins
+}
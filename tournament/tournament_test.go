@@ -0,0 +1,219 @@
+package tournament_test
+
+import (
+	"context"
+	"errors"
+	"g4"
+	"g4/bitsim"
+	"g4/protocol"
+	"g4/record"
+	"g4/search"
+	"g4/tournament"
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+// scripted is a player choosing its moves with a function.
+type scripted struct {
+	choose func(game bitsim.Game) (g4.Move, error)
+}
+
+func (p scripted) NewGame() error { return nil }
+func (p scripted) Close() error   { return nil }
+
+func (p scripted) Move(ctx context.Context, position protocol.Position) (g4.Move, error) {
+	game, err := position.Game()
+	if err != nil {
+		return g4.Move{}, err
+	}
+	return p.choose(game)
+}
+
+func scriptedEngine(name string, choose func(game bitsim.Game) (g4.Move, error)) tournament.Engine {
+	return tournament.Engine{
+		Name:  name,
+		Start: func() (tournament.Player, error) { return scripted{choose}, nil },
+	}
+}
+
+// firstMove plays the first legal move.
+func firstMove(game bitsim.Game) (g4.Move, error) {
+	moves, err := game.Generate()
+	if err != nil {
+		return g4.Move{}, err
+	}
+	return moves[0], nil
+}
+
+func builtinEngine(name string, depth int) tournament.Engine {
+	return tournament.Builtin(name, search.Options{Depth: depth, TableSize: 1 << 12}, search.MaxLevel, search.Clock{})
+}
+
+func TestPairings(t *testing.T) {
+	examples := []struct {
+		format tournament.Format
+		n      int
+		want   [][2]int
+	}{
+		{tournament.RoundRobin, 2, [][2]int{{0, 1}}},
+		{tournament.RoundRobin, 3, [][2]int{{0, 1}, {0, 2}, {1, 2}}},
+		{tournament.Gauntlet, 3, [][2]int{{0, 1}, {0, 2}}},
+		{tournament.Gauntlet, 4, [][2]int{{0, 1}, {0, 2}, {0, 3}}},
+	}
+	for k, example := range examples {
+		got := example.format.Pairings(example.n)
+		if len(got) != len(example.want) {
+			t.Errorf("example %d: got %v but want %v", k, got, example.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != example.want[i] {
+				t.Errorf("example %d: got %v but want %v", k, got, example.want)
+				break
+			}
+		}
+	}
+}
+
+func TestRunSchedule(t *testing.T) {
+	openings := tournament.RandomOpenings(rand.New(rand.NewSource(1)), 2, 4, bitsim.Rules{})
+	options := tournament.Options{
+		Engines:     []tournament.Engine{builtinEngine("a", 1), builtinEngine("b", 2), builtinEngine("c", 1)},
+		Openings:    openings,
+		Concurrency: 3,
+		MoveLimit:   30,
+	}
+	var mu sync.Mutex
+	seen := 0
+	results, err := tournament.Run(context.Background(), options, func(tournament.Game) {
+		mu.Lock()
+		seen++
+		mu.Unlock()
+	})
+	if err != nil {
+		t.Fatalf("error in Run: %v", err)
+	}
+	if len(results.Games) != 12 || seen != 12 {
+		t.Fatalf("got %d games (%d reported) but want 12", len(results.Games), seen)
+	}
+
+	// Each pair plays each opening once with each colour.
+	count := make(map[[3]int]int)
+	for _, game := range results.Games {
+		count[[3]int{game.Yellow, game.Red, game.Opening}]++
+		if game.Record.Result == record.Unfinished {
+			t.Errorf("game %+v is unfinished", game)
+		}
+		if game.Record.Yellow != results.Names[game.Yellow] || game.Record.Red != results.Names[game.Red] {
+			t.Errorf("got players %s and %s for %+v", game.Record.Yellow, game.Record.Red, game)
+		}
+		if _, err := game.Record.Replay(); err != nil {
+			t.Errorf("error in Replay: %v", err)
+		}
+		for k, move := range openings[game.Opening].Moves {
+			if game.Record.Moves[k].Move != move {
+				t.Errorf("move %d: got %v but want opening move %v", k, game.Record.Moves[k].Move, move)
+			}
+		}
+	}
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for opening := range openings {
+				want := 1
+				if i == j {
+					want = 0
+				}
+				if got := count[[3]int{i, j, opening}]; got != want {
+					t.Errorf("got %d games of %d against %d on opening %d but want %d", got, i, j, opening, want)
+				}
+			}
+		}
+	}
+}
+
+func TestRunAdjudication(t *testing.T) {
+	failing := scriptedEngine("failing", func(bitsim.Game) (g4.Move, error) {
+		return g4.Move{}, errors.New("crashed")
+	})
+	illegal := scriptedEngine("illegal", func(game bitsim.Game) (g4.Move, error) {
+		if game.Mover == g4.Yellow {
+			return g4.TokenMove(g4.Red, 0), nil
+		}
+		return g4.TokenMove(g4.Yellow, 0), nil
+	})
+	tilting := scriptedEngine("tilting", func(game bitsim.Game) (g4.Move, error) {
+		return g4.TiltMove(game.Mover, g4.LEFT), nil
+	})
+	first := scriptedEngine("first", firstMove)
+
+	examples := []struct {
+		yellow, red tournament.Engine
+		moveLimit   int
+		result      record.Result
+		reason      record.Reason
+	}{
+		{first, failing, 0, record.YellowWins, tournament.Forfeit},
+		{failing, first, 0, record.RedWins, tournament.Forfeit},
+		{first, illegal, 0, record.YellowWins, tournament.Forfeit},
+		{tilting, tilting, 0, record.Draw, record.Repetition}, // Tilts leave the empty board.
+		{first, first, 4, record.Draw, record.MoveLimit},      // No connect-4 yet.
+	}
+	for k, example := range examples {
+		options := tournament.Options{
+			Engines:   []tournament.Engine{example.yellow, example.red},
+			MoveLimit: example.moveLimit,
+		}
+		results, err := tournament.Run(context.Background(), options, nil)
+		if err != nil {
+			t.Fatalf("example %d: error in Run: %v", k, err)
+		}
+		game := results.Games[0]
+		if game.Yellow != 0 {
+			game = results.Games[1]
+		}
+		if game.Record.Result != example.result || game.Record.Reason != example.reason {
+			t.Errorf("example %d: got %v (%s) but want %v (%s)", k,
+				game.Record.Result, game.Record.Reason, example.result, example.reason)
+		}
+	}
+}
+
+func TestRunSPRT(t *testing.T) {
+	options := tournament.Options{
+		Engines: []tournament.Engine{
+			builtinEngine("search", 1),
+			scriptedEngine("failing", func(bitsim.Game) (g4.Move, error) { return g4.Move{}, errors.New("crashed") }),
+		},
+		Openings:    tournament.RandomOpenings(rand.New(rand.NewSource(1)), 100, 2, bitsim.Rules{}),
+		Concurrency: 2,
+		SPRT:        &tournament.SPRT{Elo0: 0, Elo1: 10, Alpha: 0.05, Beta: 0.05},
+	}
+	results, err := tournament.Run(context.Background(), options, nil)
+	if err != nil {
+		t.Fatalf("error in Run: %v", err)
+	}
+	if results.Decision != tournament.AcceptH1 {
+		t.Errorf("got %v but want %v", results.Decision, tournament.AcceptH1)
+	}
+	if len(results.Games) >= 200 {
+		t.Errorf("got %d games, the test did not stop early", len(results.Games))
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	broken := tournament.Engine{Name: "broken", Start: func() (tournament.Player, error) {
+		return nil, errors.New("no such engine")
+	}}
+	first := scriptedEngine("first", firstMove)
+	examples := []tournament.Options{
+		{Engines: []tournament.Engine{first}},
+		{Engines: []tournament.Engine{first, first, first}, SPRT: &tournament.SPRT{Elo1: 10, Alpha: 0.05, Beta: 0.05}},
+		{Engines: []tournament.Engine{first, broken}},
+	}
+	for k, options := range examples {
+		if _, err := tournament.Run(context.Background(), options, nil); err == nil {
+			t.Errorf("example %d: got no error", k)
+		}
+	}
+}